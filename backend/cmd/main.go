@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
+	// the runtime image has no zoneinfo, user time zones are resolved from the embedded copy
+	_ "time/tzdata"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -12,6 +16,7 @@ import (
 	"dsa-helper-backend/internals/config"
 	"dsa-helper-backend/internals/datastore"
 	"dsa-helper-backend/internals/handlers"
+	"dsa-helper-backend/internals/leetcode"
 	"dsa-helper-backend/internals/middlewares"
 )
 
@@ -24,10 +29,17 @@ func main() {
 	if err != nil {
 		log.Println("Error: ", err)
 	}
-	firestoreDataStore := &datastore.Datastore{
-		FirestoreClient: firestoreClient,
+	store, err := datastore.NewStore(context.Background(), config.DatastoreConfig, firestoreClient)
+	if err != nil {
+		log.Fatal("Error initializing datastore:", err)
 	}
-	firestoreHandler := handlers.NewFirestoreHandler(firestoreDataStore)
+	log.Println("Using datastore backend:", config.DatastoreConfig.Backend)
+	storeHandler := handlers.NewHandler(store)
+	storeHandler.TrashRetention = time.Duration(config.DatastoreConfig.TrashRetentionDays) * 24 * time.Hour
+	leetCodeHTTP := &http.Client{Timeout: config.LeetCodeConfig.Timeout}
+	storeHandler.LeetCode = leetcode.NewClient(config.LeetCodeConfig.BaseURL, leetCodeHTTP)
+	storeHandler.LeetCodeCN = leetcode.NewRegionClient(leetcode.RegionCN, config.LeetCodeConfig.CNBaseURL, leetCodeHTTP)
+	go datastore.RunTrashPurge(context.Background(), store, storeHandler.TrashRetention, time.Hour)
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
@@ -42,18 +54,49 @@ func main() {
 	authenticated.Use(middlewares.FirebaseAuthMiddleware(firebaseAuthClient))
 
 	// submission analysis routes
-	authenticated.Get("/get-submissions", handlers.SubmissionFetchHandler(config.GeminiConfig))
-	authenticated.Post("/submission-feedback", firestoreHandler.SubmissionFeedbackHandler(config.GeminiConfig))
+	authenticated.Get("/get-submissions", storeHandler.SubmissionFetchHandler(config.GeminiConfig))
+	authenticated.Post("/submission-feedback", storeHandler.SubmissionFeedbackHandler(config.GeminiConfig))
 	authenticated.Post("/pattern-info", handlers.PatternInfoHandler(config.GeminiConfig))
-	authenticated.Post("/analyze-submission", firestoreHandler.AnalyseSubmissionHandler(config.GeminiConfig))
-	authenticated.Get("/overall-analysis", handlers.OverallAnalysisHandler(config.GeminiConfig))
+	authenticated.Post("/analyze-submission", storeHandler.AnalyseSubmissionHandler(config.GeminiConfig))
+	authenticated.Get("/overall-analysis", storeHandler.OverallAnalysisHandler(config.GeminiConfig))
+	authenticated.Get("/problems/{slug}", storeHandler.HandleGetProblem)
 
 	// revision data CRUD routes
-	authenticated.Post("/revisions", firestoreHandler.HandleAddRevisions)
-	authenticated.Get("/revisions", firestoreHandler.HandleGetRevisions)
-	authenticated.Delete("/revisions", firestoreHandler.HandleDeleteRevision)
-	authenticated.Put("/revisions", firestoreHandler.HandleUpdateRevision)
-	authenticated.Get("/revisions/due", firestoreHandler.HandleGetDueRevisions)
+	authenticated.Post("/revisions", storeHandler.HandleAddRevisions)
+	authenticated.Get("/revisions", storeHandler.HandleGetRevisions)
+	authenticated.Delete("/revisions", storeHandler.HandleDeleteRevision)
+	authenticated.Put("/revisions", storeHandler.HandleUpdateRevision)
+	authenticated.Post("/revisions:batch", storeHandler.HandleBatchRevisions)
+	authenticated.Post("/revisions/import", storeHandler.HandleImportRevisions)
+	authenticated.Get("/revisions/import/lists", storeHandler.HandleGetImportLists)
+	authenticated.Get("/revisions/due", storeHandler.HandleGetDueRevisions)
+	authenticated.Get("/revisions/forecast", storeHandler.HandleGetForecast)
+	authenticated.Get("/revisions/trash", storeHandler.HandleGetTrash)
+	authenticated.Get("/revisions/leeches", storeHandler.HandleGetLeeches(config.GeminiConfig))
+	authenticated.Post("/revisions/pause", storeHandler.HandlePauseRevisions)
+	authenticated.Post("/revisions/resume", storeHandler.HandleResumeRevisions)
+	authenticated.Get("/revisions/{problemId}", storeHandler.HandleGetRevision)
+	authenticated.Put("/revisions/{problemId}", storeHandler.HandleUpdateRevisionByID)
+	authenticated.Delete("/revisions/{problemId}", storeHandler.HandleDeleteRevisionByID)
+	authenticated.Post("/revisions/{problemId}/restore", storeHandler.HandleRestoreRevision)
+	authenticated.Get("/revisions/{problemId}/notes", storeHandler.HandleGetNoteVersions)
+	authenticated.Get("/revisions/{problemId}/notes/diff", storeHandler.HandleDiffNoteVersions)
+	authenticated.Post("/revisions/{problemId}/notes/{version}/restore", storeHandler.HandleRestoreNoteVersion)
+	authenticated.Post("/revisions/{problemId}/reviews", storeHandler.HandleAddReview)
+	authenticated.Get("/revisions/{problemId}/reviews", storeHandler.HandleGetReviews)
+
+	// revision deck routes
+	authenticated.Get("/decks", storeHandler.HandleGetDecks)
+	authenticated.Post("/decks", storeHandler.HandleCreateDeck)
+	authenticated.Put("/decks/{deckId}", storeHandler.HandleUpdateDeck)
+
+	// data portability routes
+	authenticated.Get("/export", storeHandler.HandleExport)
+	authenticated.Post("/import", storeHandler.HandleImport)
+
+	// user settings routes
+	authenticated.Get("/settings", storeHandler.HandleGetSettings)
+	authenticated.Put("/settings", storeHandler.HandleUpdateSettings)
 
 	// mount authenticated
 	r.Mount("/api", authenticated)
@@ -0,0 +1,58 @@
+// migrate-revisions converts the legacy revisions/{userID} documents, which held every
+// revision of a user in one array, into one document per problem under
+// users/{userID}/revisions. It then turns next_revision dates into timestamps and
+// backfills the problem slug of revisions that were stored before they were keyed by
+// problem. Every step can be rerun safely.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"dsa-helper-backend/internals/auth"
+	"dsa-helper-backend/internals/config"
+	"dsa-helper-backend/internals/datastore"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only report what would be migrated")
+	flag.Parse()
+
+	serverConfig, err := config.LoadServerConfig()
+	if err != nil {
+		log.Fatal("Error loading config:", err)
+	}
+	_, firestoreClient, err := backendAuth.InitializeFirebase(serverConfig.Mode)
+	if err != nil {
+		log.Fatal("Error initializing firebase:", err)
+	}
+	defer firestoreClient.Close()
+
+	ds := &datastore.Datastore{FirestoreClient: firestoreClient}
+	users, problems, err := ds.MigrateRevisionLists(context.Background(), *dryRun)
+	if err != nil {
+		log.Fatalf("Migration stopped after %d users (%d problems): %v", users, problems, err)
+	}
+	if *dryRun {
+		log.Printf("Dry run: would migrate %d problems for %d users", problems, users)
+		return
+	}
+	log.Printf("Migrated %d problems for %d users", problems, users)
+
+	converted, err := ds.ConvertNextRevisionTimestamps(context.Background())
+	if err != nil {
+		log.Fatalf("Timestamp conversion stopped after %d documents: %v", converted, err)
+	}
+	log.Printf("Converted next_revision of %d documents", converted)
+
+	moved, err := ds.BackfillProblemIDs(context.Background())
+	if errors.Is(err, datastore.ErrProblemIDCollision) {
+		log.Fatalf("Backfilled problem ids of %d revisions, these were left in place and need merging by hand:\n%v", moved, err)
+	}
+	if err != nil {
+		log.Fatalf("Problem id backfill stopped after %d revisions: %v", moved, err)
+	}
+	log.Printf("Backfilled problem ids of %d revisions", moved)
+}
@@ -11,12 +11,16 @@ import (
 	"fmt"
 	"google.golang.org/genai"
 	"log"
+	"strings"
 )
 
 type ToCheck struct {
 	ProblemId        int64  `json:"problem_id"`
 	ProblemStatement string `json:"problem_statement"`
 	CandidateCode    string `json:"candidate_code"`
+	// TitleSlug identifies the problem, when missing it is derived from ProblemStatement,
+	// which the frontend fills with the title
+	TitleSlug string `json:"title_slug"`
 }
 
 func GenerateSystemInstructionPrompt(caseType string) string {
@@ -77,6 +81,14 @@ func GenerateSystemInstructionPrompt(caseType string) string {
 				Optionally, identify any common conceptual mistakes related to specific patterns that appear across multiple submissions.
 				Your analysis should be a high-level overview based on the aggregate of submissions, not a detailed review of each individual piece of code. Do NOT provide optimal code solutions, detailed time/space complexity analysis for individual submissions, or line-by-line code feedback. Focus solely on identifying and analyzing the DSA patterns and providing targeted learning recommendations.
 				Your output must strictly adhere to the provided JSON schema.`
+	case "LeechExplanation":
+		return `You are a **patient coding tutor** helping a candidate who keeps failing to recall the solutions of some problems during spaced repetition reviews.
+				For every problem in the batch you receive its title, difficulty, tags, the number of failed reviews, the candidate's own notes and their last code.
+				Identify the **single concept or insight the candidate is most likely missing** for that problem, based on the notes and code where possible.
+				Explain that concept in a **short, targeted explanation** focused on why the solution works, not on the full solution code.
+				Give a few **concrete practice tips** to make the concept stick, such as related problems or the key question to ask when reading the statement.
+				Your entire response must be a **single JSON array** with one object per problem, in the same order as the input, echoing the given problem id.
+				Adhere strictly to the provided JSON array schema.`
 	default:
 		return `You are a **versatile AI assistant** specialized in code analysis and algorithmic problem-solving.
 				If a specific task is not defined, you will provide a general but insightful analysis based on the input problem statement and code.
@@ -168,7 +180,10 @@ func SubmissionFeedback(config *config.GeminiConfig, input *ToCheck) (feedback m
 	err = json.Unmarshal([]byte(result.Text()), &feedback)
 	return feedback, err
 }
-func HighLevelAnalysis(submissions []models.LeetCodeSubmission, config config.GeminiConfig) ([]models.LeetCodeSubmission, error) {
+
+// HighLevelAnalysis fills the complexity fields of the accepted submissions, the statements
+// of the problems found in problems, keyed by slug, are given to the model
+func HighLevelAnalysis(submissions []models.LeetCodeSubmission, problems map[string]models.ProblemMetadata, config config.GeminiConfig) ([]models.LeetCodeSubmission, error) {
 	ctx := context.Background()
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
 		APIKey:  config.APIKey,
@@ -189,7 +204,7 @@ func HighLevelAnalysis(submissions []models.LeetCodeSubmission, config config.Ge
 		inputPrompt := "Analyze the following code submissions:\n\n"
 		for i, sub := range input {
 			inputPrompt += fmt.Sprintf("--- Submission %d ---\n", i+1)
-			inputPrompt += fmt.Sprintf("Problem Statement: %s\n", sub.Title)
+			inputPrompt += fmt.Sprintf("Problem Statement: %s\n", submissionStatement(sub, problems))
 			inputPrompt += fmt.Sprintf("Candidate Code:\n%s\n\n", sub.Code)
 		}
 		inputPrompt += "--- End of Submissions ---\n"
@@ -415,7 +430,7 @@ func GivePatternInfo(pattern string, language string, config config.GeminiConfig
 	}
 	return patternInfo, nil
 }
-func OverallAnalysis(submissions []models.LeetCodeSubmission, config config.GeminiConfig) (models.DSAPatternAnalysisResponse, error) {
+func OverallAnalysis(submissions []models.LeetCodeSubmission, problems map[string]models.ProblemMetadata, config config.GeminiConfig) (models.DSAPatternAnalysisResponse, error) {
 	ctx := context.Background()
 	analysisResult := models.DSAPatternAnalysisResponse{}
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
@@ -428,7 +443,7 @@ func OverallAnalysis(submissions []models.LeetCodeSubmission, config config.Gemi
 	inputPrompt := "Analyze the following code submissions:\n\n"
 	for i, sub := range submissions {
 		inputPrompt += fmt.Sprintf("--- Submission %d ---\n", i+1)
-		inputPrompt += fmt.Sprintf("Problem Statement: %s\n", sub.Title)
+		inputPrompt += fmt.Sprintf("Problem Statement: %s\n", submissionStatement(sub, problems))
 		inputPrompt += fmt.Sprintf("Candidate Code:\n%s\n\n", sub.Code)
 	}
 	inputPrompt += "--- End of Submissions ---\n"
@@ -482,3 +497,71 @@ func OverallAnalysis(submissions []models.LeetCodeSubmission, config config.Gemi
 
 	return analysisResult, nil
 }
+
+func ExplainLeeches(leeches []models.RevisionProblem, config config.GeminiConfig) ([]models.LeechExplanationResponse, error) {
+	ctx := context.Background()
+	explanations := []models.LeechExplanationResponse{}
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  config.APIKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return explanations, err
+	}
+	inputPrompt := "Explain what is being missed in the following problems:\n\n"
+	for i, leech := range leeches {
+		inputPrompt += fmt.Sprintf("--- Problem %d ---\n", i+1)
+		inputPrompt += fmt.Sprintf("Problem Id: %s\n", leech.Problem_id)
+		inputPrompt += fmt.Sprintf("Title: %s\n", leech.Title)
+		inputPrompt += fmt.Sprintf("Difficulty: %s\n", leech.Difficulty)
+		inputPrompt += fmt.Sprintf("Tags: %s\n", strings.Join(leech.Tags, ", "))
+		inputPrompt += fmt.Sprintf("Failed Reviews: %d\n", leech.Failure_count)
+		inputPrompt += fmt.Sprintf("Notes:\n%s\n", leech.Notes)
+		inputPrompt += fmt.Sprintf("Candidate Code:\n%s\n\n", leech.Code)
+	}
+	inputPrompt += "--- End of Problems ---\n"
+	result, err := client.Models.GenerateContent(ctx,
+		config.FlashSmall,
+		genai.Text(inputPrompt),
+		&genai.GenerateContentConfig{
+			ResponseMIMEType:  "application/json",
+			SystemInstruction: &genai.Content{Parts: []*genai.Part{{Text: GenerateSystemInstructionPrompt("LeechExplanation")}}},
+			ResponseSchema: &genai.Schema{
+				Type: genai.TypeArray,
+				Items: &genai.Schema{
+					Type: genai.TypeObject,
+					Properties: map[string]*genai.Schema{
+						"problemId": {
+							Type:        genai.TypeString,
+							Description: "The problem id given in the input.",
+						},
+						"missedConcept": {
+							Type:        genai.TypeString,
+							Description: "The concept or insight the candidate is most likely missing, in a few words.",
+						},
+						"explanation": {
+							Type:        genai.TypeString,
+							Description: "A short targeted explanation of the missed concept.",
+						},
+						"practiceTips": {
+							Type:        genai.TypeArray,
+							Description: "Concrete tips to practice the missed concept.",
+							Items:       &genai.Schema{Type: genai.TypeString},
+						},
+					},
+					Required: []string{"problemId", "missedConcept", "explanation", "practiceTips"},
+				},
+			},
+		},
+	)
+	if err != nil {
+		log.Println("Error generating content for leech explanation:", err)
+		return explanations, err
+	}
+	err = json.Unmarshal([]byte(result.Text()), &explanations)
+	if err != nil {
+		log.Println("Error unmarshalling leech explanation response:", err)
+		return explanations, err
+	}
+	return explanations, nil
+}
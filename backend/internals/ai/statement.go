@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"cmp"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ProblemStatement is what the prompts show as the problem statement: the statement LeetCode
+// publishes along with the difficulty and topics when meta is known, the title alone otherwise
+func ProblemStatement(title string, meta *models.ProblemMetadata) string {
+	if meta == nil {
+		return title
+	}
+	var b strings.Builder
+	b.WriteString(cmp.Or(meta.Title, title))
+	if meta.Difficulty != "" {
+		fmt.Fprintf(&b, " (%s)", meta.Difficulty)
+	}
+	if len(meta.TopicTags) > 0 {
+		fmt.Fprintf(&b, "\nTopics: %s", strings.Join(meta.TopicTags, ", "))
+	}
+	if text := htmlText(meta.Content); text != "" {
+		b.WriteString("\n")
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// submissionStatement is ProblemStatement for a submission, looked up in problems by slug
+func submissionStatement(sub models.LeetCodeSubmission, problems map[string]models.ProblemMetadata) string {
+	if meta, ok := problems[sub.ProblemSlug()]; ok {
+		return ProblemStatement(sub.Title, &meta)
+	}
+	return sub.Title
+}
+
+// blankLines matches the runs of empty lines left by nested block elements
+var blankLines = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+
+// htmlText turns the HTML statement of a problem into plain text for the prompts. Block
+// elements start a line, list items get a dash and superscripts a caret, so 10<sup>4</sup>
+// reads 10^4.
+func htmlText(content string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return content
+			}
+			return strings.TrimSpace(blankLines.ReplaceAllString(b.String(), "\n\n"))
+		case html.TextToken:
+			b.WriteString(strings.ReplaceAll(string(z.Text()), "\u00a0", " "))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "p", "div", "pre", "br", "ul", "ol":
+				b.WriteString("\n")
+			case "li":
+				b.WriteString("\n- ")
+			case "sup":
+				b.WriteString("^")
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "p", "div", "pre", "ul", "ol":
+				b.WriteString("\n")
+			}
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Package archive writes everything stored about the revisions of a user to a zip file and
+// restores it from one, into any storage backend.
+//
+// An archive holds:
+//
+//	manifest.json                           Manifest
+//	settings.json                           models.UserSettings
+//	decks.json                              []models.Deck
+//	revisions.json                          []models.RevisionProblem
+//	reviews/{problem}.json                  []models.ReviewRecord
+//	notes/{problem}.json                    []models.NoteVersion
+//	analyses/submission_feedback/{id}.json  models.SubmissionFeedbackResponse
+//	analyses/analyse_submission/{id}.json   models.AnalyseSubmissionResponse
+//	problems/{problem}.md                   a Markdown rendering of each problem
+//
+// Analyses are keyed by the LeetCode submission ID of the problem. The Markdown files are
+// for reading only, a restore ignores them.
+package archive
+
+import (
+	"archive/zip"
+	"context"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the format version written to the manifest, archives of a newer format are rejected
+const Version = 1
+
+// maxEntryBytes bounds the uncompressed size of a single archive entry
+const maxEntryBytes = 32 << 20
+
+// Names of the archive entries
+const (
+	manifestFile  = "manifest.json"
+	settingsFile  = "settings.json"
+	decksFile     = "decks.json"
+	revisionsFile = "revisions.json"
+	reviewsDir    = "reviews/"
+	notesDir      = "notes/"
+	feedbackDir   = "analyses/submission_feedback/"
+	analysisDir   = "analyses/analyse_submission/"
+	problemsDir   = "problems/"
+)
+
+// Manifest describes an archive
+type Manifest struct {
+	Version     int       `json:"version"`
+	Exported_at time.Time `json:"exported_at"`
+	Problems    int       `json:"problems"`
+}
+
+// Export is an archive about to be written. NewExport reads the revisions up front so a
+// failing store can still be answered with an error status, Write then streams the rest.
+type Export struct {
+	store    datastore.Store
+	settings models.UserSettings
+	decks    []models.Deck
+	problems []models.RevisionProblem
+	userID   string
+}
+
+// NewExport reads the settings, decks and revisions of the user
+func NewExport(ctx context.Context, store datastore.Store, userID string) (*Export, error) {
+	settings, err := store.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	decks, err := store.GetDecks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	problems, err := store.GetRevisionProblems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &Export{store: store, settings: settings, decks: decks, problems: problems, userID: userID}, nil
+}
+
+// Write writes the archive to w, the history and analyses of each problem are read as it goes
+func (e *Export) Write(ctx context.Context, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	manifest := Manifest{Version: Version, Exported_at: time.Now().UTC(), Problems: len(e.problems)}
+	entries := []struct {
+		name  string
+		value any
+	}{
+		{manifestFile, manifest},
+		{settingsFile, e.settings},
+		{decksFile, e.decks},
+		{revisionsFile, e.problems},
+	}
+	for _, entry := range entries {
+		if err := writeJSON(zw, entry.name, entry.value); err != nil {
+			return err
+		}
+	}
+	for _, problem := range e.problems {
+		if err := e.writeProblem(ctx, zw, problem); err != nil {
+			return fmt.Errorf("failed to export %s: %w", problem.Problem_id, err)
+		}
+	}
+	return zw.Close()
+}
+
+func (e *Export) writeProblem(ctx context.Context, zw *zip.Writer, problem models.RevisionProblem) error {
+	name := url.PathEscape(problem.Problem_id) + ".json"
+	reviews, err := e.store.GetReviews(ctx, e.userID, problem.Problem_id)
+	if err != nil {
+		return err
+	}
+	if len(reviews) > 0 {
+		if err := writeJSON(zw, reviewsDir+name, reviews); err != nil {
+			return err
+		}
+	}
+	notes, err := e.store.GetNoteVersions(ctx, e.userID, problem.Problem_id)
+	if err != nil {
+		return err
+	}
+	if len(notes) > 0 {
+		if err := writeJSON(zw, notesDir+name, notes); err != nil {
+			return err
+		}
+	}
+	var feedback *models.SubmissionFeedbackResponse
+	var analysis *models.AnalyseSubmissionResponse
+	if problem.ID != 0 {
+		id := strconv.FormatInt(problem.ID, 10)
+		if feedback, err = cachedAnalysis[models.SubmissionFeedbackResponse](ctx, e.store, datastore.SubmissionFeedbackCollection, id); err != nil {
+			return err
+		}
+		if feedback != nil {
+			if err := writeJSON(zw, feedbackDir+id+".json", feedback); err != nil {
+				return err
+			}
+		}
+		if analysis, err = cachedAnalysis[models.AnalyseSubmissionResponse](ctx, e.store, datastore.AnalyseSubmissionCollection, id); err != nil {
+			return err
+		}
+		if analysis != nil {
+			if err := writeJSON(zw, analysisDir+id+".json", analysis); err != nil {
+				return err
+			}
+		}
+	}
+	f, err := zw.Create(problemsDir + url.PathEscape(problem.Problem_id) + ".md")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, Markdown(problem, reviews, feedback, analysis))
+	return err
+}
+
+// cachedAnalysis returns the analysis stored under id, nil when there is none
+func cachedAnalysis[T datastore.AnalysisTypes](ctx context.Context, store datastore.Store, collectionName string, id string) (*T, error) {
+	analysis, err := datastore.GetAnalysisProblems[T](ctx, id, store, collectionName)
+	if errors.Is(err, datastore.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &analysis, nil
+}
+
+func writeJSON(zw *zip.Writer, name string, value any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// Archive is the content of an archive read back by Read
+type Archive struct {
+	Manifest  Manifest
+	Settings  *models.UserSettings
+	Decks     []models.Deck
+	Revisions []models.RevisionProblem
+	// Reviews and Notes are keyed by Problem_id
+	Reviews map[string][]models.ReviewRecord
+	Notes   map[string][]models.NoteVersion
+	// Feedback and Analyses are keyed by submission ID
+	Feedback map[string]models.SubmissionFeedbackResponse
+	Analyses map[string]models.AnalyseSubmissionResponse
+}
+
+// Read parses an archive written by Export.Write
+func Read(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{
+		Reviews:  map[string][]models.ReviewRecord{},
+		Notes:    map[string][]models.NoteVersion{},
+		Feedback: map[string]models.SubmissionFeedbackResponse{},
+		Analyses: map[string]models.AnalyseSubmissionResponse{},
+	}
+	hasManifest := false
+	for _, f := range zr.File {
+		var err error
+		switch name := f.Name; {
+		case name == manifestFile:
+			hasManifest = true
+			err = readJSON(f, &a.Manifest)
+		case name == settingsFile:
+			a.Settings = &models.UserSettings{}
+			err = readJSON(f, a.Settings)
+		case name == decksFile:
+			err = readJSON(f, &a.Decks)
+		case name == revisionsFile:
+			err = readJSON(f, &a.Revisions)
+		case strings.HasPrefix(name, reviewsDir):
+			var reviews []models.ReviewRecord
+			if err = readJSON(f, &reviews); err == nil {
+				for _, review := range reviews {
+					a.Reviews[review.Problem_id] = append(a.Reviews[review.Problem_id], review)
+				}
+			}
+		case strings.HasPrefix(name, notesDir):
+			var notes []models.NoteVersion
+			if err = readJSON(f, &notes); err == nil {
+				for _, v := range notes {
+					a.Notes[v.Problem_id] = append(a.Notes[v.Problem_id], v)
+				}
+			}
+		case strings.HasPrefix(name, feedbackDir):
+			var feedback models.SubmissionFeedbackResponse
+			if err = readJSON(f, &feedback); err == nil {
+				a.Feedback[entryID(name)] = feedback
+			}
+		case strings.HasPrefix(name, analysisDir):
+			var analysis models.AnalyseSubmissionResponse
+			if err = readJSON(f, &analysis); err == nil {
+				a.Analyses[entryID(name)] = analysis
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	if !hasManifest {
+		return nil, errors.New("not an export archive, manifest.json is missing")
+	}
+	if a.Manifest.Version > Version {
+		return nil, fmt.Errorf("archive format %d is newer than the supported format %d", a.Manifest.Version, Version)
+	}
+	return a, nil
+}
+
+func readJSON(f *zip.File, dst any) error {
+	if f.UncompressedSize64 > maxEntryBytes {
+		return fmt.Errorf("entry is larger than %d bytes", maxEntryBytes)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(io.LimitReader(rc, maxEntryBytes)).Decode(dst)
+}
+
+// entryID is the file name of an entry without its extension
+func entryID(name string) string {
+	return strings.TrimSuffix(path.Base(name), path.Ext(name))
+}
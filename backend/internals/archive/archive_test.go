@@ -0,0 +1,150 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExportRestoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := datastore.NewMemoryStore()
+	settings := models.UserSettings{UserID: "u", Scheduler: "fsrs", Timezone: "Europe/Berlin", DailyReviewLimit: 20}
+	if err := source.SaveUserSettings(ctx, settings); err != nil {
+		t.Fatal(err)
+	}
+	deck, err := source.CreateDeck(ctx, "u", models.Deck{Name: "Graphs", Scheduler: "fixed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	err = source.AddRevisionProblems(ctx, "u", []models.RevisionProblem{
+		{
+			LeetCodeSubmission: models.LeetCodeSubmission{Title: "Course Schedule", ID: 1001, Lang: "golang"},
+			Notes:              "topological sort",
+			Confidence_level:   3,
+			Next_revision:      due,
+			Tags:               []string{"Graph"},
+			Decks:              []string{deck.ID},
+		},
+		{
+			LeetCodeSubmission: models.LeetCodeSubmission{Title: "Two Sum"},
+			Confidence_level:   4,
+			Next_revision:      due,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	problem, err := source.GetRevisionProblem(ctx, "u", "course-schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	problem.Notes = "topological sort, Kahn's algorithm"
+	if _, err := source.UpdateRevisionProblem(ctx, "u", problem); err != nil {
+		t.Fatal(err)
+	}
+	problem, err = source.GetRevisionProblem(ctx, "u", "course-schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	problem.Revision_count++
+	review := models.ReviewRecord{Problem_id: problem.Problem_id, Reviewed_at: due, Grade: 4, Next_revision: due.AddDate(0, 0, 7)}
+	if _, _, err := source.RecordReview(ctx, "u", problem, review); err != nil {
+		t.Fatal(err)
+	}
+	feedback := models.SubmissionFeedbackResponse{CorrectnessAndLogic: "correct"}
+	if err := datastore.AddAnalysisProblems(ctx, source, feedback, "1001", datastore.SubmissionFeedbackCollection); err != nil {
+		t.Fatal(err)
+	}
+
+	export, err := NewExport(ctx, source, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := export.Write(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := datastore.NewMemoryStore()
+	report, err := Restore(ctx, target, "w", archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// adding two-sum wrote its first, empty, notes version
+	if report != (RestoreReport{Problems: 2, Reviews: 1, Notes: 3, Decks: 1, Analyses: 1}) {
+		t.Fatalf("restore report %+v", report)
+	}
+
+	restoredSettings, err := target.GetUserSettings(ctx, "w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.UserID = "w"
+	if restoredSettings != settings {
+		t.Errorf("settings %+v, want %+v", restoredSettings, settings)
+	}
+	decks, err := target.GetDecks(ctx, "w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decks) != 1 || decks[0].Name != "Graphs" || decks[0].Scheduler != "fixed" {
+		t.Fatalf("decks %+v", decks)
+	}
+	want, err := source.GetRevisionProblems(ctx, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range want {
+		got, err := target.GetRevisionProblem(ctx, "w", w.Problem_id)
+		if err != nil {
+			t.Fatalf("%s: %v", w.Problem_id, err)
+		}
+		if got.Title != w.Title || got.ID != w.ID || got.Notes != w.Notes || got.Confidence_level != w.Confidence_level ||
+			got.Revision_count != w.Revision_count || !got.Next_revision.Equal(w.Next_revision) ||
+			got.Schedule != w.Schedule || !slices.Equal(got.Tags, w.Tags) {
+			t.Errorf("%s restored as %+v, want %+v", w.Problem_id, got, w)
+		}
+		if len(w.Decks) > 0 && !slices.Equal(got.Decks, []string{decks[0].ID}) {
+			t.Errorf("%s restored in decks %v, want %v", w.Problem_id, got.Decks, decks[0].ID)
+		}
+	}
+	reviews, err := target.GetReviews(ctx, "w", "course-schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 1 || reviews[0].Grade != 4 || !reviews[0].Reviewed_at.Equal(due) {
+		t.Errorf("reviews %+v", reviews)
+	}
+	notes, err := target.GetNoteVersions(ctx, "w", "course-schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 || !slices.ContainsFunc(notes, func(v models.NoteVersion) bool { return v.Notes == "topological sort" }) {
+		t.Errorf("note versions %+v", notes)
+	}
+	restoredFeedback, err := datastore.GetAnalysisProblems[models.SubmissionFeedbackResponse](ctx, "1001", target, datastore.SubmissionFeedbackCollection)
+	if err != nil || restoredFeedback.CorrectnessAndLogic != "correct" {
+		t.Errorf("feedback %+v, %v", restoredFeedback, err)
+	}
+
+	// restoring again replaces the problems instead of duplicating them
+	if _, err := Restore(ctx, target, "w", archive); err != nil {
+		t.Fatal(err)
+	}
+	all, err := target.GetRevisionProblems(ctx, "w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("%d problems after a second restore, want 2", len(all))
+	}
+}
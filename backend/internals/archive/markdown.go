@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"strings"
+)
+
+// Markdown renders a problem with its review history and cached analyses for reading outside
+// the app, feedback and analysis may be nil
+func Markdown(problem models.RevisionProblem, reviews []models.ReviewRecord, feedback *models.SubmissionFeedbackResponse, analysis *models.AnalyseSubmissionResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", problem.Title)
+	if problem.URL != "" {
+		fmt.Fprintf(&b, "- Link: %s\n", problem.URL)
+	}
+	if problem.Difficulty != "" {
+		fmt.Fprintf(&b, "- Difficulty: %s\n", problem.Difficulty)
+	}
+	if len(problem.Tags) > 0 {
+		fmt.Fprintf(&b, "- Tags: %s\n", strings.Join(problem.Tags, ", "))
+	}
+	fmt.Fprintf(&b, "- Confidence: %d/5\n", problem.Confidence_level)
+	fmt.Fprintf(&b, "- Revisions: %d\n", problem.Revision_count)
+	if problem.Last_revised != "" {
+		fmt.Fprintf(&b, "- Last revised: %s\n", problem.Last_revised)
+	}
+	if !problem.Next_revision.IsZero() {
+		fmt.Fprintf(&b, "- Next revision: %s\n", problem.Next_revision.Format("2006-01-02"))
+	}
+
+	fmt.Fprintf(&b, "\n## Notes\n\n%s\n", problem.Notes)
+	if problem.Code != "" {
+		fmt.Fprintf(&b, "\n## Code\n\n%s\n", codeBlock(problem.Lang, problem.Code))
+	}
+
+	if len(reviews) > 0 {
+		b.WriteString("\n## Review history\n\n| Date | Grade | Time spent | Notes added |\n| --- | --- | --- | --- |\n")
+		for _, r := range reviews {
+			fmt.Fprintf(&b, "| %s | %d | %ds | %s |\n", r.Reviewed_at.Format("2006-01-02"), r.Grade, r.Time_spent_seconds, tableCell(r.Notes_delta))
+		}
+	}
+
+	if feedback != nil {
+		b.WriteString("\n## Submission feedback\n")
+		section(&b, "Correctness and logic", feedback.CorrectnessAndLogic)
+		section(&b, "Time complexity", feedback.TimeComplexityAnalysis)
+		section(&b, "Space complexity", feedback.SpaceComplexityAnalysis)
+		section(&b, "Code style and readability", feedback.CodeStyleAndReadability)
+		section(&b, "Alternative approaches", feedback.AlternativeApproaches)
+	}
+	if analysis != nil {
+		b.WriteString("\n## Submission analysis\n")
+		section(&b, "Algorithm", analysis.Algorithmic)
+		section(&b, "Complexity", analysis.Complexity)
+		section(&b, "Patterns", analysis.Patterns)
+		for i, step := range analysis.Steps {
+			body := step.Description
+			if step.Code != "" {
+				body += "\n\n" + codeBlock(problem.Lang, step.Code)
+			}
+			section(&b, fmt.Sprintf("Step %d: %s", i+1, step.Title), body)
+		}
+		if analysis.OptimalCode != "" {
+			section(&b, "Optimal code", codeBlock(problem.Lang, analysis.OptimalCode))
+		}
+	}
+	return b.String()
+}
+
+func section(b *strings.Builder, title string, body string) {
+	if body == "" {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n%s\n", title, body)
+}
+
+// codeBlock fences code with a fence longer than any backtick run inside it
+func codeBlock(lang string, code string) string {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(code, "\n") + "\n" + fence
+}
+
+// tableCell keeps text on one line of a Markdown table
+func tableCell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,115 @@
+package archive
+
+import (
+	"context"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"strings"
+)
+
+// RestoreReport counts what a restore wrote
+type RestoreReport struct {
+	Problems int `json:"problems"`
+	Reviews  int `json:"reviews"`
+	Notes    int `json:"notes"`
+	// Decks counts the decks that had to be created, decks are matched to the existing ones by name
+	Decks    int `json:"decks"`
+	Analyses int `json:"analyses"`
+}
+
+// Restore writes an archive into store for userID. The settings of the archive replace the
+// current ones, its revisions replace the stored problems with the same ID and the others
+// are kept. Deck IDs are rewritten to the decks of the user with the same name.
+func Restore(ctx context.Context, store datastore.Store, userID string, a *Archive) (RestoreReport, error) {
+	var report RestoreReport
+	if a.Settings != nil {
+		settings := *a.Settings
+		settings.UserID = userID
+		if err := store.SaveUserSettings(ctx, settings); err != nil {
+			return report, fmt.Errorf("failed to restore settings: %w", err)
+		}
+	}
+	deckIDs, created, err := restoreDecks(ctx, store, userID, a.Decks)
+	report.Decks = created
+	if err != nil {
+		return report, fmt.Errorf("failed to restore decks: %w", err)
+	}
+
+	restored := make([]datastore.RestoredRevision, 0, len(a.Revisions))
+	for _, problem := range a.Revisions {
+		if models.ValidateProblemID(problem.EnsureProblemID()) != nil {
+			continue
+		}
+		var decks []string
+		for _, id := range problem.Decks {
+			if deckID, ok := deckIDs[id]; ok {
+				decks = append(decks, deckID)
+			}
+		}
+		problem.Decks = decks
+		r := datastore.RestoredRevision{
+			Problem: problem,
+			Reviews: a.Reviews[problem.Problem_id],
+			Notes:   a.Notes[problem.Problem_id],
+		}
+		report.Reviews += len(r.Reviews)
+		report.Notes += len(r.Notes)
+		restored = append(restored, r)
+	}
+	if len(restored) > 0 {
+		if err := store.RestoreRevisionProblems(ctx, userID, restored); err != nil {
+			return report, err
+		}
+	}
+	report.Problems = len(restored)
+
+	for id, feedback := range a.Feedback {
+		if err := datastore.AddAnalysisProblems(ctx, store, feedback, id, datastore.SubmissionFeedbackCollection); err != nil {
+			return report, err
+		}
+		report.Analyses++
+	}
+	for id, analysis := range a.Analyses {
+		if err := datastore.AddAnalysisProblems(ctx, store, analysis, id, datastore.AnalyseSubmissionCollection); err != nil {
+			return report, err
+		}
+		report.Analyses++
+	}
+	return report, nil
+}
+
+// restoreDecks maps the deck IDs of the archive to decks of the user, creating the decks
+// whose name the user does not have yet. It returns the mapping and how many were created.
+func restoreDecks(ctx context.Context, store datastore.Store, userID string, decks []models.Deck) (map[string]string, int, error) {
+	existing, err := store.GetDecks(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	byName := make(map[string]string, len(existing))
+	for _, d := range existing {
+		byName[strings.ToLower(d.Name)] = d.ID
+	}
+	deckIDs := make(map[string]string, len(decks))
+	created := 0
+	for _, deck := range decks {
+		name := strings.ToLower(strings.TrimSpace(deck.Name))
+		if name == "" {
+			continue
+		}
+		if id, ok := byName[name]; ok {
+			deckIDs[deck.ID] = id
+			continue
+		}
+		oldID := deck.ID
+		deck.Name = strings.TrimSpace(deck.Name)
+		deck, err := store.CreateDeck(ctx, userID, deck)
+		if err != nil {
+			return deckIDs, created, err
+		}
+		created++
+		byName[name] = deck.ID
+		deckIDs[oldID] = deck.ID
+	}
+	return deckIDs, created, nil
+}
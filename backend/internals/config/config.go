@@ -1,15 +1,20 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	GeminiConfig GeminiConfig
-	ServerConfig ServerConfig
+	GeminiConfig    GeminiConfig
+	ServerConfig    ServerConfig
+	DatastoreConfig DatastoreConfig
+	LeetCodeConfig  LeetCodeConfig
 }
 
 // Config holds the configuration for the application
@@ -19,6 +24,30 @@ type ServerConfig struct {
 	AllowedOrigins []string
 }
 
+// supported values for DatastoreConfig.Backend
+const (
+	BackendFirestore = "firestore"
+	BackendMemory    = "memory"
+	BackendSQLite    = "sqlite"
+	BackendPostgres  = "postgres"
+)
+
+type DatastoreConfig struct {
+	Backend     string `json:"datastore"`
+	SQLitePath  string `json:"sqlite_path"`
+	PostgresDSN string `json:"postgres_dsn"`
+	// TrashRetentionDays is how long deleted revisions can be restored before they are purged
+	TrashRetentionDays int `json:"trash_retention_days"`
+}
+
+// LeetCodeConfig points the LeetCode clients at leetcode.com and leetcode.cn, fake ones in
+// local setups
+type LeetCodeConfig struct {
+	BaseURL   string        `json:"leetcode_base_url"`
+	CNBaseURL string        `json:"leetcode_cn_base_url"`
+	Timeout   time.Duration `json:"leetcode_timeout"`
+}
+
 type GeminiConfig struct {
 	APIKey     string `json:"gemini_api_key"`
 	FlashBig   string `json:"gemini_flash_big"`
@@ -35,9 +64,19 @@ func LoadConfig() (config Config, err error) {
 	if err != nil {
 		return config, err
 	}
+	datastoreConfig, err := LoadDatastoreConfig(serverConfig.Mode)
+	if err != nil {
+		return config, err
+	}
+	leetCodeConfig, err := LoadLeetCodeConfig()
+	if err != nil {
+		return config, err
+	}
 	return Config{
-		ServerConfig: *serverConfig,
-		GeminiConfig: *geminiConfig,
+		ServerConfig:    *serverConfig,
+		GeminiConfig:    *geminiConfig,
+		DatastoreConfig: *datastoreConfig,
+		LeetCodeConfig:  *leetCodeConfig,
 	}, nil
 }
 
@@ -68,6 +107,55 @@ func LoadGeminiConfig() (*GeminiConfig, error) {
 	}, nil
 }
 
+// LoadDatastoreConfig picks the storage backend, LOCAL mode defaults to an embedded sqlite file
+func LoadDatastoreConfig(mode string) (*DatastoreConfig, error) {
+	defaultBackend := BackendFirestore
+	if mode == "LOCAL" {
+		defaultBackend = BackendSQLite
+	}
+	backend := strings.ToLower(LoadFromEnv("DATASTORE", defaultBackend))
+	switch backend {
+	case BackendFirestore, BackendMemory, BackendSQLite, BackendPostgres:
+	default:
+		return nil, fmt.Errorf("unsupported DATASTORE %q", backend)
+	}
+	postgresDSN := LoadFromEnv("DATABASE_URL", "")
+	if backend == BackendPostgres && postgresDSN == "" {
+		return nil, fmt.Errorf("DATABASE_URL is required for the postgres datastore")
+	}
+	trashRetentionDays := LoadFromEnvInt("TRASH_RETENTION_DAYS", 30)
+	if trashRetentionDays < 1 {
+		return nil, fmt.Errorf("TRASH_RETENTION_DAYS must be at least 1, got %d", trashRetentionDays)
+	}
+	return &DatastoreConfig{
+		Backend:            backend,
+		SQLitePath:         LoadFromEnv("SQLITE_PATH", "dsa-helper.db"),
+		PostgresDSN:        postgresDSN,
+		TrashRetentionDays: trashRetentionDays,
+	}, nil
+}
+
+// LoadLeetCodeConfig reads the LeetCode site, LEETCODE_TIMEOUT_SECONDS bounds each request
+func LoadLeetCodeConfig() (*LeetCodeConfig, error) {
+	baseURL := LoadFromEnv("LEETCODE_BASE_URL", "https://leetcode.com")
+	if _, err := url.ParseRequestURI(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid LEETCODE_BASE_URL %q: %w", baseURL, err)
+	}
+	cnBaseURL := LoadFromEnv("LEETCODE_CN_BASE_URL", "https://leetcode.cn")
+	if _, err := url.ParseRequestURI(cnBaseURL); err != nil {
+		return nil, fmt.Errorf("invalid LEETCODE_CN_BASE_URL %q: %w", cnBaseURL, err)
+	}
+	timeoutSeconds := LoadFromEnvInt("LEETCODE_TIMEOUT_SECONDS", 30)
+	if timeoutSeconds < 1 {
+		return nil, fmt.Errorf("LEETCODE_TIMEOUT_SECONDS must be at least 1, got %d", timeoutSeconds)
+	}
+	return &LeetCodeConfig{
+		BaseURL:   baseURL,
+		CNBaseURL: cnBaseURL,
+		Timeout:   time.Duration(timeoutSeconds) * time.Second,
+	}, nil
+}
+
 func LoadFromEnv(env string, defaultValue string) string {
 	env, ok := os.LookupEnv(env)
 	if !ok {
@@ -5,26 +5,39 @@ import (
 	"dsa-helper-backend/internals/models"
 	"fmt"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type AnalysisTypes interface {
-	models.HighLevelAnalysisResponse | models.AnalyseSubmissionResponse | models.SubmissionFeedbackResponse
+	models.HighLevelAnalysisResponse | models.AnalyseSubmissionResponse | models.SubmissionFeedbackResponse | models.ProblemMetadata
 }
 
-func AddAnalysisProblems[T AnalysisTypes](ctx context.Context, ds *Datastore, toAdd T, title string, collectionName string) error {
-	_, err := ds.FirestoreClient.Collection(collectionName).Doc(title).Set(ctx, toAdd)
+func AddAnalysisProblems[T AnalysisTypes](ctx context.Context, ds Store, toAdd T, title string, collectionName string) error {
+	return ds.AddAnalysisProblems(ctx, collectionName, title, toAdd)
+}
+
+func GetAnalysisProblems[T AnalysisTypes](ctx context.Context, title string, ds Store, collectionName string) (T, error) {
+	var retrievedProblem T
+	err := ds.GetAnalysisProblems(ctx, collectionName, title, &retrievedProblem)
+	return retrievedProblem, err
+}
+
+func (ds *Datastore) AddAnalysisProblems(ctx context.Context, collectionName string, id string, toAdd any) error {
+	_, err := ds.FirestoreClient.Collection(collectionName).Doc(id).Set(ctx, toAdd)
 	if err != nil {
-		return fmt.Errorf("failed to add revision problems: %w", err)
+		return fmt.Errorf("failed to add analysis problem: %w", err)
 	}
 	return nil
 }
 
-func GetAnalysisProblems[T AnalysisTypes](ctx context.Context, title string, ds *Datastore, collectionName string) (T, error) {
-	var retrievedProblem T
-	dsnap, err := ds.FirestoreClient.Collection(collectionName).Doc(title).Get(ctx)
+func (ds *Datastore) GetAnalysisProblems(ctx context.Context, collectionName string, id string, dst any) error {
+	dsnap, err := ds.FirestoreClient.Collection(collectionName).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
 	if err != nil {
-		return retrievedProblem, err
+		return err
 	}
-	dsnap.DataTo(&retrievedProblem)
-	return retrievedProblem, nil
+	return dsnap.DataTo(dst)
 }
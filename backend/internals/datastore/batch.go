@@ -0,0 +1,98 @@
+package datastore
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+)
+
+func (ds *Datastore) WriteRevisionProblems(ctx context.Context, userID string, writes []RevisionWrite) ([]models.RevisionProblem, error) {
+	written := make([]models.RevisionProblem, len(writes))
+	err := ds.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		// the trash copies come after the revisions, they carry the version of deleted problems
+		docs := make([]*firestore.DocumentRef, 2*len(writes))
+		for i := range writes {
+			problemID := writes[i].Problem.EnsureProblemID()
+			docs[i] = ds.revisionsCollection(userID).Doc(problemID)
+			docs[len(writes)+i] = ds.trashCollection(userID).Doc(problemID)
+		}
+		// all reads have to happen before the first write of a transaction, stored and
+		// versions track the state left by the earlier writes of the batch
+		snaps, err := tx.GetAll(docs)
+		if err != nil {
+			return err
+		}
+		stored := make(map[string]*models.RevisionProblem)
+		versions := make(map[string]int64)
+		for i, snap := range snaps[:len(writes)] {
+			if _, seen := stored[docs[i].ID]; seen {
+				continue
+			}
+			stored[docs[i].ID] = nil
+			if !snap.Exists() {
+				if versions[docs[i].ID], err = trashedVersion(snaps[len(writes)+i]); err != nil {
+					return err
+				}
+				continue
+			}
+			var current models.RevisionProblem
+			if err := snap.DataTo(&current); err != nil {
+				return fmt.Errorf("failed to parse revision problem: %w", err)
+			}
+			stored[docs[i].ID] = &current
+			versions[docs[i].ID] = current.Version
+		}
+		for i, write := range writes {
+			problem := write.Problem
+			current := stored[docs[i].ID]
+			var err error
+			switch write.Kind {
+			case WriteCreate:
+				err = problem.Preprocess()
+				problem.Version = versions[docs[i].ID] + 1
+			case WriteUpdate:
+				if current == nil {
+					err = ErrNotFound
+				} else if err = checkVersion(*current, problem); err == nil {
+					problem.Version = current.Version + 1
+				}
+			case WriteDelete:
+				if current != nil {
+					err = checkVersion(*current, problem)
+				}
+			default:
+				err = fmt.Errorf("unknown write kind %q", write.Kind)
+			}
+			if err != nil {
+				return &WriteError{Index: i, Err: err}
+			}
+			if write.Kind == WriteDelete {
+				if current != nil {
+					if err := ds.trashRevisionProblem(tx, userID, *current); err != nil {
+						return err
+					}
+				}
+				stored[docs[i].ID] = nil
+				err = tx.Delete(docs[i])
+			} else {
+				if err := ds.recordNotes(tx, userID, current, problem); err != nil {
+					return err
+				}
+				stored[docs[i].ID] = &problem
+				versions[docs[i].ID] = problem.Version
+				err = tx.Set(docs[i], problem)
+			}
+			if err != nil {
+				return err
+			}
+			written[i] = problem
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write revision problems: %w", err)
+	}
+	return written, nil
+}
@@ -0,0 +1,81 @@
+package datastore
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// decksCollection is users/{uid}/decks, revisions reference decks by document ID
+func (ds *Datastore) decksCollection(userID string) *firestore.CollectionRef {
+	return ds.userDoc(userID).Collection("decks")
+}
+
+func (ds *Datastore) CreateDeck(ctx context.Context, userID string, deck models.Deck) (models.Deck, error) {
+	doc := ds.decksCollection(userID).NewDoc()
+	deck.ID = doc.ID
+	deck.CreatedAt = time.Now().UTC()
+	if _, err := doc.Create(ctx, deck); err != nil {
+		return models.Deck{}, fmt.Errorf("failed to create deck: %w", err)
+	}
+	return deck, nil
+}
+
+func (ds *Datastore) GetDecks(ctx context.Context, userID string) ([]models.Deck, error) {
+	docs, err := ds.decksCollection(userID).OrderBy("created_at", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decks: %w", err)
+	}
+	decks := make([]models.Deck, 0, len(docs))
+	for _, doc := range docs {
+		var d models.Deck
+		if err := doc.DataTo(&d); err != nil {
+			return nil, fmt.Errorf("failed to parse deck: %w", err)
+		}
+		decks = append(decks, d)
+	}
+	return decks, nil
+}
+
+func (ds *Datastore) GetDeck(ctx context.Context, userID string, deckID string) (models.Deck, error) {
+	snap, err := ds.decksCollection(userID).Doc(deckID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return models.Deck{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Deck{}, fmt.Errorf("failed to get deck: %w", err)
+	}
+	var d models.Deck
+	if err := snap.DataTo(&d); err != nil {
+		return models.Deck{}, fmt.Errorf("failed to parse deck: %w", err)
+	}
+	return d, nil
+}
+
+func (ds *Datastore) UpdateDeck(ctx context.Context, userID string, deck models.Deck) error {
+	doc := ds.decksCollection(userID).Doc(deck.ID)
+	err := ds.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(doc)
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var current models.Deck
+		if err := snap.DataTo(&current); err != nil {
+			return err
+		}
+		deck.CreatedAt = current.CreatedAt
+		return tx.Set(doc, deck)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update deck: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,464 @@
+package datastore
+
+import (
+	"cmp"
+	"context"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps everything in process memory, it is meant for local development and tests
+type MemoryStore struct {
+	mu        sync.RWMutex
+	revisions map[string][]models.RevisionProblem
+	analyses  map[string]map[string][]byte
+	settings  map[string]models.UserSettings
+	// reviews is keyed by userID/problemID
+	reviews map[string][]models.ReviewRecord
+	decks   map[string][]models.Deck
+	// trash holds the deleted revisions of each user, oldest first
+	trash map[string][]models.TrashedRevision
+	// notes holds the notes history of each user by problem
+	notes map[string]map[string][]models.NoteVersion
+	// submissions holds the synced LeetCode submissions of each user by site and ID
+	submissions map[string]map[submissionKey]models.LeetCodeSubmission
+	syncStates  map[string]models.SubmissionSyncState
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		revisions:   make(map[string][]models.RevisionProblem),
+		analyses:    make(map[string]map[string][]byte),
+		settings:    make(map[string]models.UserSettings),
+		reviews:     make(map[string][]models.ReviewRecord),
+		decks:       make(map[string][]models.Deck),
+		trash:       make(map[string][]models.TrashedRevision),
+		notes:       make(map[string]map[string][]models.NoteVersion),
+		submissions: make(map[string]map[submissionKey]models.LeetCodeSubmission),
+		syncStates:  make(map[string]models.SubmissionSyncState),
+	}
+}
+
+func (ms *MemoryStore) AddRevisionProblems(ctx context.Context, userID string, newRevisions []models.RevisionProblem) error {
+	err := prepareRevisionProblems(newRevisions)
+	if err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.addRevisionProblems(userID, newRevisions)
+	return nil
+}
+
+// addRevisionProblems merges newRevisions into the revisions of the user, they end up first
+func (ms *MemoryStore) addRevisionProblems(userID string, newRevisions []models.RevisionProblem) {
+	before := ms.revisions[userID]
+	merged := mergeRevisionProblems(before, newRevisions)
+	for i := range newRevisions {
+		problem := &merged[i]
+		var current *models.RevisionProblem
+		if j := slices.IndexFunc(before, func(p models.RevisionProblem) bool { return p.Problem_id == problem.Problem_id }); j >= 0 {
+			current = &before[j]
+		} else if history := ms.notes[userID][problem.Problem_id]; len(history) > 0 {
+			// a problem added again after a delete continues its notes history
+			problem.Version = history[len(history)-1].Version + 1
+		}
+		ms.recordNotes(userID, current, *problem)
+	}
+	ms.revisions[userID] = merged
+}
+
+// recordNotes adds the notes of problem to its history when they differ from current
+func (ms *MemoryStore) recordNotes(userID string, current *models.RevisionProblem, problem models.RevisionProblem) {
+	if !notesChanged(current, problem) {
+		return
+	}
+	if ms.notes[userID] == nil {
+		ms.notes[userID] = make(map[string][]models.NoteVersion)
+	}
+	ms.notes[userID][problem.Problem_id] = append(ms.notes[userID][problem.Problem_id], models.NoteVersion{
+		Problem_id: problem.Problem_id,
+		Version:    problem.Version,
+		Notes:      problem.Notes,
+		Created_at: time.Now().UTC(),
+	})
+}
+
+func (ms *MemoryStore) GetNoteVersions(ctx context.Context, userID string, problemID string) ([]models.NoteVersion, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return append([]models.NoteVersion{}, ms.notes[userID][problemID]...), nil
+}
+
+func (ms *MemoryStore) GetNoteVersion(ctx context.Context, userID string, problemID string, version int64) (models.NoteVersion, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	for _, v := range ms.notes[userID][problemID] {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return models.NoteVersion{}, ErrNotFound
+}
+
+func (ms *MemoryStore) GetRevisionProblems(ctx context.Context, userID string) ([]models.RevisionProblem, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return append([]models.RevisionProblem(nil), ms.revisions[userID]...), nil
+}
+
+func (ms *MemoryStore) QueryRevisionProblems(ctx context.Context, userID string, q RevisionQuery) (RevisionPage, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return applyRevisionQuery(ms.revisions[userID], q)
+}
+
+func (ms *MemoryStore) GetRevisionProblem(ctx context.Context, userID string, problemID string) (models.RevisionProblem, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	for _, p := range ms.revisions[userID] {
+		if p.Problem_id == problemID {
+			return p, nil
+		}
+	}
+	return models.RevisionProblem{}, ErrNotFound
+}
+
+func (ms *MemoryStore) DeleteRevisionProblem(ctx context.Context, userID string, problem models.RevisionProblem) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.deleteRevisionProblem(userID, problem)
+}
+
+func (ms *MemoryStore) deleteRevisionProblem(userID string, problem models.RevisionProblem) error {
+	problem.EnsureProblemID()
+	var updatedProblems []models.RevisionProblem
+	for _, p := range ms.revisions[userID] {
+		if p.Problem_id != problem.Problem_id {
+			updatedProblems = append(updatedProblems, p)
+			continue
+		}
+		if err := checkVersion(p, problem); err != nil {
+			return err
+		}
+		ms.trashRevisionProblem(userID, p)
+	}
+	ms.revisions[userID] = updatedProblems
+	return nil
+}
+
+// trashRevisionProblem replaces any older copy of problem in the trash
+func (ms *MemoryStore) trashRevisionProblem(userID string, problem models.RevisionProblem) {
+	trash := slices.DeleteFunc(ms.trash[userID], func(t models.TrashedRevision) bool {
+		return t.Problem_id == problem.Problem_id
+	})
+	ms.trash[userID] = append(trash, models.TrashedRevision{RevisionProblem: problem, Deleted_at: time.Now().UTC()})
+}
+
+func (ms *MemoryStore) GetTrash(ctx context.Context, userID string) ([]models.TrashedRevision, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	trash := make([]models.TrashedRevision, 0, len(ms.trash[userID]))
+	for i := len(ms.trash[userID]) - 1; i >= 0; i-- {
+		trash = append(trash, ms.trash[userID][i])
+	}
+	return trash, nil
+}
+
+func (ms *MemoryStore) RestoreRevisionProblem(ctx context.Context, userID string, problemID string) (models.RevisionProblem, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	i := slices.IndexFunc(ms.trash[userID], func(t models.TrashedRevision) bool { return t.Problem_id == problemID })
+	if i < 0 {
+		return models.RevisionProblem{}, ErrNotFound
+	}
+	for _, p := range ms.revisions[userID] {
+		if p.Problem_id == problemID {
+			return models.RevisionProblem{}, &ConflictError{Current: p}
+		}
+	}
+	problem := ms.trash[userID][i].RevisionProblem
+	problem.Version++
+	ms.trash[userID] = slices.Delete(ms.trash[userID], i, i+1)
+	ms.revisions[userID] = append([]models.RevisionProblem{problem}, ms.revisions[userID]...)
+	return problem, nil
+}
+
+func (ms *MemoryStore) RestoreRevisionProblems(ctx context.Context, userID string, restored []RestoredRevision) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.notes[userID] == nil {
+		ms.notes[userID] = make(map[string][]models.NoteVersion)
+	}
+	for _, r := range restored {
+		problemID := r.Problem.EnsureProblemID()
+		ms.revisions[userID] = slices.DeleteFunc(ms.revisions[userID], func(p models.RevisionProblem) bool { return p.Problem_id == problemID })
+		ms.revisions[userID] = append([]models.RevisionProblem{r.Problem}, ms.revisions[userID]...)
+		ms.reviews[userID+"/"+problemID] = restoredReviews(r)
+		notes := make([]models.NoteVersion, len(r.Notes))
+		for i, v := range r.Notes {
+			v.Problem_id = problemID
+			notes[i] = v
+		}
+		slices.SortFunc(notes, func(a, b models.NoteVersion) int { return cmp.Compare(a.Version, b.Version) })
+		ms.notes[userID][problemID] = notes
+	}
+	return nil
+}
+
+func (ms *MemoryStore) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	purged := 0
+	for userID, trash := range ms.trash {
+		kept := slices.DeleteFunc(trash, func(t models.TrashedRevision) bool {
+			if !t.Deleted_at.Before(before) {
+				return false
+			}
+			// the notes history and the reviews go with the problem unless it was added again
+			if !slices.ContainsFunc(ms.revisions[userID], func(p models.RevisionProblem) bool { return p.Problem_id == t.Problem_id }) {
+				delete(ms.notes[userID], t.Problem_id)
+				delete(ms.reviews, userID+"/"+t.Problem_id)
+			}
+			purged++
+			return true
+		})
+		ms.trash[userID] = kept
+	}
+	return purged, nil
+}
+
+func (ms *MemoryStore) UpdateRevisionProblem(ctx context.Context, userID string, problem models.RevisionProblem) (models.RevisionProblem, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.updateRevisionProblem(userID, problem)
+}
+
+func (ms *MemoryStore) UpdateRevisionProblems(ctx context.Context, userID string, problems []models.RevisionProblem) ([]models.RevisionProblem, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	before := append([]models.RevisionProblem(nil), ms.revisions[userID]...)
+	updated := make([]models.RevisionProblem, len(problems))
+	for i, problem := range problems {
+		var err error
+		updated[i], err = ms.updateRevisionProblem(userID, problem)
+		if err != nil {
+			ms.revisions[userID] = before
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+func (ms *MemoryStore) WriteRevisionProblems(ctx context.Context, userID string, writes []RevisionWrite) ([]models.RevisionProblem, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	before := append([]models.RevisionProblem(nil), ms.revisions[userID]...)
+	trashBefore := append([]models.TrashedRevision(nil), ms.trash[userID]...)
+	notesBefore := maps.Clone(ms.notes[userID])
+	written := make([]models.RevisionProblem, len(writes))
+	for i, write := range writes {
+		problem := write.Problem
+		var err error
+		switch write.Kind {
+		case WriteCreate:
+			problem, err = ms.createRevisionProblem(userID, problem)
+		case WriteUpdate:
+			problem, err = ms.updateRevisionProblem(userID, problem)
+		case WriteDelete:
+			err = ms.deleteRevisionProblem(userID, problem)
+		default:
+			err = fmt.Errorf("unknown write kind %q", write.Kind)
+		}
+		if err != nil {
+			ms.revisions[userID] = before
+			ms.trash[userID] = trashBefore
+			ms.notes[userID] = notesBefore
+			return nil, fmt.Errorf("failed to write revision problems: %w", &WriteError{Index: i, Err: err})
+		}
+		written[i] = problem
+	}
+	return written, nil
+}
+
+func (ms *MemoryStore) createRevisionProblem(userID string, problem models.RevisionProblem) (models.RevisionProblem, error) {
+	if err := problem.Preprocess(); err != nil {
+		return problem, err
+	}
+	ms.addRevisionProblems(userID, []models.RevisionProblem{problem})
+	return ms.revisions[userID][0], nil
+}
+
+func (ms *MemoryStore) updateRevisionProblem(userID string, problem models.RevisionProblem) (models.RevisionProblem, error) {
+	problem.EnsureProblemID()
+	for i, p := range ms.revisions[userID] {
+		if p.Problem_id == problem.Problem_id {
+			if err := checkVersion(p, problem); err != nil {
+				return models.RevisionProblem{}, err
+			}
+			problem.Version = p.Version + 1
+			ms.recordNotes(userID, &p, problem)
+			ms.revisions[userID][i] = problem
+			return problem, nil
+		}
+	}
+	return models.RevisionProblem{}, ErrNotFound
+}
+
+func (ms *MemoryStore) RecordReview(ctx context.Context, userID string, problem models.RevisionProblem, review models.ReviewRecord) (models.RevisionProblem, models.ReviewRecord, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	problem, err := ms.updateRevisionProblem(userID, problem)
+	if err != nil {
+		return problem, review, err
+	}
+	review.ID = newID()
+	review.Problem_id = problem.Problem_id
+	key := userID + "/" + problem.Problem_id
+	ms.reviews[key] = append(ms.reviews[key], review)
+	return problem, review, nil
+}
+
+func (ms *MemoryStore) GetReviews(ctx context.Context, userID string, problemID string) ([]models.ReviewRecord, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return append([]models.ReviewRecord(nil), ms.reviews[userID+"/"+problemID]...), nil
+}
+
+func (ms *MemoryStore) GetDueRevisionProblems(ctx context.Context, userID string, before time.Time) ([]models.RevisionProblem, error) {
+	problems, err := ms.GetRevisionProblems(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision problems: %w", err)
+	}
+	return filterDueRevisionProblems(problems, before), nil
+}
+
+func (ms *MemoryStore) GetUserSettings(ctx context.Context, userID string) (models.UserSettings, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	settings, ok := ms.settings[userID]
+	if !ok {
+		return models.UserSettings{UserID: userID}, nil
+	}
+	return settings, nil
+}
+
+func (ms *MemoryStore) SaveUserSettings(ctx context.Context, settings models.UserSettings) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.settings[settings.UserID] = settings
+	return nil
+}
+
+func (ms *MemoryStore) CreateDeck(ctx context.Context, userID string, deck models.Deck) (models.Deck, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	deck.ID = newID()
+	deck.CreatedAt = time.Now().UTC()
+	ms.decks[userID] = append(ms.decks[userID], deck)
+	return deck, nil
+}
+
+func (ms *MemoryStore) GetDecks(ctx context.Context, userID string) ([]models.Deck, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return append([]models.Deck(nil), ms.decks[userID]...), nil
+}
+
+func (ms *MemoryStore) GetDeck(ctx context.Context, userID string, deckID string) (models.Deck, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	for _, d := range ms.decks[userID] {
+		if d.ID == deckID {
+			return d, nil
+		}
+	}
+	return models.Deck{}, ErrNotFound
+}
+
+func (ms *MemoryStore) UpdateDeck(ctx context.Context, userID string, deck models.Deck) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for i, d := range ms.decks[userID] {
+		if d.ID == deck.ID {
+			deck.CreatedAt = d.CreatedAt
+			ms.decks[userID][i] = deck
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// submissionKey identifies a stored submission, the IDs of the LeetCode sites overlap
+type submissionKey struct {
+	region string
+	id     int64
+}
+
+func (ms *MemoryStore) SaveSubmissions(ctx context.Context, userID string, submissions []models.LeetCodeSubmission, state models.SubmissionSyncState) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.submissions[userID] == nil {
+		ms.submissions[userID] = make(map[submissionKey]models.LeetCodeSubmission)
+	}
+	region := submissionRegion(state.Region)
+	for _, s := range submissions {
+		ms.submissions[userID][submissionKey{region: region, id: s.ID}] = s
+	}
+	state.UserID = userID
+	ms.syncStates[userID] = advanceSyncState(ms.syncStates[userID], state)
+	return nil
+}
+
+func (ms *MemoryStore) GetSubmissionSyncState(ctx context.Context, userID string) (models.SubmissionSyncState, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	state, ok := ms.syncStates[userID]
+	if !ok {
+		return models.SubmissionSyncState{UserID: userID}, nil
+	}
+	return state, nil
+}
+
+func (ms *MemoryStore) QuerySubmissions(ctx context.Context, userID string, q SubmissionQuery) (SubmissionPage, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	region := submissionRegion(q.Region)
+	var submissions []models.LeetCodeSubmission
+	for key, s := range ms.submissions[userID] {
+		if key.region == region {
+			submissions = append(submissions, s)
+		}
+	}
+	return applySubmissionQuery(submissions, q)
+}
+
+// analyses are kept as JSON so callers never share memory with the store
+func (ms *MemoryStore) AddAnalysisProblems(ctx context.Context, collectionName string, id string, toAdd any) error {
+	data, err := json.Marshal(toAdd)
+	if err != nil {
+		return fmt.Errorf("failed to add analysis problem: %w", err)
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.analyses[collectionName] == nil {
+		ms.analyses[collectionName] = make(map[string][]byte)
+	}
+	ms.analyses[collectionName][id] = data
+	return nil
+}
+
+func (ms *MemoryStore) GetAnalysisProblems(ctx context.Context, collectionName string, id string, dst any) error {
+	ms.mu.RLock()
+	data, ok := ms.analyses[collectionName][id]
+	ms.mu.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+	return json.Unmarshal(data, dst)
+}
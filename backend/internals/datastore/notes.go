@@ -0,0 +1,78 @@
+package datastore
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// notesCollection is users/{uid}/revisions/{problemId}/notes, each document is keyed by the
+// problem version that saved the notes
+func (ds *Datastore) notesCollection(userID string, problemID string) *firestore.CollectionRef {
+	return ds.revisionsCollection(userID).Doc(problemID).Collection("notes")
+}
+
+// recordNotes adds the notes of problem to its history as part of tx when they differ from
+// the notes of current, the stored copy before the write or nil for a new problem
+func (ds *Datastore) recordNotes(tx *firestore.Transaction, userID string, current *models.RevisionProblem, problem models.RevisionProblem) error {
+	if !notesChanged(current, problem) {
+		return nil
+	}
+	doc := ds.notesCollection(userID, problem.Problem_id).Doc(strconv.FormatInt(problem.Version, 10))
+	return tx.Set(doc, models.NoteVersion{
+		Problem_id: problem.Problem_id,
+		Version:    problem.Version,
+		Notes:      problem.Notes,
+		Created_at: time.Now().UTC(),
+	})
+}
+
+// trashedVersion returns the version of a problem in the trash so a problem added again
+// after a delete continues its notes history, 0 when the problem is not in the trash
+func trashedVersion(snap *firestore.DocumentSnapshot) (int64, error) {
+	if !snap.Exists() {
+		return 0, nil
+	}
+	var trashed models.TrashedRevision
+	if err := snap.DataTo(&trashed); err != nil {
+		return 0, fmt.Errorf("failed to parse trashed revision: %w", err)
+	}
+	return trashed.Version, nil
+}
+
+func (ds *Datastore) GetNoteVersions(ctx context.Context, userID string, problemID string) ([]models.NoteVersion, error) {
+	docs, err := ds.notesCollection(userID, problemID).OrderBy("version", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note versions: %w", err)
+	}
+	versions := make([]models.NoteVersion, 0, len(docs))
+	for _, doc := range docs {
+		var v models.NoteVersion
+		if err := doc.DataTo(&v); err != nil {
+			return nil, fmt.Errorf("failed to parse note version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+func (ds *Datastore) GetNoteVersion(ctx context.Context, userID string, problemID string, version int64) (models.NoteVersion, error) {
+	var v models.NoteVersion
+	snap, err := ds.notesCollection(userID, problemID).Doc(strconv.FormatInt(version, 10)).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return v, ErrNotFound
+	}
+	if err != nil {
+		return v, fmt.Errorf("failed to get note version: %w", err)
+	}
+	if err := snap.DataTo(&v); err != nil {
+		return v, fmt.Errorf("failed to parse note version: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,333 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+var postgresDialect = dialect{
+	name:                 "postgres",
+	numberedPlaceholders: true,
+	createMigrationsTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`,
+	migrations: postgresMigrations,
+}
+
+var postgresMigrations = []migration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE TABLE revision_problems (
+				id BIGSERIAL PRIMARY KEY,
+				user_id TEXT NOT NULL,
+				title TEXT NOT NULL,
+				added_at TIMESTAMPTZ NOT NULL,
+				submission_id BIGINT NOT NULL DEFAULT 0,
+				code TEXT NOT NULL DEFAULT '',
+				lang TEXT NOT NULL DEFAULT '',
+				lang_name TEXT NOT NULL DEFAULT '',
+				timestamp BIGINT NOT NULL DEFAULT 0,
+				status_display TEXT NOT NULL DEFAULT '',
+				runtime TEXT NOT NULL DEFAULT '',
+				url TEXT NOT NULL DEFAULT '',
+				is_pending TEXT NOT NULL DEFAULT '',
+				memory TEXT NOT NULL DEFAULT '',
+				is_best_solution BOOLEAN NOT NULL DEFAULT FALSE,
+				best_time_complexity TEXT NOT NULL DEFAULT '',
+				current_time_complexity TEXT NOT NULL DEFAULT '',
+				best_space_complexity TEXT NOT NULL DEFAULT '',
+				current_space_complexity TEXT NOT NULL DEFAULT '',
+				notes TEXT NOT NULL DEFAULT '',
+				last_revised TEXT NOT NULL DEFAULT '',
+				next_revision TEXT NOT NULL DEFAULT '',
+				difficulty TEXT NOT NULL DEFAULT '',
+				confidence_level INTEGER NOT NULL DEFAULT 0,
+				revision_count INTEGER NOT NULL DEFAULT 0,
+				CONSTRAINT revision_problems_user_problem_key UNIQUE (user_id, title)
+			)`,
+			`CREATE INDEX idx_revision_problems_next_revision ON revision_problems (user_id, next_revision)`,
+			`CREATE TABLE revision_tags (
+				user_id TEXT NOT NULL,
+				title TEXT NOT NULL,
+				tag TEXT NOT NULL,
+				position INTEGER NOT NULL,
+				PRIMARY KEY (user_id, title, tag),
+				FOREIGN KEY (user_id, title) REFERENCES revision_problems (user_id, title) ON DELETE CASCADE
+			)`,
+			`CREATE INDEX idx_revision_tags_tag ON revision_tags (user_id, tag)`,
+			`CREATE TABLE submission_feedback (
+				problem_id TEXT PRIMARY KEY,
+				data JSONB NOT NULL,
+				updated_at TIMESTAMPTZ NOT NULL
+			)`,
+			`CREATE TABLE analyse_submission (
+				problem_id TEXT PRIMARY KEY,
+				data JSONB NOT NULL,
+				updated_at TIMESTAMPTZ NOT NULL
+			)`,
+			`CREATE TABLE high_level_analysis (
+				problem_id TEXT PRIMARY KEY,
+				data JSONB NOT NULL,
+				updated_at TIMESTAMPTZ NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 2,
+		statements: []string{
+			`ALTER TABLE revision_problems ADD COLUMN version BIGINT NOT NULL DEFAULT 1`,
+		},
+	},
+	{
+		version: 3,
+		statements: []string{
+			`ALTER TABLE revision_problems ADD COLUMN problem_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE revision_problems ADD COLUMN question_id BIGINT NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN title_slug TEXT NOT NULL DEFAULT ''`,
+		},
+		run: backfillProblemIDs,
+	},
+	{
+		version: 4,
+		statements: []string{
+			// keep the most recent row when two titles map to the same problem
+			`DELETE FROM revision_problems a USING revision_problems b
+			WHERE a.user_id = b.user_id AND a.problem_id = b.problem_id AND (a.added_at, a.id) < (b.added_at, b.id)`,
+			`ALTER TABLE revision_tags ADD COLUMN problem_id TEXT NOT NULL DEFAULT ''`,
+			`UPDATE revision_tags t SET problem_id = p.problem_id
+			FROM revision_problems p WHERE p.user_id = t.user_id AND p.title = t.title`,
+			`ALTER TABLE revision_tags DROP CONSTRAINT revision_tags_user_id_title_fkey`,
+			`ALTER TABLE revision_tags DROP CONSTRAINT revision_tags_pkey`,
+			`ALTER TABLE revision_tags DROP COLUMN title`,
+			`ALTER TABLE revision_problems DROP CONSTRAINT revision_problems_user_problem_key`,
+			`ALTER TABLE revision_problems ADD CONSTRAINT revision_problems_user_problem_key UNIQUE (user_id, problem_id)`,
+			`ALTER TABLE revision_tags ADD PRIMARY KEY (user_id, problem_id, tag)`,
+			`ALTER TABLE revision_tags ADD CONSTRAINT revision_tags_problem_fkey FOREIGN KEY (user_id, problem_id)
+				REFERENCES revision_problems (user_id, problem_id) ON DELETE CASCADE`,
+		},
+	},
+	{
+		version: 5,
+		statements: []string{
+			`ALTER TABLE revision_problems ADD COLUMN schedule_algorithm TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_interval INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_repetitions INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_lapses INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_ease DOUBLE PRECISION NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_stability DOUBLE PRECISION NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_difficulty DOUBLE PRECISION NOT NULL DEFAULT 0`,
+			`CREATE TABLE user_settings (
+				user_id TEXT PRIMARY KEY,
+				scheduler TEXT NOT NULL DEFAULT ''
+			)`,
+		},
+	},
+	{
+		// reviews have no foreign key, the history is kept when a revision is deleted and re-added
+		version: 6,
+		statements: []string{
+			`CREATE TABLE revision_reviews (
+				id TEXT PRIMARY KEY,
+				user_id TEXT NOT NULL,
+				problem_id TEXT NOT NULL,
+				reviewed_at TIMESTAMPTZ NOT NULL,
+				grade INTEGER NOT NULL,
+				time_spent_seconds INTEGER NOT NULL DEFAULT 0,
+				notes_delta TEXT NOT NULL DEFAULT '',
+				next_revision TEXT NOT NULL DEFAULT ''
+			)`,
+			`CREATE INDEX idx_revision_reviews_problem ON revision_reviews (user_id, problem_id, reviewed_at)`,
+		},
+	},
+	{
+		// next_revision turns from a server local date into a timestamp, existing dates are taken as UTC
+		// midnight. Users get a time zone that decides where their day starts.
+		version: 7,
+		statements: []string{
+			`ALTER TABLE revision_problems ALTER COLUMN next_revision DROP DEFAULT`,
+			`ALTER TABLE revision_problems ALTER COLUMN next_revision TYPE TIMESTAMPTZ
+				USING CASE WHEN next_revision = '' THEN TIMESTAMPTZ '0001-01-01 00:00:00+00' ELSE (next_revision || ' 00:00:00+00')::TIMESTAMPTZ END`,
+			`ALTER TABLE revision_problems ALTER COLUMN next_revision SET DEFAULT TIMESTAMPTZ '0001-01-01 00:00:00+00'`,
+			`ALTER TABLE revision_reviews ALTER COLUMN next_revision DROP DEFAULT`,
+			`ALTER TABLE revision_reviews ALTER COLUMN next_revision TYPE TIMESTAMPTZ
+				USING CASE WHEN next_revision = '' THEN TIMESTAMPTZ '0001-01-01 00:00:00+00' ELSE (next_revision || ' 00:00:00+00')::TIMESTAMPTZ END`,
+			`ALTER TABLE revision_reviews ALTER COLUMN next_revision SET DEFAULT TIMESTAMPTZ '0001-01-01 00:00:00+00'`,
+			`ALTER TABLE user_settings ADD COLUMN timezone TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 8,
+		statements: []string{
+			`ALTER TABLE user_settings ADD COLUMN daily_review_limit INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version: 9,
+		statements: []string{
+			`ALTER TABLE revision_problems ADD COLUMN failure_count INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN is_leech BOOLEAN NOT NULL DEFAULT FALSE`,
+			`ALTER TABLE revision_problems ADD COLUMN relearning BOOLEAN NOT NULL DEFAULT FALSE`,
+			`ALTER TABLE revision_problems ADD COLUMN relearning_step INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE user_settings ADD COLUMN leech_threshold INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version: 10,
+		statements: []string{
+			`ALTER TABLE user_settings ADD COLUMN paused_at TIMESTAMPTZ NOT NULL DEFAULT TIMESTAMPTZ '0001-01-01 00:00:00+00'`,
+		},
+	},
+	{
+		version: 11,
+		statements: []string{
+			`CREATE TABLE decks (
+				user_id TEXT NOT NULL,
+				id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				archived BOOLEAN NOT NULL DEFAULT FALSE,
+				scheduler TEXT NOT NULL DEFAULT '',
+				daily_review_limit INTEGER NOT NULL DEFAULT 0,
+				leech_threshold INTEGER NOT NULL DEFAULT 0,
+				created_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY (user_id, id)
+			)`,
+			`CREATE TABLE revision_decks (
+				user_id TEXT NOT NULL,
+				problem_id TEXT NOT NULL,
+				deck_id TEXT NOT NULL,
+				position INTEGER NOT NULL,
+				PRIMARY KEY (user_id, problem_id, deck_id),
+				FOREIGN KEY (user_id, problem_id) REFERENCES revision_problems (user_id, problem_id) ON DELETE CASCADE
+			)`,
+			`CREATE INDEX idx_revision_decks_deck ON revision_decks (user_id, deck_id)`,
+		},
+	},
+	{
+		// deleted revisions are kept as JSON, with their tags and decks, until the trash is purged
+		version: 12,
+		statements: []string{
+			`CREATE TABLE revision_trash (
+				user_id TEXT NOT NULL,
+				problem_id TEXT NOT NULL,
+				deleted_at TIMESTAMPTZ NOT NULL,
+				data JSONB NOT NULL,
+				PRIMARY KEY (user_id, problem_id)
+			)`,
+			`CREATE INDEX idx_revision_trash_deleted_at ON revision_trash (deleted_at)`,
+		},
+	},
+	{
+		// every write that changes the notes of a revision keeps them under the new version,
+		// existing notes become the first entry of their history
+		version: 13,
+		statements: []string{
+			`CREATE TABLE revision_notes (
+				user_id TEXT NOT NULL,
+				problem_id TEXT NOT NULL,
+				version BIGINT NOT NULL,
+				notes TEXT NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY (user_id, problem_id, version)
+			)`,
+			`INSERT INTO revision_notes (user_id, problem_id, version, notes, created_at)
+				SELECT user_id, problem_id, version, notes, added_at FROM revision_problems`,
+		},
+	},
+	{
+		version: 14,
+		statements: []string{
+			`CREATE TABLE leetcode_submissions (
+				user_id TEXT NOT NULL,
+				id BIGINT NOT NULL,
+				question_id BIGINT NOT NULL DEFAULT 0,
+				title TEXT NOT NULL DEFAULT '',
+				title_slug TEXT NOT NULL DEFAULT '',
+				code TEXT NOT NULL DEFAULT '',
+				lang TEXT NOT NULL DEFAULT '',
+				lang_name TEXT NOT NULL DEFAULT '',
+				timestamp BIGINT NOT NULL DEFAULT 0,
+				status_display TEXT NOT NULL DEFAULT '',
+				runtime TEXT NOT NULL DEFAULT '',
+				url TEXT NOT NULL DEFAULT '',
+				is_pending TEXT NOT NULL DEFAULT '',
+				memory TEXT NOT NULL DEFAULT '',
+				is_best_solution BOOLEAN NOT NULL DEFAULT FALSE,
+				best_time_complexity TEXT NOT NULL DEFAULT '',
+				current_time_complexity TEXT NOT NULL DEFAULT '',
+				best_space_complexity TEXT NOT NULL DEFAULT '',
+				current_space_complexity TEXT NOT NULL DEFAULT '',
+				PRIMARY KEY (user_id, id)
+			)`,
+			`CREATE TABLE submission_sync (
+				user_id TEXT PRIMARY KEY,
+				last_submission_id BIGINT NOT NULL,
+				last_timestamp BIGINT NOT NULL,
+				synced_at TIMESTAMPTZ NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 15,
+		statements: []string{
+			`CREATE TABLE problem_metadata (
+				problem_id TEXT PRIMARY KEY,
+				data JSONB NOT NULL,
+				updated_at TIMESTAMPTZ NOT NULL
+			)`,
+		},
+	},
+	{
+		// syncs before leetcode.cn support were all of leetcode.com
+		version: 16,
+		statements: []string{
+			`ALTER TABLE user_settings ADD COLUMN leetcode_region TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE submission_sync ADD COLUMN region TEXT NOT NULL DEFAULT 'com'`,
+		},
+	},
+	{
+		version: 17,
+		statements: []string{
+			`ALTER TABLE user_settings ADD COLUMN resume_cursor TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		// submission IDs of leetcode.com and leetcode.cn overlap, the site becomes part of the
+		// key and the stored submissions, all synced before sites could be picked, are of .com
+		version: 18,
+		statements: []string{
+			`ALTER TABLE leetcode_submissions ADD COLUMN region TEXT NOT NULL DEFAULT 'com'`,
+			`ALTER TABLE leetcode_submissions DROP CONSTRAINT leetcode_submissions_pkey`,
+			`ALTER TABLE leetcode_submissions ADD PRIMARY KEY (user_id, region, id)`,
+		},
+	},
+	{
+		version: 19,
+		statements: []string{
+			`ALTER TABLE submission_sync ADD COLUMN backfill_since_id BIGINT NOT NULL DEFAULT 0`,
+			`ALTER TABLE submission_sync ADD COLUMN backfill_before_id BIGINT NOT NULL DEFAULT 0`,
+		},
+	},
+}
+
+// NewPostgresStore connects to the database at dsn and brings its schema up to date
+func NewPostgresStore(ctx context.Context, dsn string) (*SQLStore, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening postgres connection: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to postgres: %w", err)
+	}
+	store := &SQLStore{DB: db, dialect: postgresDialect}
+	if err := store.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
@@ -0,0 +1,27 @@
+package datastore
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunTrashPurge removes the revisions that stayed in the trash longer than retention, once
+// at start and then every interval until ctx is done
+func RunTrashPurge(ctx context.Context, store Store, retention time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := store.PurgeTrash(ctx, time.Now().Add(-retention))
+		if err != nil {
+			log.Println("Error purging trash:", err)
+		} else if purged > 0 {
+			log.Println("Purged revisions from the trash:", purged)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package datastore
+
+import (
+	"cmp"
+	"dsa-helper-backend/internals/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidQuery is returned for a RevisionQuery with an unknown sort key or a malformed
+// cursor, and for a SubmissionQuery with a malformed cursor
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Sort keys of RevisionQuery, problems with the same key are ordered by Problem_id
+const (
+	SortNextRevision  = "next_revision"
+	SortLastRevised   = "last_revised"
+	SortConfidence    = "confidence"
+	SortTitle         = "title"
+	SortRevisionCount = "revision_count"
+)
+
+// revisionSort describes how a sort key is read from a problem and stored in a cursor
+type revisionSort struct {
+	column string
+	value  func(p models.RevisionProblem) any
+	parse  func(s string) (any, error)
+}
+
+func parseString(s string) (any, error) { return s, nil }
+
+func parseInt(s string) (any, error) { return strconv.Atoi(s) }
+
+func parseTime(s string) (any, error) { return time.Parse(time.RFC3339Nano, s) }
+
+var revisionSorts = map[string]revisionSort{
+	SortNextRevision: {
+		column: "next_revision",
+		value:  func(p models.RevisionProblem) any { return p.Next_revision.UTC() },
+		parse:  parseTime,
+	},
+	SortLastRevised: {
+		column: "last_revised",
+		value:  func(p models.RevisionProblem) any { return p.Last_revised },
+		parse:  parseString,
+	},
+	SortConfidence: {
+		column: "confidence_level",
+		value:  func(p models.RevisionProblem) any { return p.Confidence_level },
+		parse:  parseInt,
+	},
+	SortTitle: {
+		column: "title",
+		value:  func(p models.RevisionProblem) any { return p.Title },
+		parse:  parseString,
+	},
+	SortRevisionCount: {
+		column: "revision_count",
+		value:  func(p models.RevisionProblem) any { return p.Revision_count },
+		parse:  parseInt,
+	},
+}
+
+// RevisionQuery selects a page of revisions, zero fields do not filter
+type RevisionQuery struct {
+	Tag        string
+	Deck       string
+	Difficulty string
+	// Language matches the Lang or LangName of the submission
+	Language      string
+	MinConfidence int
+	MaxConfidence int
+	// DueBefore and DueAfter bound Next_revision, DueBefore is exclusive
+	DueBefore time.Time
+	DueAfter  time.Time
+	// Search is a case insensitive substring of the title or the notes
+	Search string
+	// Sort is one of the Sort constants, SortNextRevision when empty
+	Sort string
+	Desc bool
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+	// Limit is the page size, 0 returns every matching problem
+	Limit int
+}
+
+// RevisionPage is one page of a RevisionQuery, NextCursor is empty on the last page
+type RevisionPage struct {
+	Problems   []models.RevisionProblem `json:"problems"`
+	NextCursor string                   `json:"next_cursor"`
+}
+
+// revisionCursor is the position after the last problem of a page, it is sent base64 encoded
+type revisionCursor struct {
+	Key string `json:"k"`
+	ID  string `json:"id"`
+}
+
+// Validate checks the sort key and decodes the cursor of the query
+func (q RevisionQuery) Validate() error {
+	_, _, err := q.position()
+	return err
+}
+
+func (q RevisionQuery) sortKey() (revisionSort, error) {
+	if q.Sort == "" {
+		return revisionSorts[SortNextRevision], nil
+	}
+	sort, ok := revisionSorts[q.Sort]
+	if !ok {
+		return revisionSort{}, fmt.Errorf("%w: unknown sort key %q", ErrInvalidQuery, q.Sort)
+	}
+	return sort, nil
+}
+
+// position returns the sort key and the cursor of the query, the cursor is nil on the first page
+func (q RevisionQuery) position() (revisionSort, *cursorPosition, error) {
+	sort, err := q.sortKey()
+	if err != nil {
+		return sort, nil, err
+	}
+	if q.Cursor == "" {
+		return sort, nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return sort, nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	var c revisionCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return sort, nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	value, err := sort.parse(c.Key)
+	if err != nil {
+		return sort, nil, fmt.Errorf("%w: cursor does not match sort key", ErrInvalidQuery)
+	}
+	return sort, &cursorPosition{value: value, id: c.ID}, nil
+}
+
+type cursorPosition struct {
+	value any
+	id    string
+}
+
+// cursorAfter encodes the position after problem
+func cursorAfter(sort revisionSort, problem models.RevisionProblem) string {
+	key := sort.value(problem)
+	var s string
+	switch v := key.(type) {
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	default:
+		s = fmt.Sprint(v)
+	}
+	raw, _ := json.Marshal(revisionCursor{Key: s, ID: problem.Problem_id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func compareKeys(a any, b any) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case int:
+		return cmp.Compare(a, b.(int))
+	default:
+		return strings.Compare(a.(string), b.(string))
+	}
+}
+
+// matches reports whether problem passes the filters of the query
+func (q RevisionQuery) matches(p models.RevisionProblem) bool {
+	if q.Tag != "" && !slices.Contains(p.Tags, q.Tag) {
+		return false
+	}
+	if q.Deck != "" && !slices.Contains(p.Decks, q.Deck) {
+		return false
+	}
+	if q.Difficulty != "" && !strings.EqualFold(p.Difficulty, q.Difficulty) {
+		return false
+	}
+	if q.Language != "" && !strings.EqualFold(p.Lang, q.Language) && !strings.EqualFold(p.LangName, q.Language) {
+		return false
+	}
+	if q.MinConfidence > 0 && p.Confidence_level < q.MinConfidence {
+		return false
+	}
+	if q.MaxConfidence > 0 && p.Confidence_level > q.MaxConfidence {
+		return false
+	}
+	if !q.DueBefore.IsZero() && !p.Next_revision.Before(q.DueBefore) {
+		return false
+	}
+	if !q.DueAfter.IsZero() && p.Next_revision.Before(q.DueAfter) {
+		return false
+	}
+	if q.Search != "" {
+		search := strings.ToLower(q.Search)
+		if !strings.Contains(strings.ToLower(p.Title), search) && !strings.Contains(strings.ToLower(p.Notes), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyRevisionQuery runs a query over all the problems of a user, for the stores that
+// cannot filter on their own
+func applyRevisionQuery(problems []models.RevisionProblem, q RevisionQuery) (RevisionPage, error) {
+	sort, after, err := q.position()
+	if err != nil {
+		return RevisionPage{}, err
+	}
+	compare := func(a models.RevisionProblem, b models.RevisionProblem) int {
+		c := cmp.Or(compareKeys(sort.value(a), sort.value(b)), strings.Compare(a.Problem_id, b.Problem_id))
+		if q.Desc {
+			return -c
+		}
+		return c
+	}
+	var matched []models.RevisionProblem
+	for _, p := range problems {
+		if !q.matches(p) {
+			continue
+		}
+		if after != nil {
+			c := cmp.Or(compareKeys(sort.value(p), after.value), strings.Compare(p.Problem_id, after.id))
+			if q.Desc {
+				c = -c
+			}
+			if c <= 0 {
+				continue
+			}
+		}
+		matched = append(matched, p)
+	}
+	slices.SortFunc(matched, compare)
+	return pageOf(matched, sort, q.Limit), nil
+}
+
+// pageOf cuts problems, sorted and past the cursor, to the page size. Stores fetch one
+// problem more than the limit so they know whether another page follows.
+func pageOf(problems []models.RevisionProblem, sort revisionSort, limit int) RevisionPage {
+	page := RevisionPage{Problems: problems}
+	if page.Problems == nil {
+		page.Problems = []models.RevisionProblem{}
+	}
+	if limit > 0 && len(problems) > limit {
+		page.Problems = problems[:limit]
+		page.NextCursor = cursorAfter(sort, page.Problems[limit-1])
+	}
+	return page
+}
@@ -0,0 +1,78 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+)
+
+// RestoreRevisionProblems goes through a BulkWriter since a problem with a long history does
+// not fit in one transaction, a failed restore can leave part of the archive written and is
+// repaired by running it again
+func (ds *Datastore) RestoreRevisionProblems(ctx context.Context, userID string, restored []RestoredRevision) error {
+	bulk := ds.FirestoreClient.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	var errs []error
+	enqueue := func(job *firestore.BulkWriterJob, err error) {
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		jobs = append(jobs, job)
+	}
+	for _, r := range restored {
+		problemID := r.Problem.EnsureProblemID()
+		stale, err := ds.staleHistory(ctx, userID, r)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, doc := range stale {
+			enqueue(bulk.Delete(doc))
+		}
+		enqueue(bulk.Set(ds.revisionsCollection(userID).Doc(problemID), r.Problem))
+		for _, review := range restoredReviews(r) {
+			enqueue(bulk.Create(ds.reviewsCollection(userID, problemID).Doc(review.ID), review))
+		}
+		for _, v := range r.Notes {
+			v.Problem_id = problemID
+			enqueue(bulk.Set(ds.notesCollection(userID, problemID).Doc(strconv.FormatInt(v.Version, 10)), v))
+		}
+	}
+	bulk.End()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to restore revision problems: %w", err)
+	}
+	return nil
+}
+
+// staleHistory returns the stored reviews of a restored problem and the note versions the
+// archive does not overwrite, a BulkWriter cannot delete and set the same document
+func (ds *Datastore) staleHistory(ctx context.Context, userID string, r RestoredRevision) ([]*firestore.DocumentRef, error) {
+	stale, err := ds.reviewsCollection(userID, r.Problem.Problem_id).DocumentRefs(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	kept := make(map[string]bool, len(r.Notes))
+	for _, v := range r.Notes {
+		kept[strconv.FormatInt(v.Version, 10)] = true
+	}
+	notes, err := ds.notesCollection(userID, r.Problem.Problem_id).DocumentRefs(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		if !kept[note.ID] {
+			stale = append(stale, note)
+		}
+	}
+	return stale, nil
+}
@@ -0,0 +1,64 @@
+package datastore
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+)
+
+// reviewsCollection is users/{uid}/revisions/{problemId}/reviews, Firestore keeps a
+// subcollection when its parent document is deleted so the history survives a delete
+func (ds *Datastore) reviewsCollection(userID string, problemID string) *firestore.CollectionRef {
+	return ds.revisionsCollection(userID).Doc(problemID).Collection("reviews")
+}
+
+func (ds *Datastore) RecordReview(ctx context.Context, userID string, problem models.RevisionProblem, review models.ReviewRecord) (models.RevisionProblem, models.ReviewRecord, error) {
+	doc := ds.revisionsCollection(userID).Doc(problem.EnsureProblemID())
+	reviewDoc := ds.reviewsCollection(userID, problem.Problem_id).NewDoc()
+	review.ID = reviewDoc.ID
+	review.Problem_id = problem.Problem_id
+	var updated models.RevisionProblem
+	err := ds.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		current, _, err := getRevisionProblem(tx, doc)
+		if err != nil {
+			return err
+		}
+		next, err := nextRevision(current, problem)
+		if err != nil {
+			return err
+		}
+		if err := ds.recordNotes(tx, userID, &current, next); err != nil {
+			return err
+		}
+		if err := tx.Set(doc, next); err != nil {
+			return err
+		}
+		if err := tx.Create(reviewDoc, review); err != nil {
+			return err
+		}
+		updated = next
+		return nil
+	})
+	if err != nil {
+		return models.RevisionProblem{}, models.ReviewRecord{}, fmt.Errorf("failed to record review: %w", err)
+	}
+	return updated, review, nil
+}
+
+func (ds *Datastore) GetReviews(ctx context.Context, userID string, problemID string) ([]models.ReviewRecord, error) {
+	docs, err := ds.reviewsCollection(userID, problemID).OrderBy("reviewed_at", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %w", err)
+	}
+	reviews := make([]models.ReviewRecord, 0, len(docs))
+	for _, doc := range docs {
+		var r models.ReviewRecord
+		if err := doc.DataTo(&r); err != nil {
+			return nil, fmt.Errorf("failed to parse review: %w", err)
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, nil
+}
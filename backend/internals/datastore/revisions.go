@@ -3,43 +3,95 @@ package datastore
 import (
 	"context"
 	"dsa-helper-backend/internals/models"
+	"errors"
 	"fmt"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// Datastore is the Firestore implementation of Store, every revision is its own
+// document under users/{uid}/revisions/{problemId}
 type Datastore struct {
 	FirestoreClient *firestore.Client
 }
 
-func (ds *Datastore) AddRevisionProblems(ctx context.Context, userID string, newRevisions []models.RevisionProblem) error {
-	for i := range newRevisions {
-		err := newRevisions[i].Preprocess()
-		if err != nil {
-			return err
-		}
-		newRevisions[i].FindNextRevisionDate()
+// legacyRevisionsCollection held one RevisionList document per user before revisions were split out
+const legacyRevisionsCollection = "revisions"
+
+// legacyRevisionProblem reads revisions written while next_revision was a "2006-01-02" string
+type legacyRevisionProblem struct {
+	models.RevisionProblem
+	Next_revision string `firestore:"next_revision"`
+}
+
+type legacyRevisionList struct {
+	UserID    string                  `firestore:"userId"`
+	Revisions []legacyRevisionProblem `firestore:"revisions"`
+}
+
+// parseLegacyDate reads an old next_revision date as UTC midnight, the time zone of the
+// server that wrote it is unknown
+func parseLegacyDate(date string) time.Time {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}
 	}
-	oldRevisions, err := ds.GetRevisionProblems(ctx, userID)
+	return t
+}
+
+func (ds *Datastore) userDoc(userID string) *firestore.DocumentRef {
+	return ds.FirestoreClient.Collection("users").Doc(userID)
+}
+
+func (ds *Datastore) revisionsCollection(userID string) *firestore.CollectionRef {
+	return ds.userDoc(userID).Collection("revisions")
+}
+
+func (ds *Datastore) AddRevisionProblems(ctx context.Context, userID string, newRevisions []models.RevisionProblem) error {
+	err := prepareRevisionProblems(newRevisions)
 	if err != nil {
 		return err
 	}
-	for i := 0; i < len(oldRevisions); i++ {
-		isAlreadyPresent := false
-		for j := 0; j < len(newRevisions); j++ {
-			if newRevisions[j].Title == oldRevisions[i].Title {
-				isAlreadyPresent = true
-			}
+	revisions := ds.revisionsCollection(userID)
+	err = ds.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		// the trash copies come after the revisions, they carry the version of deleted problems
+		docs := make([]*firestore.DocumentRef, 2*len(newRevisions))
+		for i, p := range newRevisions {
+			docs[i] = revisions.Doc(p.Problem_id)
+			docs[len(newRevisions)+i] = ds.trashCollection(userID).Doc(p.Problem_id)
 		}
-		if !isAlreadyPresent {
-			newRevisions = append(newRevisions, oldRevisions[i])
+		// all reads have to happen before the first write of a transaction
+		snaps, err := tx.GetAll(docs)
+		if err != nil {
+			return err
 		}
-	}
-	_, err = ds.FirestoreClient.Collection("revisions").Doc(userID).Set(ctx, models.RevisionList{
-		UserID:    userID,
-		Revisions: newRevisions,
+		for i, p := range newRevisions {
+			var current *models.RevisionProblem
+			if snaps[i].Exists() {
+				current = &models.RevisionProblem{}
+				if err := snaps[i].DataTo(current); err != nil {
+					return err
+				}
+				p.Version = current.Version + 1
+			} else {
+				trashed, err := trashedVersion(snaps[len(newRevisions)+i])
+				if err != nil {
+					return err
+				}
+				p.Version = trashed + 1
+			}
+			if err := ds.recordNotes(tx, userID, current, p); err != nil {
+				return err
+			}
+			if err := tx.Set(docs[i], p); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		return fmt.Errorf("failed to add revision problems: %w", err)
@@ -48,8 +100,37 @@ func (ds *Datastore) AddRevisionProblems(ctx context.Context, userID string, new
 }
 
 func (ds *Datastore) GetRevisionProblems(ctx context.Context, userID string) ([]models.RevisionProblem, error) {
-	iter := ds.FirestoreClient.Collection("revisions").Where("userId", "==", userID).Documents(ctx)
-	var revisionList models.RevisionList
+	return ds.getRevisionProblems(ds.revisionsCollection(userID).Documents(ctx))
+}
+
+// QueryRevisionProblems filters in the store, Firestore has no substring search and would
+// need a composite index for every combination of filters and sort keys
+func (ds *Datastore) QueryRevisionProblems(ctx context.Context, userID string, q RevisionQuery) (RevisionPage, error) {
+	problems, err := ds.GetRevisionProblems(ctx, userID)
+	if err != nil {
+		return RevisionPage{}, fmt.Errorf("failed to get revision problems: %w", err)
+	}
+	return applyRevisionQuery(problems, q)
+}
+
+func (ds *Datastore) GetRevisionProblem(ctx context.Context, userID string, problemID string) (models.RevisionProblem, error) {
+	var p models.RevisionProblem
+	snap, err := ds.revisionsCollection(userID).Doc(problemID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return p, ErrNotFound
+	}
+	if err != nil {
+		return p, err
+	}
+	if err := snap.DataTo(&p); err != nil {
+		return p, fmt.Errorf("failed to parse revision problem: %w", err)
+	}
+	return p, nil
+}
+
+func (ds *Datastore) getRevisionProblems(iter *firestore.DocumentIterator) ([]models.RevisionProblem, error) {
+	defer iter.Stop()
+	var problems []models.RevisionProblem
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -58,28 +139,36 @@ func (ds *Datastore) GetRevisionProblems(ctx context.Context, userID string) ([]
 		if err != nil {
 			return nil, err
 		}
-		if err := doc.DataTo(&revisionList); err != nil {
+		var p models.RevisionProblem
+		if err := doc.DataTo(&p); err != nil {
 			return nil, fmt.Errorf("failed to parse revision problem: %w", err)
 		}
+		problems = append(problems, p)
 	}
-	return revisionList.Revisions, nil
+	return problems, nil
 }
 
+// DeleteRevisionProblem and UpdateRevisionProblem compare versions inside a transaction.
+// Firestore fails the commit if the document changed after it was read, the transaction
+// then retries and the version check reports the conflict, the retry compares against the
+// version the caller sent since the transaction leaves problem untouched.
 func (ds *Datastore) DeleteRevisionProblem(ctx context.Context, userID string, problem models.RevisionProblem) error {
-	// get all
-	problems, err := ds.GetRevisionProblems(ctx, userID)
-	if err != nil {
-		return fmt.Errorf("failed to get revision problems: %w", err)
-	}
-	var updatedProblems []models.RevisionProblem
-	for _, p := range problems {
-		if p.Title != problem.Title {
-			updatedProblems = append(updatedProblems, p)
+	doc := ds.revisionsCollection(userID).Doc(problem.EnsureProblemID())
+	err := ds.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		current, snap, err := getRevisionProblem(tx, doc)
+		if errors.Is(err, ErrNotFound) {
+			return nil
 		}
-	}
-	_, err = ds.FirestoreClient.Collection("revisions").Doc(userID).Set(ctx, models.RevisionList{
-		UserID:    userID,
-		Revisions: updatedProblems,
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(current, problem); err != nil {
+			return err
+		}
+		if err := ds.trashRevisionProblem(tx, userID, current); err != nil {
+			return err
+		}
+		return tx.Delete(doc, firestore.LastUpdateTime(snap.UpdateTime))
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete the revision problem: %w", err)
@@ -87,43 +176,300 @@ func (ds *Datastore) DeleteRevisionProblem(ctx context.Context, userID string, p
 	return nil
 }
 
-func (ds *Datastore) UpdateRevisionProblem(ctx context.Context, userID string, problem models.RevisionProblem) error {
-	// get all problems
-	problems, err := ds.GetRevisionProblems(ctx, userID)
+func (ds *Datastore) UpdateRevisionProblem(ctx context.Context, userID string, problem models.RevisionProblem) (models.RevisionProblem, error) {
+	doc := ds.revisionsCollection(userID).Doc(problem.EnsureProblemID())
+	var updated models.RevisionProblem
+	err := ds.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		current, _, err := getRevisionProblem(tx, doc)
+		if err != nil {
+			return err
+		}
+		next, err := nextRevision(current, problem)
+		if err != nil {
+			return err
+		}
+		if err := ds.recordNotes(tx, userID, &current, next); err != nil {
+			return err
+		}
+		if err := tx.Set(doc, next); err != nil {
+			return err
+		}
+		updated = next
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get revision problems: %w", err)
+		return models.RevisionProblem{}, fmt.Errorf("failed to update the revision problem: %w", err)
 	}
-	var updatedProblems []models.RevisionProblem
-	for _, p := range problems {
-		if p.Title == problem.Title {
-			p = problem
-			p.FindNextRevisionDate()
+	return updated, nil
+}
+
+// UpdateRevisionProblems writes every problem in one transaction, which limits a call to MaxUpdateRevisionProblems problems
+func (ds *Datastore) UpdateRevisionProblems(ctx context.Context, userID string, problems []models.RevisionProblem) ([]models.RevisionProblem, error) {
+	updated := make([]models.RevisionProblem, len(problems))
+	err := ds.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docs := make([]*firestore.DocumentRef, len(problems))
+		for i := range problems {
+			docs[i] = ds.revisionsCollection(userID).Doc(problems[i].EnsureProblemID())
 		}
-		updatedProblems = append(updatedProblems, p)
-	}
-	_, err = ds.FirestoreClient.Collection("revisions").Doc(userID).Set(ctx, models.RevisionList{
-		UserID:    userID,
-		Revisions: updatedProblems,
+		snaps, err := tx.GetAll(docs)
+		if err != nil {
+			return err
+		}
+		for i, problem := range problems {
+			if !snaps[i].Exists() {
+				return ErrNotFound
+			}
+			var current models.RevisionProblem
+			if err := snaps[i].DataTo(&current); err != nil {
+				return fmt.Errorf("failed to parse revision problem: %w", err)
+			}
+			next, err := nextRevision(current, problem)
+			if err != nil {
+				return err
+			}
+			if err := ds.recordNotes(tx, userID, &current, next); err != nil {
+				return err
+			}
+			updated[i] = next
+		}
+		for i := range updated {
+			if err := tx.Set(docs[i], updated[i]); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("failed to update the revision problem: %w", err)
+		return nil, fmt.Errorf("failed to update the revision problems: %w", err)
 	}
-	return nil
+	return updated, nil
 }
 
-func (ds *Datastore) GetDueRevisionProblems(ctx context.Context, userID string) ([]models.RevisionProblem, error) {
-	problems, err := ds.GetRevisionProblems(ctx, userID)
+func getRevisionProblem(tx *firestore.Transaction, doc *firestore.DocumentRef) (models.RevisionProblem, *firestore.DocumentSnapshot, error) {
+	var p models.RevisionProblem
+	snap, err := tx.Get(doc)
+	if status.Code(err) == codes.NotFound {
+		return p, nil, ErrNotFound
+	}
+	if err != nil {
+		return p, nil, err
+	}
+	if err := snap.DataTo(&p); err != nil {
+		return p, nil, fmt.Errorf("failed to parse revision problem: %w", err)
+	}
+	return p, snap, nil
+}
+
+func (ds *Datastore) GetDueRevisionProblems(ctx context.Context, userID string, before time.Time) ([]models.RevisionProblem, error) {
+	problems, err := ds.getRevisionProblems(ds.revisionsCollection(userID).Where("next_revision", "<", before).Documents(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get revision problems: %w", err)
 	}
+	return problems, nil
+}
 
-	var dueProblems []models.RevisionProblem
-	today := time.Now().Format("2006-01-02")
+// MigrateRevisionLists moves every legacy revisions/{userID} list into the per problem
+// subcollection and deletes the list once its problems are written. It is safe to rerun,
+// users that were already migrated no longer have a legacy document.
+func (ds *Datastore) MigrateRevisionLists(ctx context.Context, dryRun bool) (users int, problems int, err error) {
+	iter := ds.FirestoreClient.Collection(legacyRevisionsCollection).Documents(ctx)
+	defer iter.Stop()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return users, problems, err
+		}
+		var revisionList legacyRevisionList
+		if err := doc.DataTo(&revisionList); err != nil {
+			return users, problems, fmt.Errorf("failed to parse revision list %s: %w", doc.Ref.ID, err)
+		}
+		userID := revisionList.UserID
+		if userID == "" {
+			userID = doc.Ref.ID
+		}
+		if !dryRun {
+			if err := ds.copyRevisionList(ctx, userID, revisionList.Revisions); err != nil {
+				return users, problems, fmt.Errorf("failed to migrate revisions of user %s: %w", userID, err)
+			}
+			if _, err := doc.Ref.Delete(ctx); err != nil {
+				return users, problems, fmt.Errorf("failed to delete legacy revision list of user %s: %w", userID, err)
+			}
+		}
+		users++
+		problems += len(revisionList.Revisions)
+	}
+	return users, problems, nil
+}
 
-	for _, p := range problems {
-		if p.Next_revision <= today {
-			dueProblems = append(dueProblems, p)
+// copyRevisionList uses a BulkWriter because a single list can hold more problems than a transaction allows
+func (ds *Datastore) copyRevisionList(ctx context.Context, userID string, revisions []legacyRevisionProblem) error {
+	bw := ds.FirestoreClient.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(revisions))
+	for _, legacy := range revisions {
+		p := legacy.RevisionProblem
+		p.Next_revision = parseLegacyDate(legacy.Next_revision)
+		p.EnsureProblemID()
+		job, err := bw.Set(ds.revisionsCollection(userID).Doc(p.Problem_id), p)
+		if err != nil {
+			bw.End()
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrProblemIDCollision is reported by BackfillProblemIDs for a revision whose problem id is
+// already taken by another revision of the user, or whose history would overwrite the history
+// stored under that id. The revision is left where it is for the two to be merged by hand.
+var ErrProblemIDCollision = errors.New("problem id already taken")
+
+// revisionHistories are the subcollections of a revision document that move along with it
+var revisionHistories = []string{"notes", "reviews"}
+
+// BackfillProblemIDs gives every stored revision a problem_id and moves it to the document
+// named after that id, along with its notes history and reviews. Documents written before
+// revisions were keyed by problem used the title as their ID. Collisions do not stop the
+// backfill, they are returned together at the end wrapping ErrProblemIDCollision. It is
+// safe to rerun.
+func (ds *Datastore) BackfillProblemIDs(ctx context.Context) (moved int, err error) {
+	users, err := ds.FirestoreClient.Collection("users").DocumentRefs(ctx).GetAll()
+	if err != nil {
+		return 0, err
+	}
+	var collisions []error
+	for _, user := range users {
+		revisions := ds.revisionsCollection(user.ID)
+		docs, err := revisions.Documents(ctx).GetAll()
+		if err != nil {
+			return moved, err
+		}
+		for _, doc := range docs {
+			var p models.RevisionProblem
+			if err := doc.DataTo(&p); err != nil {
+				return moved, fmt.Errorf("failed to parse revision problem %s of user %s: %w", doc.Ref.ID, user.ID, err)
+			}
+			hadProblemID := p.Problem_id != ""
+			if hadProblemID && doc.Ref.ID == p.Problem_id {
+				continue
+			}
+			target := revisions.Doc(p.EnsureProblemID())
+			err = ds.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+				if target.ID == doc.Ref.ID {
+					return tx.Set(target, p)
+				}
+				return moveRevision(tx, doc.Ref, target, p)
+			})
+			if errors.Is(err, ErrProblemIDCollision) {
+				collisions = append(collisions, fmt.Errorf("revision problem %s of user %s: %w", doc.Ref.ID, user.ID, err))
+				continue
+			}
+			if err != nil {
+				return moved, fmt.Errorf("failed to backfill revision problem %s of user %s: %w", doc.Ref.ID, user.ID, err)
+			}
+			moved++
+		}
+	}
+	return moved, errors.Join(collisions...)
+}
+
+// moveRevision writes p to target and moves the history of from under it, it returns
+// ErrProblemIDCollision instead of overwriting anything stored under target
+func moveRevision(tx *firestore.Transaction, from *firestore.DocumentRef, target *firestore.DocumentRef, p models.RevisionProblem) error {
+	_, err := tx.Get(target)
+	if err == nil {
+		return fmt.Errorf("%w: %s", ErrProblemIDCollision, target.ID)
+	}
+	if status.Code(err) != codes.NotFound {
+		return err
+	}
+	// all reads have to happen before the first write of a transaction
+	var history []*firestore.DocumentSnapshot
+	var moves []*firestore.DocumentRef
+	for _, collection := range revisionHistories {
+		docs, err := tx.Documents(from.Collection(collection)).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			history = append(history, doc)
+			moves = append(moves, target.Collection(collection).Doc(doc.Ref.ID))
+		}
+	}
+	if len(moves) > 0 {
+		taken, err := tx.GetAll(moves)
+		if err != nil {
+			return err
+		}
+		for _, snap := range taken {
+			if snap.Exists() {
+				return fmt.Errorf("%w: %s/%s/%s", ErrProblemIDCollision, target.ID, snap.Ref.Parent.ID, snap.Ref.ID)
+			}
+		}
+	}
+	if err := tx.Create(target, p); err != nil {
+		return err
+	}
+	for i, doc := range history {
+		data := doc.Data()
+		data["problem_id"] = target.ID
+		if err := tx.Create(moves[i], data); err != nil {
+			return err
+		}
+		if err := tx.Delete(doc.Ref); err != nil {
+			return err
+		}
+	}
+	return tx.Delete(from)
+}
+
+// ConvertNextRevisionTimestamps rewrites next_revision fields still holding a "2006-01-02"
+// string, on revisions and on their reviews, into timestamps. Due queries compare
+// timestamps and never match the old strings. It is safe to rerun.
+func (ds *Datastore) ConvertNextRevisionTimestamps(ctx context.Context) (converted int, err error) {
+	users, err := ds.FirestoreClient.Collection("users").DocumentRefs(ctx).GetAll()
+	if err != nil {
+		return 0, err
+	}
+	for _, user := range users {
+		// DocumentRefs also lists deleted revisions whose reviews are still around
+		revisions, err := ds.revisionsCollection(user.ID).DocumentRefs(ctx).GetAll()
+		if err != nil {
+			return converted, err
+		}
+		for _, revision := range revisions {
+			docs, err := revision.Collection("reviews").Documents(ctx).GetAll()
+			if err != nil {
+				return converted, err
+			}
+			snap, err := revision.Get(ctx)
+			if err != nil && status.Code(err) != codes.NotFound {
+				return converted, err
+			}
+			if snap.Exists() {
+				docs = append(docs, snap)
+			}
+			for _, doc := range docs {
+				date, ok := doc.Data()["next_revision"].(string)
+				if !ok {
+					continue
+				}
+				_, err := doc.Ref.Update(ctx, []firestore.Update{{Path: "next_revision", Value: parseLegacyDate(date)}})
+				if err != nil {
+					return converted, fmt.Errorf("failed to convert %s: %w", doc.Ref.Path, err)
+				}
+				converted++
+			}
 		}
 	}
-	return dueProblems, nil
+	return converted, nil
 }
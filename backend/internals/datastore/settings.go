@@ -0,0 +1,35 @@
+package datastore
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// settings live on the users/{uid} document that also parents the revisions subcollection
+
+func (ds *Datastore) GetUserSettings(ctx context.Context, userID string) (models.UserSettings, error) {
+	settings := models.UserSettings{UserID: userID}
+	snap, err := ds.userDoc(userID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+	if err := snap.DataTo(&settings); err != nil {
+		return settings, fmt.Errorf("failed to parse user settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (ds *Datastore) SaveUserSettings(ctx context.Context, settings models.UserSettings) error {
+	_, err := ds.userDoc(settings.UserID).Set(ctx, settings)
+	if err != nil {
+		return fmt.Errorf("failed to save user settings: %w", err)
+	}
+	return nil
+}
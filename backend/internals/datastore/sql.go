@@ -0,0 +1,171 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLStore implements Store on top of database/sql, the schema lives in the dialect migrations
+type SQLStore struct {
+	DB      *sql.DB
+	dialect dialect
+}
+
+// dialect holds what differs between the SQL engines we support
+type dialect struct {
+	name string
+	// numberedPlaceholders is set for engines that bind $1, $2... instead of ?
+	numberedPlaceholders bool
+	// createMigrationsTable creates the schema_migrations bookkeeping table
+	createMigrationsTable string
+	migrations            []migration
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// rebind rewrites ? placeholders into the bind syntax of the dialect
+func (ss *SQLStore) rebind(query string) string {
+	if !ss.dialect.numberedPlaceholders {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (ss *SQLStore) exec(ctx context.Context, q queryer, query string, args ...any) (sql.Result, error) {
+	return q.ExecContext(ctx, ss.rebind(query), args...)
+}
+
+func (ss *SQLStore) query(ctx context.Context, q queryer, query string, args ...any) (*sql.Rows, error) {
+	return q.QueryContext(ctx, ss.rebind(query), args...)
+}
+
+func (ss *SQLStore) queryRow(ctx context.Context, q queryer, query string, args ...any) *sql.Row {
+	return q.QueryRowContext(ctx, ss.rebind(query), args...)
+}
+
+// inTx runs fn in a transaction that is committed only if fn succeeds
+func (ss *SQLStore) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := ss.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// collection names used by the analysis handlers
+const (
+	SubmissionFeedbackCollection = "submissionFeedback"
+	AnalyseSubmissionCollection  = "analyseSubmission"
+	HighLevelAnalysisCollection  = "highLevelAnalysis"
+	// ProblemMetadataCollection caches the LeetCode metadata of problems by slug
+	ProblemMetadataCollection = "problemMetadata"
+)
+
+var analysisTables = map[string]string{
+	SubmissionFeedbackCollection: "submission_feedback",
+	AnalyseSubmissionCollection:  "analyse_submission",
+	HighLevelAnalysisCollection:  "high_level_analysis",
+	ProblemMetadataCollection:    "problem_metadata",
+}
+
+type migration struct {
+	version    int
+	statements []string
+	// run is an optional data migration executed after the statements
+	run func(ctx context.Context, ss *SQLStore, tx *sql.Tx) error
+}
+
+// migrate applies every migration newer than the recorded schema version, each in its own transaction
+func (ss *SQLStore) migrate(ctx context.Context) error {
+	_, err := ss.DB.ExecContext(ctx, ss.dialect.createMigrationsTable)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	var current int
+	err = ss.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	for _, m := range ss.dialect.migrations {
+		if m.version <= current {
+			continue
+		}
+		err := ss.inTx(ctx, func(tx *sql.Tx) error {
+			for _, stmt := range m.statements {
+				if _, err := tx.ExecContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			if m.run != nil {
+				if err := m.run(ctx, ss, tx); err != nil {
+					return err
+				}
+			}
+			_, err := ss.exec(ctx, tx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, m.version, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d on %s failed: %w", m.version, ss.dialect.name, err)
+		}
+	}
+	return nil
+}
+
+func (ss *SQLStore) AddAnalysisProblems(ctx context.Context, collectionName string, id string, toAdd any) error {
+	table, ok := analysisTables[collectionName]
+	if !ok {
+		return fmt.Errorf("unknown analysis collection %q", collectionName)
+	}
+	data, err := json.Marshal(toAdd)
+	if err != nil {
+		return fmt.Errorf("failed to add analysis problem: %w", err)
+	}
+	_, err = ss.exec(ctx, ss.DB, `INSERT INTO `+table+` (problem_id, data, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (problem_id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+		id, string(data), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to add analysis problem: %w", err)
+	}
+	return nil
+}
+
+func (ss *SQLStore) GetAnalysisProblems(ctx context.Context, collectionName string, id string, dst any) error {
+	table, ok := analysisTables[collectionName]
+	if !ok {
+		return fmt.Errorf("unknown analysis collection %q", collectionName)
+	}
+	var data string
+	err := ss.queryRow(ctx, ss.DB, `SELECT data FROM `+table+` WHERE problem_id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), dst)
+}
@@ -0,0 +1,50 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"time"
+)
+
+func (ss *SQLStore) WriteRevisionProblems(ctx context.Context, userID string, writes []RevisionWrite) ([]models.RevisionProblem, error) {
+	written := make([]models.RevisionProblem, len(writes))
+	addedAt := time.Now().UTC()
+	err := ss.inTx(ctx, func(tx *sql.Tx) error {
+		for i, write := range writes {
+			problem := write.Problem
+			var err error
+			switch write.Kind {
+			case WriteCreate:
+				problem, err = ss.createRevisionProblem(ctx, tx, userID, addedAt, problem)
+			case WriteUpdate:
+				problem, err = ss.updateRevisionProblem(ctx, tx, userID, problem)
+			case WriteDelete:
+				err = ss.deleteRevisionProblem(ctx, tx, userID, problem)
+			default:
+				err = fmt.Errorf("unknown write kind %q", write.Kind)
+			}
+			if err != nil {
+				return &WriteError{Index: i, Err: err}
+			}
+			written[i] = problem
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write revision problems: %w", err)
+	}
+	return written, nil
+}
+
+// createRevisionProblem adds problem and reads it back for its version
+func (ss *SQLStore) createRevisionProblem(ctx context.Context, tx *sql.Tx, userID string, addedAt time.Time, problem models.RevisionProblem) (models.RevisionProblem, error) {
+	if err := problem.Preprocess(); err != nil {
+		return problem, err
+	}
+	if err := ss.addRevisionProblem(ctx, tx, userID, addedAt, problem); err != nil {
+		return problem, err
+	}
+	return ss.getRevisionProblem(ctx, tx, userID, problem.Problem_id)
+}
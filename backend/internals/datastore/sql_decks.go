@@ -0,0 +1,77 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"dsa-helper-backend/internals/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const deckColumnList = `id, name, archived, scheduler, daily_review_limit, leech_threshold, created_at`
+
+func deckFields(d *models.Deck) []any {
+	return []any{&d.ID, &d.Name, &d.Archived, &d.Scheduler, &d.DailyReviewLimit, &d.LeechThreshold, &d.CreatedAt}
+}
+
+func (ss *SQLStore) CreateDeck(ctx context.Context, userID string, deck models.Deck) (models.Deck, error) {
+	deck.ID = newID()
+	deck.CreatedAt = time.Now().UTC()
+	_, err := ss.exec(ctx, ss.DB, `INSERT INTO decks (user_id, `+deckColumnList+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, deck.ID, deck.Name, deck.Archived, deck.Scheduler, deck.DailyReviewLimit, deck.LeechThreshold, deck.CreatedAt)
+	if err != nil {
+		return models.Deck{}, fmt.Errorf("failed to create deck: %w", err)
+	}
+	return deck, nil
+}
+
+func (ss *SQLStore) GetDecks(ctx context.Context, userID string) ([]models.Deck, error) {
+	rows, err := ss.query(ctx, ss.DB, `SELECT `+deckColumnList+` FROM decks WHERE user_id = ? ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decks: %w", err)
+	}
+	defer rows.Close()
+	decks := []models.Deck{}
+	for rows.Next() {
+		var d models.Deck
+		if err := rows.Scan(deckFields(&d)...); err != nil {
+			return nil, fmt.Errorf("failed to parse deck: %w", err)
+		}
+		decks = append(decks, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get decks: %w", err)
+	}
+	return decks, nil
+}
+
+func (ss *SQLStore) GetDeck(ctx context.Context, userID string, deckID string) (models.Deck, error) {
+	var d models.Deck
+	err := ss.queryRow(ctx, ss.DB, `SELECT `+deckColumnList+` FROM decks WHERE user_id = ? AND id = ?`, userID, deckID).
+		Scan(deckFields(&d)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Deck{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Deck{}, fmt.Errorf("failed to get deck: %w", err)
+	}
+	return d, nil
+}
+
+func (ss *SQLStore) UpdateDeck(ctx context.Context, userID string, deck models.Deck) error {
+	res, err := ss.exec(ctx, ss.DB, `UPDATE decks SET name = ?, archived = ?, scheduler = ?, daily_review_limit = ?, leech_threshold = ?
+		WHERE user_id = ? AND id = ?`,
+		deck.Name, deck.Archived, deck.Scheduler, deck.DailyReviewLimit, deck.LeechThreshold, userID, deck.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update deck: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update deck: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
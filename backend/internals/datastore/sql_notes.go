@@ -0,0 +1,57 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"dsa-helper-backend/internals/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// recordNotes adds the notes of problem to its history when they differ from the notes of
+// current, the stored copy before the write or nil for a new problem
+func (ss *SQLStore) recordNotes(ctx context.Context, tx *sql.Tx, userID string, current *models.RevisionProblem, problem models.RevisionProblem) error {
+	if !notesChanged(current, problem) {
+		return nil
+	}
+	_, err := ss.exec(ctx, tx, `INSERT INTO revision_notes (user_id, problem_id, version, notes, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, problem_id, version) DO UPDATE SET notes = excluded.notes, created_at = excluded.created_at`,
+		userID, problem.Problem_id, problem.Version, problem.Notes, time.Now().UTC())
+	return err
+}
+
+func (ss *SQLStore) GetNoteVersions(ctx context.Context, userID string, problemID string) ([]models.NoteVersion, error) {
+	rows, err := ss.query(ctx, ss.DB, `SELECT problem_id, version, notes, created_at FROM revision_notes
+		WHERE user_id = ? AND problem_id = ? ORDER BY version`, userID, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note versions: %w", err)
+	}
+	defer rows.Close()
+	versions := []models.NoteVersion{}
+	for rows.Next() {
+		var v models.NoteVersion
+		if err := rows.Scan(&v.Problem_id, &v.Version, &v.Notes, &v.Created_at); err != nil {
+			return nil, fmt.Errorf("failed to parse note version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get note versions: %w", err)
+	}
+	return versions, nil
+}
+
+func (ss *SQLStore) GetNoteVersion(ctx context.Context, userID string, problemID string, version int64) (models.NoteVersion, error) {
+	var v models.NoteVersion
+	err := ss.queryRow(ctx, ss.DB, `SELECT problem_id, version, notes, created_at FROM revision_notes
+		WHERE user_id = ? AND problem_id = ? AND version = ?`, userID, problemID, version).
+		Scan(&v.Problem_id, &v.Version, &v.Notes, &v.Created_at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return v, ErrNotFound
+	}
+	if err != nil {
+		return v, fmt.Errorf("failed to get note version: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,54 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+func (ss *SQLStore) RestoreRevisionProblems(ctx context.Context, userID string, restored []RestoredRevision) error {
+	addedAt := time.Now().UTC()
+	err := ss.inTx(ctx, func(tx *sql.Tx) error {
+		for _, r := range restored {
+			if err := ss.restoreRevisionProblem(ctx, tx, userID, addedAt, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore revision problems: %w", err)
+	}
+	return nil
+}
+
+func (ss *SQLStore) restoreRevisionProblem(ctx context.Context, tx *sql.Tx, userID string, addedAt time.Time, r RestoredRevision) error {
+	problemID := r.Problem.EnsureProblemID()
+	for _, table := range []string{"revision_reviews", "revision_notes"} {
+		_, err := ss.exec(ctx, tx, `DELETE FROM `+table+` WHERE user_id = ? AND problem_id = ?`, userID, problemID)
+		if err != nil {
+			return err
+		}
+	}
+	if err := ss.insertRevisionProblem(ctx, tx, userID, addedAt, r.Problem); err != nil {
+		return err
+	}
+	for _, review := range restoredReviews(r) {
+		_, err := ss.exec(ctx, tx, `INSERT INTO revision_reviews (id, user_id, problem_id, reviewed_at, grade, time_spent_seconds, notes_delta, next_revision)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, review.ID, userID, problemID, review.Reviewed_at.UTC(), review.Grade,
+			review.Time_spent_seconds, review.Notes_delta, review.Next_revision.UTC())
+		if err != nil {
+			return err
+		}
+	}
+	for _, v := range r.Notes {
+		_, err := ss.exec(ctx, tx, `INSERT INTO revision_notes (user_id, problem_id, version, notes, created_at) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (user_id, problem_id, version) DO UPDATE SET notes = excluded.notes, created_at = excluded.created_at`,
+			userID, problemID, v.Version, v.Notes, v.Created_at.UTC())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+)
+
+func (ss *SQLStore) RecordReview(ctx context.Context, userID string, problem models.RevisionProblem, review models.ReviewRecord) (models.RevisionProblem, models.ReviewRecord, error) {
+	err := ss.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		problem, err = ss.updateRevisionProblem(ctx, tx, userID, problem)
+		if err != nil {
+			return err
+		}
+		review.ID = newID()
+		review.Problem_id = problem.Problem_id
+		_, err = ss.exec(ctx, tx, `INSERT INTO revision_reviews (id, user_id, problem_id, reviewed_at, grade, time_spent_seconds, notes_delta, next_revision)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, review.ID, userID, review.Problem_id, review.Reviewed_at.UTC(), review.Grade,
+			review.Time_spent_seconds, review.Notes_delta, review.Next_revision.UTC())
+		return err
+	})
+	if err != nil {
+		return models.RevisionProblem{}, models.ReviewRecord{}, fmt.Errorf("failed to record review: %w", err)
+	}
+	return problem, review, nil
+}
+
+func (ss *SQLStore) GetReviews(ctx context.Context, userID string, problemID string) ([]models.ReviewRecord, error) {
+	rows, err := ss.query(ctx, ss.DB, `SELECT id, problem_id, reviewed_at, grade, time_spent_seconds, notes_delta, next_revision
+		FROM revision_reviews WHERE user_id = ? AND problem_id = ? ORDER BY reviewed_at, id`, userID, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %w", err)
+	}
+	defer rows.Close()
+	var reviews []models.ReviewRecord
+	for rows.Next() {
+		var r models.ReviewRecord
+		err := rows.Scan(&r.ID, &r.Problem_id, &r.Reviewed_at, &r.Grade, &r.Time_spent_seconds, &r.Notes_delta, &r.Next_revision)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reviews: %w", err)
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
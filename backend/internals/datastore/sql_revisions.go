@@ -0,0 +1,468 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"dsa-helper-backend/internals/models"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// revisionColumns are the client editable columns of revision_problems, in the order of
+// revisionFields. problem_id comes first because it is the key and never updated.
+var revisionColumns = []string{
+	"problem_id", "title", "question_id", "title_slug", "submission_id", "code", "lang", "lang_name", "timestamp",
+	"status_display", "runtime", "url", "is_pending", "memory", "is_best_solution", "best_time_complexity",
+	"current_time_complexity", "best_space_complexity", "current_space_complexity", "notes", "last_revised",
+	"next_revision", "difficulty", "confidence_level", "revision_count", "schedule_algorithm", "schedule_interval",
+	"schedule_repetitions", "schedule_lapses", "schedule_ease", "schedule_stability", "schedule_difficulty",
+	"failure_count", "is_leech", "relearning", "relearning_step",
+}
+
+// revisionFields returns pointers to the fields backing revisionColumns, usable both as
+// query arguments and as scan destinations
+func revisionFields(p *models.RevisionProblem) []any {
+	// sqlite stores timestamps as text, they only compare correctly when all are in UTC
+	p.Next_revision = p.Next_revision.UTC()
+	return []any{
+		&p.Problem_id, &p.Title, &p.QuestionID, &p.TitleSlug, &p.ID, &p.Code, &p.Lang, &p.LangName, &p.Timestamp,
+		&p.StatusDisplay, &p.Runtime, &p.URL, &p.IsPending, &p.Memory, &p.IsBestSolution, &p.BestTimeComplexity,
+		&p.CurrentTimeComplexity, &p.BestSpaceComplexity, &p.CurrentSpaceComplexity, &p.Notes, &p.Last_revised,
+		&p.Next_revision, &p.Difficulty, &p.Confidence_level, &p.Revision_count, &p.Schedule.Algorithm, &p.Schedule.Interval,
+		&p.Schedule.Repetitions, &p.Schedule.Lapses, &p.Schedule.Ease, &p.Schedule.Stability, &p.Schedule.Difficulty,
+		&p.Failure_count, &p.Is_leech, &p.Relearning, &p.Relearning_step,
+	}
+}
+
+var (
+	revisionColumnList = strings.Join(revisionColumns, ", ")
+	// revisionSelectColumns adds the store managed columns to revisionColumns
+	revisionSelectColumns = revisionColumnList + ", version"
+	revisionPlaceholders  = strings.TrimSuffix(strings.Repeat("?, ", len(revisionColumns)), ", ")
+	revisionAssignments   = assignments(revisionColumns[1:], func(c string) string { return "?" })
+	revisionUpserts       = assignments(revisionColumns[1:], func(c string) string { return "excluded." + c })
+)
+
+func assignments(columns []string, value func(column string) string) string {
+	parts := make([]string, len(columns))
+	for i, c := range columns {
+		parts[i] = c + " = " + value(c)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func scanRevisionProblem(rows *sql.Rows) (models.RevisionProblem, error) {
+	var p models.RevisionProblem
+	err := rows.Scan(append(revisionFields(&p), &p.Version)...)
+	return p, err
+}
+
+func (ss *SQLStore) AddRevisionProblems(ctx context.Context, userID string, newRevisions []models.RevisionProblem) error {
+	err := prepareRevisionProblems(newRevisions)
+	if err != nil {
+		return err
+	}
+	addedAt := time.Now().UTC()
+	err = ss.inTx(ctx, func(tx *sql.Tx) error {
+		for i := range newRevisions {
+			if err := ss.addRevisionProblem(ctx, tx, userID, addedAt, newRevisions[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add revision problems: %w", err)
+	}
+	return nil
+}
+
+// addRevisionProblem inserts problem or replaces the stored copy, which bumps its version
+func (ss *SQLStore) addRevisionProblem(ctx context.Context, tx *sql.Tx, userID string, addedAt time.Time, problem models.RevisionProblem) error {
+	var current *models.RevisionProblem
+	stored := models.RevisionProblem{Problem_id: problem.Problem_id}
+	err := ss.queryRow(ctx, tx, `SELECT version, notes FROM revision_problems WHERE user_id = ? AND problem_id = ?`,
+		userID, problem.Problem_id).Scan(&stored.Version, &stored.Notes)
+	if errors.Is(err, sql.ErrNoRows) {
+		// a problem added again after a delete continues its notes history
+		err = ss.queryRow(ctx, tx, `SELECT COALESCE(MAX(version), 0) FROM revision_notes WHERE user_id = ? AND problem_id = ?`,
+			userID, problem.Problem_id).Scan(&stored.Version)
+	} else if err == nil {
+		current = &stored
+	}
+	if err != nil {
+		return err
+	}
+	problem.Version = stored.Version + 1
+	if err := ss.recordNotes(ctx, tx, userID, current, problem); err != nil {
+		return err
+	}
+	return ss.insertRevisionProblem(ctx, tx, userID, addedAt, problem)
+}
+
+// insertRevisionProblem writes problem with its version as is
+func (ss *SQLStore) insertRevisionProblem(ctx context.Context, tx *sql.Tx, userID string, addedAt time.Time, problem models.RevisionProblem) error {
+	args := append(append([]any{userID, addedAt}, revisionFields(&problem)...), problem.Version)
+	_, err := ss.exec(ctx, tx, `INSERT INTO revision_problems (user_id, added_at, `+revisionColumnList+`, version)
+		VALUES (?, ?, `+revisionPlaceholders+`, ?)
+		ON CONFLICT (user_id, problem_id) DO UPDATE SET added_at = excluded.added_at, `+revisionUpserts+`,
+			version = excluded.version`,
+		args...)
+	if err != nil {
+		return err
+	}
+	return ss.replaceValues(ctx, tx, userID, problem)
+}
+
+// valueTable stores a string slice of revisions one row per value, in slice order
+type valueTable struct {
+	table  string
+	column string
+}
+
+var (
+	tagTable  = valueTable{table: "revision_tags", column: "tag"}
+	deckTable = valueTable{table: "revision_decks", column: "deck_id"}
+)
+
+// replaceValues writes the tags and decks of a revision
+func (ss *SQLStore) replaceValues(ctx context.Context, q queryer, userID string, problem models.RevisionProblem) error {
+	if err := ss.replaceValueTable(ctx, q, tagTable, userID, problem.Problem_id, problem.Tags); err != nil {
+		return err
+	}
+	return ss.replaceValueTable(ctx, q, deckTable, userID, problem.Problem_id, problem.Decks)
+}
+
+func (ss *SQLStore) replaceValueTable(ctx context.Context, q queryer, vt valueTable, userID string, problemID string, values []string) error {
+	_, err := ss.exec(ctx, q, `DELETE FROM `+vt.table+` WHERE user_id = ? AND problem_id = ?`, userID, problemID)
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		_, err := ss.exec(ctx, q, `INSERT INTO `+vt.table+` (user_id, problem_id, `+vt.column+`, position) VALUES (?, ?, ?, ?)
+			ON CONFLICT (user_id, problem_id, `+vt.column+`) DO NOTHING`, userID, problemID, value, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ss *SQLStore) GetRevisionProblems(ctx context.Context, userID string) ([]models.RevisionProblem, error) {
+	return ss.queryRevisionProblems(ctx, ss.DB, userID, `SELECT `+revisionSelectColumns+` FROM revision_problems
+		WHERE user_id = ? ORDER BY added_at DESC, problem_id`, userID)
+}
+
+// QueryRevisionProblems filters, sorts and pages in SQL, pages continue after the
+// (sort key, problem_id) of the cursor so concurrent writes do not shift them
+func (ss *SQLStore) QueryRevisionProblems(ctx context.Context, userID string, q RevisionQuery) (RevisionPage, error) {
+	sort, after, err := q.position()
+	if err != nil {
+		return RevisionPage{}, err
+	}
+	where := []string{"user_id = ?"}
+	args := []any{userID}
+	filter := func(condition string, values ...any) {
+		where = append(where, condition)
+		args = append(args, values...)
+	}
+	for _, vt := range []struct {
+		valueTable
+		value string
+	}{{tagTable, q.Tag}, {deckTable, q.Deck}} {
+		if vt.value != "" {
+			filter(`EXISTS (SELECT 1 FROM `+vt.table+` v WHERE v.user_id = revision_problems.user_id
+				AND v.problem_id = revision_problems.problem_id AND v.`+vt.column+` = ?)`, vt.value)
+		}
+	}
+	if q.Difficulty != "" {
+		filter("LOWER(difficulty) = LOWER(?)", q.Difficulty)
+	}
+	if q.Language != "" {
+		filter("(LOWER(lang) = LOWER(?) OR LOWER(lang_name) = LOWER(?))", q.Language, q.Language)
+	}
+	if q.MinConfidence > 0 {
+		filter("confidence_level >= ?", q.MinConfidence)
+	}
+	if q.MaxConfidence > 0 {
+		filter("confidence_level <= ?", q.MaxConfidence)
+	}
+	if !q.DueBefore.IsZero() {
+		filter("next_revision < ?", q.DueBefore.UTC())
+	}
+	if !q.DueAfter.IsZero() {
+		filter("next_revision >= ?", q.DueAfter.UTC())
+	}
+	if q.Search != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(q.Search)) + "%"
+		filter(`(LOWER(title) LIKE ? ESCAPE '\' OR LOWER(notes) LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+	order, compare := "ASC", ">"
+	if q.Desc {
+		order, compare = "DESC", "<"
+	}
+	if after != nil {
+		value := after.value
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC()
+		}
+		filter(`(`+sort.column+` `+compare+` ? OR (`+sort.column+` = ? AND problem_id `+compare+` ?))`, value, value, after.id)
+	}
+	query := `SELECT ` + revisionSelectColumns + ` FROM revision_problems WHERE ` + strings.Join(where, " AND ") +
+		` ORDER BY ` + sort.column + ` ` + order + `, problem_id ` + order
+	if q.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, q.Limit+1)
+	}
+	problems, err := ss.queryRevisionProblems(ctx, ss.DB, userID, query, args...)
+	if err != nil {
+		return RevisionPage{}, fmt.Errorf("failed to query revision problems: %w", err)
+	}
+	return pageOf(problems, sort, q.Limit), nil
+}
+
+// likeEscaper escapes the LIKE wildcards of a search term
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (ss *SQLStore) GetRevisionProblem(ctx context.Context, userID string, problemID string) (models.RevisionProblem, error) {
+	return ss.getRevisionProblem(ctx, ss.DB, userID, problemID)
+}
+
+func (ss *SQLStore) getRevisionProblem(ctx context.Context, q queryer, userID string, problemID string) (models.RevisionProblem, error) {
+	problems, err := ss.queryRevisionProblems(ctx, q, userID, `SELECT `+revisionSelectColumns+` FROM revision_problems
+		WHERE user_id = ? AND problem_id = ?`, userID, problemID)
+	if err != nil {
+		return models.RevisionProblem{}, err
+	}
+	if len(problems) == 0 {
+		return models.RevisionProblem{}, ErrNotFound
+	}
+	return problems[0], nil
+}
+
+func (ss *SQLStore) queryRevisionProblems(ctx context.Context, q queryer, userID string, query string, args ...any) ([]models.RevisionProblem, error) {
+	rows, err := ss.query(ctx, q, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var problems []models.RevisionProblem
+	for rows.Next() {
+		p, err := scanRevisionProblem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse revision problem: %w", err)
+		}
+		problems = append(problems, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	problemIDs := make([]string, len(problems))
+	for i := range problems {
+		problemIDs[i] = problems[i].Problem_id
+	}
+	tags, err := ss.getValues(ctx, q, tagTable, userID, problemIDs)
+	if err != nil {
+		return nil, err
+	}
+	decks, err := ss.getValues(ctx, q, deckTable, userID, problemIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range problems {
+		problems[i].Tags = tags[problems[i].Problem_id]
+		problems[i].Decks = decks[problems[i].Problem_id]
+	}
+	return problems, nil
+}
+
+// valueQueryChunk is how many problem IDs one query of getValues binds, well below the
+// parameter limits of sqlite and postgres
+const valueQueryChunk = 500
+
+// getValues returns the values of vt for the given problems, keyed by problem ID
+func (ss *SQLStore) getValues(ctx context.Context, q queryer, vt valueTable, userID string, problemIDs []string) (map[string][]string, error) {
+	values := make(map[string][]string)
+	for chunk := range slices.Chunk(problemIDs, valueQueryChunk) {
+		args := make([]any, 0, len(chunk)+1)
+		args = append(args, userID)
+		for _, id := range chunk {
+			args = append(args, id)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ")
+		rows, err := ss.query(ctx, q, `SELECT problem_id, `+vt.column+` FROM `+vt.table+`
+			WHERE user_id = ? AND problem_id IN (`+placeholders+`) ORDER BY problem_id, position`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var problemID, value string
+			if err := rows.Scan(&problemID, &value); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			values[problemID] = append(values[problemID], value)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func (ss *SQLStore) DeleteRevisionProblem(ctx context.Context, userID string, problem models.RevisionProblem) error {
+	err := ss.inTx(ctx, func(tx *sql.Tx) error {
+		return ss.deleteRevisionProblem(ctx, tx, userID, problem)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete the revision problem: %w", err)
+	}
+	return nil
+}
+
+func (ss *SQLStore) deleteRevisionProblem(ctx context.Context, tx *sql.Tx, userID string, problem models.RevisionProblem) error {
+	problemID := problem.EnsureProblemID()
+	current, err := ss.getRevisionProblem(ctx, tx, userID, problemID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(current, problem); err != nil {
+		return err
+	}
+	if err := ss.trashRevisionProblem(ctx, tx, userID, current); err != nil {
+		return err
+	}
+	for _, vt := range []valueTable{tagTable, deckTable} {
+		_, err = ss.exec(ctx, tx, `DELETE FROM `+vt.table+` WHERE user_id = ? AND problem_id = ?`, userID, problemID)
+		if err != nil {
+			return err
+		}
+	}
+	res, err := ss.exec(ctx, tx, `DELETE FROM revision_problems WHERE user_id = ? AND problem_id = ? AND version = ?`,
+		userID, problemID, current.Version)
+	if err != nil {
+		return err
+	}
+	return ss.checkWritten(ctx, tx, res, userID, problemID)
+}
+
+func (ss *SQLStore) UpdateRevisionProblem(ctx context.Context, userID string, problem models.RevisionProblem) (models.RevisionProblem, error) {
+	err := ss.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		problem, err = ss.updateRevisionProblem(ctx, tx, userID, problem)
+		return err
+	})
+	if err != nil {
+		return models.RevisionProblem{}, fmt.Errorf("failed to update the revision problem: %w", err)
+	}
+	return problem, nil
+}
+
+func (ss *SQLStore) UpdateRevisionProblems(ctx context.Context, userID string, problems []models.RevisionProblem) ([]models.RevisionProblem, error) {
+	updated := make([]models.RevisionProblem, len(problems))
+	err := ss.inTx(ctx, func(tx *sql.Tx) error {
+		for i, problem := range problems {
+			var err error
+			updated[i], err = ss.updateRevisionProblem(ctx, tx, userID, problem)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update the revision problems: %w", err)
+	}
+	return updated, nil
+}
+
+func (ss *SQLStore) updateRevisionProblem(ctx context.Context, tx *sql.Tx, userID string, problem models.RevisionProblem) (models.RevisionProblem, error) {
+	problemID := problem.EnsureProblemID()
+	current, err := ss.getRevisionProblem(ctx, tx, userID, problemID)
+	if err != nil {
+		return problem, err
+	}
+	if err := checkVersion(current, problem); err != nil {
+		return problem, err
+	}
+	args := append(revisionFields(&problem)[1:], userID, problemID, current.Version)
+	res, err := ss.exec(ctx, tx, `UPDATE revision_problems SET `+revisionAssignments+`, version = version + 1
+		WHERE user_id = ? AND problem_id = ? AND version = ?`, args...)
+	if err != nil {
+		return problem, err
+	}
+	if err := ss.checkWritten(ctx, tx, res, userID, problemID); err != nil {
+		return problem, err
+	}
+	problem.Version = current.Version + 1
+	if err := ss.recordNotes(ctx, tx, userID, &current, problem); err != nil {
+		return problem, err
+	}
+	return problem, ss.replaceValues(ctx, tx, userID, problem)
+}
+
+// checkWritten turns a version guarded write that matched no row into a conflict, which
+// happens when another writer committed between our read and our write
+func (ss *SQLStore) checkWritten(ctx context.Context, q queryer, res sql.Result, userID string, problemID string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	current, err := ss.getRevisionProblem(ctx, q, userID, problemID)
+	if err != nil {
+		return err
+	}
+	return &ConflictError{Current: current}
+}
+
+func (ss *SQLStore) GetDueRevisionProblems(ctx context.Context, userID string, before time.Time) ([]models.RevisionProblem, error) {
+	problems, err := ss.queryRevisionProblems(ctx, ss.DB, userID, `SELECT `+revisionSelectColumns+` FROM revision_problems
+		WHERE user_id = ? AND next_revision < ? ORDER BY next_revision, problem_id`, userID, before.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision problems: %w", err)
+	}
+	return problems, nil
+}
+
+// backfillProblemIDs derives the problem slug of rows stored before revisions were keyed by problem
+func backfillProblemIDs(ctx context.Context, ss *SQLStore, tx *sql.Tx) error {
+	rows, err := ss.query(ctx, tx, `SELECT user_id, title, url FROM revision_problems WHERE problem_id = ''`)
+	if err != nil {
+		return err
+	}
+	var pending []models.RevisionProblem
+	var users []string
+	for rows.Next() {
+		var userID string
+		var p models.RevisionProblem
+		if err := rows.Scan(&userID, &p.Title, &p.URL); err != nil {
+			rows.Close()
+			return err
+		}
+		users = append(users, userID)
+		pending = append(pending, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range pending {
+		_, err := ss.exec(ctx, tx, `UPDATE revision_problems SET problem_id = ? WHERE user_id = ? AND title = ?`,
+			pending[i].EnsureProblemID(), users[i], pending[i].Title)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
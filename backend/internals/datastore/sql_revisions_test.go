@@ -0,0 +1,79 @@
+package datastore
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestSQLiteStore(t *testing.T) *SQLStore {
+	t.Helper()
+	store, err := NewSQLiteStore(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.DB.Close() })
+	return store
+}
+
+// testStores are the stores a test runs against, Firestore needs an emulator
+var testStores = []struct {
+	name  string
+	store func(t *testing.T) Store
+}{
+	{"memory", func(t *testing.T) Store { return NewMemoryStore() }},
+	{"sqlite", func(t *testing.T) Store { return newTestSQLiteStore(t) }},
+}
+
+func TestQueryRevisionProblemsLoadsTagsAndDecksOfFetchedProblems(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+	deck, err := store.CreateDeck(ctx, "u", models.Deck{Name: "Graphs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	var problems []models.RevisionProblem
+	for i, title := range []string{"Course Schedule", "Two Sum", "Number of Islands"} {
+		problems = append(problems, models.RevisionProblem{
+			LeetCodeSubmission: models.LeetCodeSubmission{Title: title},
+			Confidence_level:   3,
+			Next_revision:      now.AddDate(0, 0, i-1),
+			Tags:               []string{"tag-" + title, "shared"},
+			Decks:              []string{deck.ID},
+		})
+	}
+	if err := store.AddRevisionProblems(ctx, "u", problems); err != nil {
+		t.Fatal(err)
+	}
+	// another user with the same problem must not leak into the result
+	other := problems[0]
+	other.Tags = []string{"other"}
+	other.Decks = nil
+	if err := store.AddRevisionProblems(ctx, "v", []models.RevisionProblem{other}); err != nil {
+		t.Fatal(err)
+	}
+
+	due, err := store.GetDueRevisionProblems(ctx, "u", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].Problem_id != "course-schedule" {
+		t.Fatalf("due problems %+v, want course-schedule", due)
+	}
+	if !slices.Equal(due[0].Tags, []string{"tag-Course Schedule", "shared"}) || !slices.Equal(due[0].Decks, []string{deck.ID}) {
+		t.Fatalf("course-schedule has tags %v and decks %v", due[0].Tags, due[0].Decks)
+	}
+	all, err := store.GetRevisionProblems(ctx, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range all {
+		if len(p.Tags) != 2 || p.Tags[0] != "tag-"+p.Title || len(p.Decks) != 1 {
+			t.Errorf("%s has tags %v and decks %v", p.Problem_id, p.Tags, p.Decks)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"dsa-helper-backend/internals/models"
+	"errors"
+	"fmt"
+)
+
+func (ss *SQLStore) GetUserSettings(ctx context.Context, userID string) (models.UserSettings, error) {
+	settings := models.UserSettings{UserID: userID}
+	err := ss.queryRow(ctx, ss.DB, `SELECT scheduler, timezone, daily_review_limit, leech_threshold, paused_at, leetcode_region,
+		resume_cursor FROM user_settings WHERE user_id = ?`, userID).Scan(&settings.Scheduler, &settings.Timezone, &settings.DailyReviewLimit,
+		&settings.LeechThreshold, &settings.PausedAt, &settings.LeetCodeRegion, &settings.ResumeCursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (ss *SQLStore) SaveUserSettings(ctx context.Context, settings models.UserSettings) error {
+	_, err := ss.exec(ctx, ss.DB, `INSERT INTO user_settings (user_id, scheduler, timezone, daily_review_limit, leech_threshold, paused_at,
+			leetcode_region, resume_cursor)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET scheduler = excluded.scheduler, timezone = excluded.timezone,
+			daily_review_limit = excluded.daily_review_limit, leech_threshold = excluded.leech_threshold,
+			paused_at = excluded.paused_at, leetcode_region = excluded.leetcode_region,
+			resume_cursor = excluded.resume_cursor`,
+		settings.UserID, settings.Scheduler, settings.Timezone, settings.DailyReviewLimit, settings.LeechThreshold,
+		settings.PausedAt.UTC(), settings.LeetCodeRegion, settings.ResumeCursor)
+	if err != nil {
+		return fmt.Errorf("failed to save user settings: %w", err)
+	}
+	return nil
+}
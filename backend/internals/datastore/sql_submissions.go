@@ -0,0 +1,141 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"dsa-helper-backend/internals/models"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// submissionColumns are the columns of leetcode_submissions after user_id and region, in the
+// order of submissionFields
+var submissionColumns = []string{
+	"id", "question_id", "title", "title_slug", "code", "lang", "lang_name", "timestamp", "status_display",
+	"runtime", "url", "is_pending", "memory", "is_best_solution", "best_time_complexity",
+	"current_time_complexity", "best_space_complexity", "current_space_complexity",
+}
+
+func submissionFields(s *models.LeetCodeSubmission) []any {
+	return []any{
+		&s.ID, &s.QuestionID, &s.Title, &s.TitleSlug, &s.Code, &s.Lang, &s.LangName, &s.Timestamp, &s.StatusDisplay,
+		&s.Runtime, &s.URL, &s.IsPending, &s.Memory, &s.IsBestSolution, &s.BestTimeComplexity,
+		&s.CurrentTimeComplexity, &s.BestSpaceComplexity, &s.CurrentSpaceComplexity,
+	}
+}
+
+var (
+	submissionColumnList   = strings.Join(submissionColumns, ", ")
+	submissionPlaceholders = strings.TrimSuffix(strings.Repeat("?, ", len(submissionColumns)), ", ")
+	submissionUpserts      = assignments(submissionColumns[1:], func(c string) string { return "excluded." + c })
+)
+
+// syncStateAdvances are the assignments of advanceSyncState: a sync that finished after a
+// newer one of the same site keeps the progress of the newer one
+var syncStateAdvances = assignments([]string{"last_submission_id", "last_timestamp", "backfill_since_id", "backfill_before_id"},
+	func(c string) string {
+		return `CASE WHEN excluded.region <> submission_sync.region
+			OR excluded.last_submission_id >= submission_sync.last_submission_id
+			THEN excluded.` + c + ` ELSE submission_sync.` + c + ` END`
+	})
+
+func (ss *SQLStore) SaveSubmissions(ctx context.Context, userID string, submissions []models.LeetCodeSubmission, state models.SubmissionSyncState) error {
+	err := ss.inTx(ctx, func(tx *sql.Tx) error {
+		for i := range submissions {
+			_, err := ss.exec(ctx, tx, `INSERT INTO leetcode_submissions (user_id, region, `+submissionColumnList+`)
+				VALUES (?, ?, `+submissionPlaceholders+`)
+				ON CONFLICT (user_id, region, id) DO UPDATE SET `+submissionUpserts,
+				append([]any{userID, submissionRegion(state.Region)}, submissionFields(&submissions[i])...)...)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := ss.exec(ctx, tx, `INSERT INTO submission_sync (user_id, region, last_submission_id, last_timestamp,
+				backfill_since_id, backfill_before_id, synced_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (user_id) DO UPDATE SET
+				`+syncStateAdvances+`,
+				region = excluded.region,
+				synced_at = excluded.synced_at`,
+			userID, state.Region, state.LastSubmissionID, state.LastTimestamp, state.BackfillSinceID, state.BackfillBeforeID,
+			state.SyncedAt.UTC())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save submissions: %w", err)
+	}
+	return nil
+}
+
+func (ss *SQLStore) GetSubmissionSyncState(ctx context.Context, userID string) (models.SubmissionSyncState, error) {
+	state := models.SubmissionSyncState{UserID: userID}
+	err := ss.queryRow(ctx, ss.DB, `SELECT region, last_submission_id, last_timestamp, backfill_since_id, backfill_before_id, synced_at
+		FROM submission_sync WHERE user_id = ?`,
+		userID).Scan(&state.Region, &state.LastSubmissionID, &state.LastTimestamp, &state.BackfillSinceID, &state.BackfillBeforeID,
+		&state.SyncedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to get submission sync state: %w", err)
+	}
+	return state, nil
+}
+
+// QuerySubmissions filters in SQL and reads rows until the page is full, submissions stored
+// without a title_slug are matched on the slug derived from their URL or title
+func (ss *SQLStore) QuerySubmissions(ctx context.Context, userID string, q SubmissionQuery) (SubmissionPage, error) {
+	before, err := q.before()
+	if err != nil {
+		return SubmissionPage{}, err
+	}
+	where := []string{"user_id = ?", "region = ?"}
+	args := []any{userID, submissionRegion(q.Region)}
+	filter := func(condition string, values ...any) {
+		where = append(where, condition)
+		args = append(args, values...)
+	}
+	if q.Status != "" {
+		filter("LOWER(status_display) = LOWER(?)", q.Status)
+	}
+	if q.Language != "" {
+		filter("(LOWER(lang) = LOWER(?) OR LOWER(lang_name) = LOWER(?))", q.Language, q.Language)
+	}
+	if q.Slug != "" {
+		filter("(title_slug = ? OR title_slug = '')", q.Slug)
+	}
+	if q.Search != "" {
+		filter(`LOWER(title) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(q.Search))+"%")
+	}
+	if !q.Since.IsZero() {
+		filter("timestamp >= ?", q.Since.Unix())
+	}
+	if !q.Until.IsZero() {
+		filter("timestamp < ?", q.Until.Unix())
+	}
+	if before > 0 {
+		filter("id < ?", before)
+	}
+	query := `SELECT ` + submissionColumnList + ` FROM leetcode_submissions WHERE ` + strings.Join(where, " AND ") +
+		` ORDER BY id DESC`
+	rows, err := ss.query(ctx, ss.DB, query, args...)
+	if err != nil {
+		return SubmissionPage{}, fmt.Errorf("failed to query submissions: %w", err)
+	}
+	defer rows.Close()
+	var matched []models.LeetCodeSubmission
+	for (q.Limit <= 0 || len(matched) <= q.Limit) && rows.Next() {
+		var s models.LeetCodeSubmission
+		if err := rows.Scan(submissionFields(&s)...); err != nil {
+			return SubmissionPage{}, fmt.Errorf("failed to parse submission: %w", err)
+		}
+		if q.matches(s) {
+			matched = append(matched, s)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return SubmissionPage{}, fmt.Errorf("failed to query submissions: %w", err)
+	}
+	return submissionPageOf(matched, q.Limit), nil
+}
@@ -0,0 +1,111 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// trashRevisionProblem keeps a copy of problem in revision_trash, deleting the same problem
+// again replaces the older copy
+func (ss *SQLStore) trashRevisionProblem(ctx context.Context, tx *sql.Tx, userID string, problem models.RevisionProblem) error {
+	data, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	_, err = ss.exec(ctx, tx, `INSERT INTO revision_trash (user_id, problem_id, deleted_at, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, problem_id) DO UPDATE SET deleted_at = excluded.deleted_at, data = excluded.data`,
+		userID, problem.Problem_id, time.Now().UTC(), string(data))
+	return err
+}
+
+func (ss *SQLStore) GetTrash(ctx context.Context, userID string) ([]models.TrashedRevision, error) {
+	rows, err := ss.query(ctx, ss.DB, `SELECT deleted_at, data FROM revision_trash WHERE user_id = ?
+		ORDER BY deleted_at DESC, problem_id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	defer rows.Close()
+	trash := []models.TrashedRevision{}
+	for rows.Next() {
+		var t models.TrashedRevision
+		var data string
+		if err := rows.Scan(&t.Deleted_at, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse trashed revision: %w", err)
+		}
+		if err := json.Unmarshal([]byte(data), &t.RevisionProblem); err != nil {
+			return nil, fmt.Errorf("failed to parse trashed revision: %w", err)
+		}
+		trash = append(trash, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	return trash, nil
+}
+
+func (ss *SQLStore) RestoreRevisionProblem(ctx context.Context, userID string, problemID string) (models.RevisionProblem, error) {
+	var problem models.RevisionProblem
+	err := ss.inTx(ctx, func(tx *sql.Tx) error {
+		var data string
+		err := ss.queryRow(ctx, tx, `SELECT data FROM revision_trash WHERE user_id = ? AND problem_id = ?`, userID, problemID).Scan(&data)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(data), &problem); err != nil {
+			return err
+		}
+		current, err := ss.getRevisionProblem(ctx, tx, userID, problemID)
+		if err == nil {
+			return &ConflictError{Current: current}
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		// the version keeps counting so edits made before the delete cannot apply
+		problem.Version++
+		if err := ss.insertRevisionProblem(ctx, tx, userID, time.Now().UTC(), problem); err != nil {
+			return err
+		}
+		_, err = ss.exec(ctx, tx, `DELETE FROM revision_trash WHERE user_id = ? AND problem_id = ?`, userID, problemID)
+		return err
+	})
+	if err != nil {
+		return models.RevisionProblem{}, fmt.Errorf("failed to restore revision problem: %w", err)
+	}
+	return problem, nil
+}
+
+// PurgeTrash also drops the notes history and the reviews of the purged problems that were
+// not added again
+func (ss *SQLStore) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	var purged int64
+	err := ss.inTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range []string{"revision_reviews", "revision_notes"} {
+			_, err := ss.exec(ctx, tx, `DELETE FROM `+table+` WHERE EXISTS (SELECT 1 FROM revision_trash t
+					WHERE t.user_id = `+table+`.user_id AND t.problem_id = `+table+`.problem_id AND t.deleted_at < ?)
+				AND NOT EXISTS (SELECT 1 FROM revision_problems p
+					WHERE p.user_id = `+table+`.user_id AND p.problem_id = `+table+`.problem_id)`, before.UTC())
+			if err != nil {
+				return err
+			}
+		}
+		res, err := ss.exec(ctx, tx, `DELETE FROM revision_trash WHERE deleted_at < ?`, before.UTC())
+		if err != nil {
+			return err
+		}
+		purged, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	return int(purged), nil
+}
@@ -0,0 +1,405 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+var sqliteDialect = dialect{
+	name: "sqlite",
+	createMigrationsTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`,
+	migrations: sqliteMigrations,
+}
+
+var sqliteMigrations = []migration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE TABLE revision_problems (
+				user_id TEXT NOT NULL,
+				title TEXT NOT NULL,
+				added_at TEXT NOT NULL,
+				submission_id INTEGER NOT NULL DEFAULT 0,
+				code TEXT NOT NULL DEFAULT '',
+				lang TEXT NOT NULL DEFAULT '',
+				lang_name TEXT NOT NULL DEFAULT '',
+				timestamp INTEGER NOT NULL DEFAULT 0,
+				status_display TEXT NOT NULL DEFAULT '',
+				runtime TEXT NOT NULL DEFAULT '',
+				url TEXT NOT NULL DEFAULT '',
+				is_pending TEXT NOT NULL DEFAULT '',
+				memory TEXT NOT NULL DEFAULT '',
+				is_best_solution BOOLEAN NOT NULL DEFAULT FALSE,
+				best_time_complexity TEXT NOT NULL DEFAULT '',
+				current_time_complexity TEXT NOT NULL DEFAULT '',
+				best_space_complexity TEXT NOT NULL DEFAULT '',
+				current_space_complexity TEXT NOT NULL DEFAULT '',
+				notes TEXT NOT NULL DEFAULT '',
+				last_revised TEXT NOT NULL DEFAULT '',
+				next_revision TEXT NOT NULL DEFAULT '',
+				difficulty TEXT NOT NULL DEFAULT '',
+				confidence_level INTEGER NOT NULL DEFAULT 0,
+				revision_count INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY (user_id, title)
+			)`,
+			`CREATE INDEX idx_revision_problems_next_revision ON revision_problems (user_id, next_revision)`,
+			`CREATE TABLE revision_tags (
+				user_id TEXT NOT NULL,
+				title TEXT NOT NULL,
+				tag TEXT NOT NULL,
+				position INTEGER NOT NULL,
+				PRIMARY KEY (user_id, title, tag),
+				FOREIGN KEY (user_id, title) REFERENCES revision_problems (user_id, title) ON DELETE CASCADE
+			)`,
+			`CREATE TABLE submission_feedback (
+				problem_id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			)`,
+			`CREATE TABLE analyse_submission (
+				problem_id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			)`,
+			`CREATE TABLE high_level_analysis (
+				problem_id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 2,
+		statements: []string{
+			`ALTER TABLE revision_problems ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+	},
+	{
+		version: 3,
+		statements: []string{
+			`ALTER TABLE revision_problems ADD COLUMN problem_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE revision_problems ADD COLUMN question_id INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN title_slug TEXT NOT NULL DEFAULT ''`,
+		},
+		run: backfillProblemIDs,
+	},
+	{
+		// sqlite cannot change a primary key in place, the tables are rebuilt keyed by problem_id
+		version: 4,
+		statements: []string{
+			`CREATE TABLE revision_problems_v4 (
+				user_id TEXT NOT NULL,
+				problem_id TEXT NOT NULL,
+				title TEXT NOT NULL,
+				added_at TEXT NOT NULL,
+				question_id INTEGER NOT NULL DEFAULT 0,
+				title_slug TEXT NOT NULL DEFAULT '',
+				submission_id INTEGER NOT NULL DEFAULT 0,
+				code TEXT NOT NULL DEFAULT '',
+				lang TEXT NOT NULL DEFAULT '',
+				lang_name TEXT NOT NULL DEFAULT '',
+				timestamp INTEGER NOT NULL DEFAULT 0,
+				status_display TEXT NOT NULL DEFAULT '',
+				runtime TEXT NOT NULL DEFAULT '',
+				url TEXT NOT NULL DEFAULT '',
+				is_pending TEXT NOT NULL DEFAULT '',
+				memory TEXT NOT NULL DEFAULT '',
+				is_best_solution BOOLEAN NOT NULL DEFAULT FALSE,
+				best_time_complexity TEXT NOT NULL DEFAULT '',
+				current_time_complexity TEXT NOT NULL DEFAULT '',
+				best_space_complexity TEXT NOT NULL DEFAULT '',
+				current_space_complexity TEXT NOT NULL DEFAULT '',
+				notes TEXT NOT NULL DEFAULT '',
+				last_revised TEXT NOT NULL DEFAULT '',
+				next_revision TEXT NOT NULL DEFAULT '',
+				difficulty TEXT NOT NULL DEFAULT '',
+				confidence_level INTEGER NOT NULL DEFAULT 0,
+				revision_count INTEGER NOT NULL DEFAULT 0,
+				version INTEGER NOT NULL DEFAULT 1,
+				PRIMARY KEY (user_id, problem_id)
+			)`,
+			`INSERT INTO revision_problems_v4 (user_id, problem_id, title, added_at, question_id, title_slug, submission_id,
+				code, lang, lang_name, timestamp, status_display, runtime, url, is_pending, memory, is_best_solution,
+				best_time_complexity, current_time_complexity, best_space_complexity, current_space_complexity, notes,
+				last_revised, next_revision, difficulty, confidence_level, revision_count, version)
+			SELECT user_id, problem_id, title, added_at, question_id, title_slug, submission_id,
+				code, lang, lang_name, timestamp, status_display, runtime, url, is_pending, memory, is_best_solution,
+				best_time_complexity, current_time_complexity, best_space_complexity, current_space_complexity, notes,
+				last_revised, next_revision, difficulty, confidence_level, revision_count, version
+			FROM revision_problems WHERE true ORDER BY added_at DESC
+			ON CONFLICT (user_id, problem_id) DO NOTHING`,
+			`CREATE TABLE revision_tags_v4 (
+				user_id TEXT NOT NULL,
+				problem_id TEXT NOT NULL,
+				tag TEXT NOT NULL,
+				position INTEGER NOT NULL,
+				PRIMARY KEY (user_id, problem_id, tag),
+				FOREIGN KEY (user_id, problem_id) REFERENCES revision_problems_v4 (user_id, problem_id) ON DELETE CASCADE
+			)`,
+			`INSERT INTO revision_tags_v4 (user_id, problem_id, tag, position)
+			SELECT t.user_id, p.problem_id, t.tag, t.position
+			FROM revision_tags t
+			JOIN revision_problems p ON p.user_id = t.user_id AND p.title = t.title
+			JOIN revision_problems_v4 n ON n.user_id = p.user_id AND n.problem_id = p.problem_id AND n.title = p.title`,
+			`DROP TABLE revision_tags`,
+			`DROP TABLE revision_problems`,
+			`ALTER TABLE revision_problems_v4 RENAME TO revision_problems`,
+			`ALTER TABLE revision_tags_v4 RENAME TO revision_tags`,
+			`CREATE INDEX idx_revision_problems_next_revision ON revision_problems (user_id, next_revision)`,
+		},
+	},
+	{
+		version: 5,
+		statements: []string{
+			`ALTER TABLE revision_problems ADD COLUMN schedule_algorithm TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_interval INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_repetitions INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_lapses INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_ease REAL NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_stability REAL NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN schedule_difficulty REAL NOT NULL DEFAULT 0`,
+			`CREATE TABLE user_settings (
+				user_id TEXT PRIMARY KEY,
+				scheduler TEXT NOT NULL DEFAULT ''
+			)`,
+		},
+	},
+	{
+		// reviews have no foreign key, the history is kept when a revision is deleted and re-added
+		version: 6,
+		statements: []string{
+			`CREATE TABLE revision_reviews (
+				id TEXT PRIMARY KEY,
+				user_id TEXT NOT NULL,
+				problem_id TEXT NOT NULL,
+				reviewed_at TIMESTAMP NOT NULL,
+				grade INTEGER NOT NULL,
+				time_spent_seconds INTEGER NOT NULL DEFAULT 0,
+				notes_delta TEXT NOT NULL DEFAULT '',
+				next_revision TEXT NOT NULL DEFAULT ''
+			)`,
+			`CREATE INDEX idx_revision_reviews_problem ON revision_reviews (user_id, problem_id, reviewed_at)`,
+		},
+	},
+	{
+		// next_revision turns from a server local date into a UTC timestamp, existing dates are
+		// taken as UTC midnight. sqlite cannot change a column type so the column is replaced.
+		// Users get a time zone that decides where their day starts.
+		version: 7,
+		statements: []string{
+			`ALTER TABLE revision_problems ADD COLUMN next_revision_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00+00:00'`,
+			`UPDATE revision_problems SET next_revision_at = next_revision || ' 00:00:00+00:00' WHERE next_revision != ''`,
+			`DROP INDEX idx_revision_problems_next_revision`,
+			`ALTER TABLE revision_problems DROP COLUMN next_revision`,
+			`ALTER TABLE revision_problems RENAME COLUMN next_revision_at TO next_revision`,
+			`CREATE INDEX idx_revision_problems_next_revision ON revision_problems (user_id, next_revision)`,
+			`ALTER TABLE revision_reviews ADD COLUMN next_revision_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00+00:00'`,
+			`UPDATE revision_reviews SET next_revision_at = next_revision || ' 00:00:00+00:00' WHERE next_revision != ''`,
+			`ALTER TABLE revision_reviews DROP COLUMN next_revision`,
+			`ALTER TABLE revision_reviews RENAME COLUMN next_revision_at TO next_revision`,
+			`ALTER TABLE user_settings ADD COLUMN timezone TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 8,
+		statements: []string{
+			`ALTER TABLE user_settings ADD COLUMN daily_review_limit INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version: 9,
+		statements: []string{
+			`ALTER TABLE revision_problems ADD COLUMN failure_count INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE revision_problems ADD COLUMN is_leech BOOLEAN NOT NULL DEFAULT FALSE`,
+			`ALTER TABLE revision_problems ADD COLUMN relearning BOOLEAN NOT NULL DEFAULT FALSE`,
+			`ALTER TABLE revision_problems ADD COLUMN relearning_step INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE user_settings ADD COLUMN leech_threshold INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version: 10,
+		statements: []string{
+			`ALTER TABLE user_settings ADD COLUMN paused_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00+00:00'`,
+		},
+	},
+	{
+		version: 11,
+		statements: []string{
+			`CREATE TABLE decks (
+				user_id TEXT NOT NULL,
+				id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				archived BOOLEAN NOT NULL DEFAULT FALSE,
+				scheduler TEXT NOT NULL DEFAULT '',
+				daily_review_limit INTEGER NOT NULL DEFAULT 0,
+				leech_threshold INTEGER NOT NULL DEFAULT 0,
+				created_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (user_id, id)
+			)`,
+			`CREATE TABLE revision_decks (
+				user_id TEXT NOT NULL,
+				problem_id TEXT NOT NULL,
+				deck_id TEXT NOT NULL,
+				position INTEGER NOT NULL,
+				PRIMARY KEY (user_id, problem_id, deck_id),
+				FOREIGN KEY (user_id, problem_id) REFERENCES revision_problems (user_id, problem_id) ON DELETE CASCADE
+			)`,
+			`CREATE INDEX idx_revision_decks_deck ON revision_decks (user_id, deck_id)`,
+		},
+	},
+	{
+		// deleted revisions are kept as JSON, with their tags and decks, until the trash is purged
+		version: 12,
+		statements: []string{
+			`CREATE TABLE revision_trash (
+				user_id TEXT NOT NULL,
+				problem_id TEXT NOT NULL,
+				deleted_at TIMESTAMP NOT NULL,
+				data TEXT NOT NULL,
+				PRIMARY KEY (user_id, problem_id)
+			)`,
+			`CREATE INDEX idx_revision_trash_deleted_at ON revision_trash (deleted_at)`,
+		},
+	},
+	{
+		// every write that changes the notes of a revision keeps them under the new version,
+		// existing notes become the first entry of their history
+		version: 13,
+		statements: []string{
+			`CREATE TABLE revision_notes (
+				user_id TEXT NOT NULL,
+				problem_id TEXT NOT NULL,
+				version INTEGER NOT NULL,
+				notes TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (user_id, problem_id, version)
+			)`,
+			`INSERT INTO revision_notes (user_id, problem_id, version, notes, created_at)
+				SELECT user_id, problem_id, version, notes, added_at FROM revision_problems`,
+		},
+	},
+	{
+		version: 14,
+		statements: []string{
+			`CREATE TABLE leetcode_submissions (
+				user_id TEXT NOT NULL,
+				id INTEGER NOT NULL,
+				question_id INTEGER NOT NULL DEFAULT 0,
+				title TEXT NOT NULL DEFAULT '',
+				title_slug TEXT NOT NULL DEFAULT '',
+				code TEXT NOT NULL DEFAULT '',
+				lang TEXT NOT NULL DEFAULT '',
+				lang_name TEXT NOT NULL DEFAULT '',
+				timestamp INTEGER NOT NULL DEFAULT 0,
+				status_display TEXT NOT NULL DEFAULT '',
+				runtime TEXT NOT NULL DEFAULT '',
+				url TEXT NOT NULL DEFAULT '',
+				is_pending TEXT NOT NULL DEFAULT '',
+				memory TEXT NOT NULL DEFAULT '',
+				is_best_solution BOOLEAN NOT NULL DEFAULT FALSE,
+				best_time_complexity TEXT NOT NULL DEFAULT '',
+				current_time_complexity TEXT NOT NULL DEFAULT '',
+				best_space_complexity TEXT NOT NULL DEFAULT '',
+				current_space_complexity TEXT NOT NULL DEFAULT '',
+				PRIMARY KEY (user_id, id)
+			)`,
+			`CREATE TABLE submission_sync (
+				user_id TEXT PRIMARY KEY,
+				last_submission_id INTEGER NOT NULL,
+				last_timestamp INTEGER NOT NULL,
+				synced_at TIMESTAMP NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 15,
+		statements: []string{
+			`CREATE TABLE problem_metadata (
+				problem_id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		// syncs before leetcode.cn support were all of leetcode.com
+		version: 16,
+		statements: []string{
+			`ALTER TABLE user_settings ADD COLUMN leetcode_region TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE submission_sync ADD COLUMN region TEXT NOT NULL DEFAULT 'com'`,
+		},
+	},
+	{
+		version: 17,
+		statements: []string{
+			`ALTER TABLE user_settings ADD COLUMN resume_cursor TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		// submission IDs of leetcode.com and leetcode.cn overlap, the site becomes part of the
+		// key and the stored submissions, all synced before sites could be picked, are of .com
+		version: 18,
+		statements: []string{
+			`CREATE TABLE leetcode_submissions_new (
+				user_id TEXT NOT NULL,
+				region TEXT NOT NULL DEFAULT 'com',
+				id INTEGER NOT NULL,
+				question_id INTEGER NOT NULL DEFAULT 0,
+				title TEXT NOT NULL DEFAULT '',
+				title_slug TEXT NOT NULL DEFAULT '',
+				code TEXT NOT NULL DEFAULT '',
+				lang TEXT NOT NULL DEFAULT '',
+				lang_name TEXT NOT NULL DEFAULT '',
+				timestamp INTEGER NOT NULL DEFAULT 0,
+				status_display TEXT NOT NULL DEFAULT '',
+				runtime TEXT NOT NULL DEFAULT '',
+				url TEXT NOT NULL DEFAULT '',
+				is_pending TEXT NOT NULL DEFAULT '',
+				memory TEXT NOT NULL DEFAULT '',
+				is_best_solution BOOLEAN NOT NULL DEFAULT FALSE,
+				best_time_complexity TEXT NOT NULL DEFAULT '',
+				current_time_complexity TEXT NOT NULL DEFAULT '',
+				best_space_complexity TEXT NOT NULL DEFAULT '',
+				current_space_complexity TEXT NOT NULL DEFAULT '',
+				PRIMARY KEY (user_id, region, id)
+			)`,
+			`INSERT INTO leetcode_submissions_new (user_id, id, question_id, title, title_slug, code, lang, lang_name, timestamp, status_display,
+					runtime, url, is_pending, memory, is_best_solution, best_time_complexity,
+					current_time_complexity, best_space_complexity, current_space_complexity)
+				SELECT user_id, id, question_id, title, title_slug, code, lang, lang_name, timestamp, status_display,
+					runtime, url, is_pending, memory, is_best_solution, best_time_complexity,
+					current_time_complexity, best_space_complexity, current_space_complexity FROM leetcode_submissions`,
+			`DROP TABLE leetcode_submissions`,
+			`ALTER TABLE leetcode_submissions_new RENAME TO leetcode_submissions`,
+		},
+	},
+	{
+		version: 19,
+		statements: []string{
+			`ALTER TABLE submission_sync ADD COLUMN backfill_since_id INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE submission_sync ADD COLUMN backfill_before_id INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+}
+
+// NewSQLiteStore opens (or creates) the database file at path and brings its schema up to date
+func NewSQLiteStore(ctx context.Context, path string) (*SQLStore, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite", path))
+	if err != nil {
+		return nil, fmt.Errorf("error opening sqlite database: %w", err)
+	}
+	// sqlite only allows a single writer, serialising through one connection avoids SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	store := &SQLStore{DB: db, dialect: sqliteDialect}
+	if err := store.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
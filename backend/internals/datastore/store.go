@@ -0,0 +1,237 @@
+package datastore
+
+import (
+	"context"
+	"crypto/rand"
+	"dsa-helper-backend/internals/config"
+	"dsa-helper-backend/internals/models"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// MaxUpdateRevisionProblems is the most problems one UpdateRevisionProblems call takes, a
+// Firestore transaction is capped at 500 writes
+const MaxUpdateRevisionProblems = 500
+
+// ErrNotFound is returned when a requested document does not exist in the store
+var ErrNotFound = errors.New("not found")
+
+// ConflictError is returned when a write carries a version that is older than the stored
+// one, Current holds the server copy so the client can merge and retry
+type ConflictError struct {
+	Current models.RevisionProblem
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("revision problem %q was modified concurrently, current version is %d", e.Current.Problem_id, e.Current.Version)
+}
+
+// Kinds of RevisionWrite
+const (
+	WriteCreate = "create"
+	WriteUpdate = "update"
+	WriteDelete = "delete"
+)
+
+// RevisionWrite is one write of WriteRevisionProblems. A create is saved like
+// AddRevisionProblems, an update like UpdateRevisionProblem and a delete like DeleteRevisionProblem.
+type RevisionWrite struct {
+	Kind    string
+	Problem models.RevisionProblem
+}
+
+// WriteError wraps the error of the write at Index when a batch is rolled back
+type WriteError struct {
+	Index int
+	Err   error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("write %d: %v", e.Index, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// RestoredRevision is a revision with its history, as written by RestoreRevisionProblems
+type RestoredRevision struct {
+	Problem models.RevisionProblem
+	Reviews []models.ReviewRecord
+	Notes   []models.NoteVersion
+}
+
+// Store is implemented by every storage backend for revisions and cached analyses.
+// Revisions are keyed by their Problem_id, Update and Delete derive it when it is missing.
+// Stores only persist revisions, scheduling is done by the callers.
+type Store interface {
+	AddRevisionProblems(ctx context.Context, userID string, newRevisions []models.RevisionProblem) error
+	GetRevisionProblems(ctx context.Context, userID string) ([]models.RevisionProblem, error)
+	// QueryRevisionProblems returns one page of the revisions matching q, it returns
+	// ErrInvalidQuery for an unknown sort key or a malformed cursor
+	QueryRevisionProblems(ctx context.Context, userID string, q RevisionQuery) (RevisionPage, error)
+	// GetRevisionProblem returns ErrNotFound when the user has no revision for problemID
+	GetRevisionProblem(ctx context.Context, userID string, problemID string) (models.RevisionProblem, error)
+	// UpdateRevisionProblem only applies when problem.Version matches the stored version
+	// (a zero version skips the check) and returns the stored copy with its new version.
+	// It returns ErrNotFound for unknown problems and a *ConflictError on version mismatch.
+	UpdateRevisionProblem(ctx context.Context, userID string, problem models.RevisionProblem) (models.RevisionProblem, error)
+	// UpdateRevisionProblems applies UpdateRevisionProblem to at most MaxUpdateRevisionProblems
+	// problems, either all of them are saved or none is
+	UpdateRevisionProblems(ctx context.Context, userID string, problems []models.RevisionProblem) ([]models.RevisionProblem, error)
+	// WriteRevisionProblems applies writes in order, either all of them are saved or none is,
+	// and returns the stored copy of every problem. A failed write is reported as a *WriteError.
+	WriteRevisionProblems(ctx context.Context, userID string, writes []RevisionWrite) ([]models.RevisionProblem, error)
+	// DeleteRevisionProblem moves the problem to the trash, it follows the same version rule
+	// as UpdateRevisionProblem
+	DeleteRevisionProblem(ctx context.Context, userID string, problem models.RevisionProblem) error
+	// GetTrash returns the deleted problems of the user, most recently deleted first
+	GetTrash(ctx context.Context, userID string) ([]models.TrashedRevision, error)
+	// RestoreRevisionProblem moves a problem back from the trash. It returns ErrNotFound when
+	// the problem is not in the trash and a *ConflictError when it was added again since.
+	RestoreRevisionProblem(ctx context.Context, userID string, problemID string) (models.RevisionProblem, error)
+	// PurgeTrash permanently removes the problems of every user deleted before the given time,
+	// with their notes history and reviews, and returns how many were removed
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+	// RestoreRevisionProblems writes revisions read from an export as they are, versions
+	// included. Each replaces the stored problem with the same ID along with its reviews and
+	// notes history. Reviews get new IDs so an export can be restored next to the account it
+	// was taken from.
+	RestoreRevisionProblems(ctx context.Context, userID string, restored []RestoredRevision) error
+	// GetDueRevisionProblems returns the problems with a Next_revision before the given time
+	GetDueRevisionProblems(ctx context.Context, userID string, before time.Time) ([]models.RevisionProblem, error)
+	// RecordReview saves problem like UpdateRevisionProblem and appends review to its history
+	// in the same write, the stored review gets an ID
+	RecordReview(ctx context.Context, userID string, problem models.RevisionProblem, review models.ReviewRecord) (models.RevisionProblem, models.ReviewRecord, error)
+	// GetReviews returns the review history of a problem, oldest first
+	GetReviews(ctx context.Context, userID string, problemID string) ([]models.ReviewRecord, error)
+	// GetNoteVersions returns the notes history of a problem, oldest first. Every write that
+	// changes the notes of a problem adds to it.
+	GetNoteVersions(ctx context.Context, userID string, problemID string) ([]models.NoteVersion, error)
+	// GetNoteVersion returns ErrNotFound when no write of that version changed the notes
+	GetNoteVersion(ctx context.Context, userID string, problemID string, version int64) (models.NoteVersion, error)
+	// GetUserSettings returns the zero settings for users that never saved any
+	GetUserSettings(ctx context.Context, userID string) (models.UserSettings, error)
+	SaveUserSettings(ctx context.Context, settings models.UserSettings) error
+	// CreateDeck stores a new deck and returns it with its generated ID and creation time
+	CreateDeck(ctx context.Context, userID string, deck models.Deck) (models.Deck, error)
+	// GetDecks returns all decks of the user, archived ones included, oldest first
+	GetDecks(ctx context.Context, userID string) ([]models.Deck, error)
+	// GetDeck returns ErrNotFound when the user has no deck with deckID
+	GetDeck(ctx context.Context, userID string, deckID string) (models.Deck, error)
+	// UpdateDeck replaces a stored deck, it returns ErrNotFound for unknown decks
+	UpdateDeck(ctx context.Context, userID string, deck models.Deck) error
+	// SaveSubmissions stores LeetCode submissions of the user made on the site of state,
+	// replacing those of that site with the same ID, and then the sync state they bring the
+	// user to. The state never moves back to an older submission.
+	SaveSubmissions(ctx context.Context, userID string, submissions []models.LeetCodeSubmission, state models.SubmissionSyncState) error
+	// GetSubmissionSyncState returns the zero state for users that never synced
+	GetSubmissionSyncState(ctx context.Context, userID string) (models.SubmissionSyncState, error)
+	// QuerySubmissions returns one page of the stored submissions of the site of q matching q,
+	// newest first, it returns ErrInvalidQuery for a malformed cursor
+	QuerySubmissions(ctx context.Context, userID string, q SubmissionQuery) (SubmissionPage, error)
+	// AddAnalysisProblems stores toAdd under id in the given analysis collection
+	AddAnalysisProblems(ctx context.Context, collectionName string, id string, toAdd any) error
+	// GetAnalysisProblems loads the analysis stored under id into dst
+	GetAnalysisProblems(ctx context.Context, collectionName string, id string, dst any) error
+}
+
+// NewStore returns the backend selected in the datastore config
+func NewStore(ctx context.Context, cfg config.DatastoreConfig, firestoreClient *firestore.Client) (Store, error) {
+	switch cfg.Backend {
+	case config.BackendFirestore:
+		if firestoreClient == nil {
+			return nil, fmt.Errorf("firestore backend selected but firestore client is not initialized")
+		}
+		return &Datastore{FirestoreClient: firestoreClient}, nil
+	case config.BackendMemory:
+		return NewMemoryStore(), nil
+	case config.BackendSQLite:
+		return NewSQLiteStore(ctx, cfg.SQLitePath)
+	case config.BackendPostgres:
+		return NewPostgresStore(ctx, cfg.PostgresDSN)
+	default:
+		return nil, fmt.Errorf("unknown datastore backend %q", cfg.Backend)
+	}
+}
+
+// checkVersion reports a conflict when the client sent a version that no longer matches
+func checkVersion(current models.RevisionProblem, problem models.RevisionProblem) error {
+	if problem.Version != 0 && problem.Version != current.Version {
+		return &ConflictError{Current: current}
+	}
+	return nil
+}
+
+// nextRevision returns problem as it is written over current, one version further. problem
+// is taken by value: Firestore runs a transaction again when its commit fails, and the retry
+// has to check the version the client sent, not the one the failed attempt would have written.
+func nextRevision(current models.RevisionProblem, problem models.RevisionProblem) (models.RevisionProblem, error) {
+	if err := checkVersion(current, problem); err != nil {
+		return models.RevisionProblem{}, err
+	}
+	problem.Version = current.Version + 1
+	return problem, nil
+}
+
+// mergeRevisionProblems replaces old problems with new ones of the same problem, carrying the version forward
+func mergeRevisionProblems(oldRevisions []models.RevisionProblem, newRevisions []models.RevisionProblem) []models.RevisionProblem {
+	for j := range newRevisions {
+		newRevisions[j].Version = 1
+	}
+	for i := 0; i < len(oldRevisions); i++ {
+		isAlreadyPresent := false
+		for j := 0; j < len(newRevisions); j++ {
+			if newRevisions[j].Problem_id == oldRevisions[i].Problem_id {
+				isAlreadyPresent = true
+				newRevisions[j].Version = oldRevisions[i].Version + 1
+			}
+		}
+		if !isAlreadyPresent {
+			newRevisions = append(newRevisions, oldRevisions[i])
+		}
+	}
+	return newRevisions
+}
+
+func filterDueRevisionProblems(problems []models.RevisionProblem, before time.Time) []models.RevisionProblem {
+	var dueProblems []models.RevisionProblem
+	for _, p := range problems {
+		if p.Next_revision.Before(before) {
+			dueProblems = append(dueProblems, p)
+		}
+	}
+	return dueProblems
+}
+
+// notesChanged reports whether writing problem over current, nil for a new problem, adds
+// a version to the notes history
+func notesChanged(current *models.RevisionProblem, problem models.RevisionProblem) bool {
+	return current == nil || current.Notes != problem.Notes
+}
+
+func prepareRevisionProblems(newRevisions []models.RevisionProblem) error {
+	return models.PreprocessRevisionProblems(newRevisions)
+}
+
+// newID returns a random ID for reviews and decks of stores that do not generate their own
+func newID() string {
+	b := make([]byte, 10)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// restoredReviews copies the reviews of r with new IDs and the problem ID of the restored problem
+func restoredReviews(r RestoredRevision) []models.ReviewRecord {
+	reviews := make([]models.ReviewRecord, len(r.Reviews))
+	for i, review := range r.Reviews {
+		review.ID = newID()
+		review.Problem_id = r.Problem.Problem_id
+		reviews[i] = review
+	}
+	return reviews
+}
@@ -0,0 +1,42 @@
+package datastore
+
+import (
+	"dsa-helper-backend/internals/models"
+	"errors"
+	"testing"
+)
+
+// TestNextRevisionRetry runs the version check of a Firestore update the way a contended
+// transaction does: the first attempt fails to commit because another writer got in, the
+// retry reads that writer's revision and has to report the conflict
+func TestNextRevisionRetry(t *testing.T) {
+	problem := models.RevisionProblem{Problem_id: "two-sum", Version: 3, Notes: "mine"}
+	attempt := func(current models.RevisionProblem) (models.RevisionProblem, error) {
+		return nextRevision(current, problem)
+	}
+
+	next, err := attempt(models.RevisionProblem{Problem_id: "two-sum", Version: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Version != 4 || next.Notes != "mine" {
+		t.Fatalf("first attempt wrote %+v, want version 4 with the new notes", next)
+	}
+	// the commit failed, meanwhile another writer stored version 4
+	concurrent := models.RevisionProblem{Problem_id: "two-sum", Version: 4, Notes: "theirs"}
+	var conflict *ConflictError
+	if _, err := attempt(concurrent); !errors.As(err, &conflict) {
+		t.Fatalf("retry after a concurrent write: %v, want ConflictError", err)
+	}
+	if conflict.Current.Notes != "theirs" {
+		t.Errorf("conflict carries %+v, want the concurrent revision", conflict.Current)
+	}
+	if problem.Version != 3 {
+		t.Errorf("the attempts changed the version of the caller's problem to %d", problem.Version)
+	}
+
+	// a problem without a version skips the check
+	if next, err := nextRevision(concurrent, models.RevisionProblem{Problem_id: "two-sum"}); err != nil || next.Version != 5 {
+		t.Errorf("unversioned update gave %+v %v, want version 5", next, err)
+	}
+}
@@ -0,0 +1,255 @@
+package datastore
+
+import (
+	"cmp"
+	"context"
+	"dsa-helper-backend/internals/models"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SubmissionQuery selects a page of the stored submissions of a user, newest first. Zero
+// fields do not filter.
+type SubmissionQuery struct {
+	// Region is the LeetCode site the submissions were made on, empty is leetcode.com. It
+	// always filters, the IDs of the sites overlap.
+	Region string
+	// Status matches the StatusDisplay of the submission, like Accepted
+	Status string
+	// Language matches the Lang or LangName of the submission
+	Language string
+	// Slug matches the problem of the submission
+	Slug string
+	// Search is a case insensitive substring of the title
+	Search string
+	// Since and Until bound the submission time, Until is exclusive
+	Since time.Time
+	Until time.Time
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+	// Limit is the page size, 0 returns every matching submission
+	Limit int
+}
+
+// SubmissionPage is one page of a SubmissionQuery, NextCursor is empty on the last page
+type SubmissionPage struct {
+	Submissions []models.LeetCodeSubmission `json:"submissions"`
+	NextCursor  string                      `json:"next_cursor"`
+}
+
+// submissionRegion is the site submissions saved or queried under region belong to, the
+// store keeps those of leetcode.com, the only site before regions, under "com"
+func submissionRegion(region string) string {
+	return cmp.Or(region, "com")
+}
+
+// LeetCode submission IDs grow over time, so pages are ordered by ID and the cursor is the
+// ID of the last submission of the previous page
+
+// Validate decodes the cursor of the query
+func (q SubmissionQuery) Validate() error {
+	_, err := q.before()
+	return err
+}
+
+// before returns the ID the page starts below, 0 on the first page
+func (q SubmissionQuery) before() (int64, error) {
+	if q.Cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return 0, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return id, nil
+}
+
+// matches reports whether submission passes the filters of the query
+func (q SubmissionQuery) matches(s models.LeetCodeSubmission) bool {
+	if q.Status != "" && !strings.EqualFold(s.StatusDisplay, q.Status) {
+		return false
+	}
+	if q.Language != "" && !strings.EqualFold(s.Lang, q.Language) && !strings.EqualFold(s.LangName, q.Language) {
+		return false
+	}
+	if q.Slug != "" && s.ProblemSlug() != q.Slug {
+		return false
+	}
+	if q.Search != "" && !strings.Contains(strings.ToLower(s.Title), strings.ToLower(q.Search)) {
+		return false
+	}
+	if !q.Since.IsZero() && s.Timestamp < q.Since.Unix() {
+		return false
+	}
+	if !q.Until.IsZero() && s.Timestamp >= q.Until.Unix() {
+		return false
+	}
+	return true
+}
+
+// applySubmissionQuery runs a query over all the submissions of a user, for the stores that
+// cannot filter on their own
+func applySubmissionQuery(submissions []models.LeetCodeSubmission, q SubmissionQuery) (SubmissionPage, error) {
+	before, err := q.before()
+	if err != nil {
+		return SubmissionPage{}, err
+	}
+	var matched []models.LeetCodeSubmission
+	for _, s := range submissions {
+		if (before == 0 || s.ID < before) && q.matches(s) {
+			matched = append(matched, s)
+		}
+	}
+	slices.SortFunc(matched, func(a models.LeetCodeSubmission, b models.LeetCodeSubmission) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return submissionPageOf(matched, q.Limit), nil
+}
+
+// submissionPageOf cuts submissions, newest first and past the cursor, to the page size.
+// Stores fetch one submission more than the limit so they know whether another page follows.
+func submissionPageOf(submissions []models.LeetCodeSubmission, limit int) SubmissionPage {
+	page := SubmissionPage{Submissions: submissions}
+	if page.Submissions == nil {
+		page.Submissions = []models.LeetCodeSubmission{}
+	}
+	if limit > 0 && len(submissions) > limit {
+		page.Submissions = submissions[:limit]
+		last := page.Submissions[limit-1].ID
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(last, 10)))
+	}
+	return page
+}
+
+// advanceSyncState returns the state to store when next is saved over current, a sync that
+// finished after a newer one does not move the state back, nor the backfill it left. A sync of
+// another site replaces the state, its IDs do not compare with those of current.
+func advanceSyncState(current models.SubmissionSyncState, next models.SubmissionSyncState) models.SubmissionSyncState {
+	if next.Region == current.Region && next.LastSubmissionID < current.LastSubmissionID {
+		next.LastSubmissionID = current.LastSubmissionID
+		next.LastTimestamp = current.LastTimestamp
+		next.BackfillSinceID = current.BackfillSinceID
+		next.BackfillBeforeID = current.BackfillBeforeID
+	}
+	return next
+}
+
+// submissions of leetcode.com live in users/{uid}/submissions/{id}, those of another site in
+// users/{uid}/submissions_{region}/{id}, and the sync state in users/{uid}/sync/submissions
+
+func (ds *Datastore) submissionsCollection(userID string, region string) *firestore.CollectionRef {
+	if region = submissionRegion(region); region != "com" {
+		return ds.userDoc(userID).Collection("submissions_" + region)
+	}
+	return ds.userDoc(userID).Collection("submissions")
+}
+
+func (ds *Datastore) submissionSyncDoc(userID string) *firestore.DocumentRef {
+	return ds.userDoc(userID).Collection("sync").Doc("submissions")
+}
+
+// SaveSubmissions writes the submissions through a BulkWriter before the sync state, a sync
+// that fails halfway leaves the state where it was and the next one writes them again
+func (ds *Datastore) SaveSubmissions(ctx context.Context, userID string, submissions []models.LeetCodeSubmission, state models.SubmissionSyncState) error {
+	bulk := ds.FirestoreClient.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	var errs []error
+	for _, s := range submissions {
+		job, err := bulk.Set(ds.submissionsCollection(userID, state.Region).Doc(strconv.FormatInt(s.ID, 10)), s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	bulk.End()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to save submissions: %w", err)
+	}
+	state.UserID = userID
+	doc := ds.submissionSyncDoc(userID)
+	err := ds.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var current models.SubmissionSyncState
+		snap, err := tx.Get(doc)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := snap.DataTo(&current); err != nil {
+				return err
+			}
+		}
+		return tx.Set(doc, advanceSyncState(current, state))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save submission sync state: %w", err)
+	}
+	return nil
+}
+
+func (ds *Datastore) GetSubmissionSyncState(ctx context.Context, userID string) (models.SubmissionSyncState, error) {
+	state := models.SubmissionSyncState{UserID: userID}
+	snap, err := ds.submissionSyncDoc(userID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := snap.DataTo(&state); err != nil {
+		return state, fmt.Errorf("failed to parse submission sync state: %w", err)
+	}
+	return state, nil
+}
+
+// QuerySubmissions walks the submissions by descending ID, which the automatic single field
+// index serves, and filters them in the store until the page is full
+func (ds *Datastore) QuerySubmissions(ctx context.Context, userID string, q SubmissionQuery) (SubmissionPage, error) {
+	before, err := q.before()
+	if err != nil {
+		return SubmissionPage{}, err
+	}
+	query := ds.submissionsCollection(userID, q.Region).OrderBy("id", firestore.Desc)
+	if before > 0 {
+		query = query.Where("id", "<", before)
+	}
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+	var matched []models.LeetCodeSubmission
+	for q.Limit <= 0 || len(matched) <= q.Limit {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return SubmissionPage{}, fmt.Errorf("failed to query submissions: %w", err)
+		}
+		var s models.LeetCodeSubmission
+		if err := doc.DataTo(&s); err != nil {
+			return SubmissionPage{}, fmt.Errorf("failed to parse submission: %w", err)
+		}
+		if q.matches(s) {
+			matched = append(matched, s)
+		}
+	}
+	return submissionPageOf(matched, q.Limit), nil
+}
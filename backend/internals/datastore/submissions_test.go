@@ -0,0 +1,51 @@
+package datastore
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSubmissionsAreKeptPerSite(t *testing.T) {
+	for _, tt := range testStores {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store(t)
+			ctx := context.Background()
+			save := func(region string, submissions ...models.LeetCodeSubmission) {
+				t.Helper()
+				state := models.SubmissionSyncState{Region: region, LastSubmissionID: submissions[0].ID, SyncedAt: time.Now()}
+				if err := store.SaveSubmissions(ctx, "u", submissions, state); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// the IDs of the two sites overlap
+			save("com", models.LeetCodeSubmission{ID: 2, Title: "Two Sum"}, models.LeetCodeSubmission{ID: 1, Title: "Climbing Stairs"})
+			save("cn", models.LeetCodeSubmission{ID: 2, Title: "Coin Change"})
+			save("", models.LeetCodeSubmission{ID: 3, Title: "Valid Parentheses"})
+			titles := func(region string) []string {
+				t.Helper()
+				page, err := store.QuerySubmissions(ctx, "u", SubmissionQuery{Region: region})
+				if err != nil {
+					t.Fatal(err)
+				}
+				var titles []string
+				for _, s := range page.Submissions {
+					titles = append(titles, s.Title)
+				}
+				return titles
+			}
+			want := map[string][]string{
+				"com": {"Valid Parentheses", "Two Sum", "Climbing Stairs"},
+				"":    {"Valid Parentheses", "Two Sum", "Climbing Stairs"},
+				"cn":  {"Coin Change"},
+			}
+			for region, want := range want {
+				if got := titles(region); !slices.Equal(got, want) {
+					t.Errorf("region %q: got %q, want %q", region, got, want)
+				}
+			}
+		})
+	}
+}
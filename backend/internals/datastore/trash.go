@@ -0,0 +1,149 @@
+package datastore
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// trashCollection is users/{uid}/trash, a deleted revision keeps its problem ID as document ID
+func (ds *Datastore) trashCollection(userID string) *firestore.CollectionRef {
+	return ds.userDoc(userID).Collection("trash")
+}
+
+// trashRevisionProblem keeps a copy of problem in the trash as part of tx, deleting the same
+// problem again replaces the older copy
+func (ds *Datastore) trashRevisionProblem(tx *firestore.Transaction, userID string, problem models.RevisionProblem) error {
+	return tx.Set(ds.trashCollection(userID).Doc(problem.Problem_id), models.TrashedRevision{
+		RevisionProblem: problem,
+		Deleted_at:      time.Now().UTC(),
+	})
+}
+
+func (ds *Datastore) GetTrash(ctx context.Context, userID string) ([]models.TrashedRevision, error) {
+	docs, err := ds.trashCollection(userID).OrderBy("deleted_at", firestore.Desc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	trash := make([]models.TrashedRevision, 0, len(docs))
+	for _, doc := range docs {
+		var t models.TrashedRevision
+		if err := doc.DataTo(&t); err != nil {
+			return nil, fmt.Errorf("failed to parse trashed revision: %w", err)
+		}
+		trash = append(trash, t)
+	}
+	return trash, nil
+}
+
+func (ds *Datastore) RestoreRevisionProblem(ctx context.Context, userID string, problemID string) (models.RevisionProblem, error) {
+	trashDoc := ds.trashCollection(userID).Doc(problemID)
+	doc := ds.revisionsCollection(userID).Doc(problemID)
+	var problem models.RevisionProblem
+	err := ds.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snaps, err := tx.GetAll([]*firestore.DocumentRef{trashDoc, doc})
+		if err != nil {
+			return err
+		}
+		if !snaps[0].Exists() {
+			return ErrNotFound
+		}
+		if snaps[1].Exists() {
+			var current models.RevisionProblem
+			if err := snaps[1].DataTo(&current); err != nil {
+				return fmt.Errorf("failed to parse revision problem: %w", err)
+			}
+			return &ConflictError{Current: current}
+		}
+		var trashed models.TrashedRevision
+		if err := snaps[0].DataTo(&trashed); err != nil {
+			return fmt.Errorf("failed to parse trashed revision: %w", err)
+		}
+		problem = trashed.RevisionProblem
+		// the version keeps counting so edits made before the delete cannot apply
+		problem.Version++
+		if err := tx.Create(doc, problem); err != nil {
+			return err
+		}
+		return tx.Delete(trashDoc)
+	})
+	if err != nil {
+		return models.RevisionProblem{}, fmt.Errorf("failed to restore revision problem: %w", err)
+	}
+	return problem, nil
+}
+
+// purgeHistory deletes the notes history and the reviews of a trashed problem unless it was
+// added again, Firestore keeps both subcollections after the revision document is deleted
+func (ds *Datastore) purgeHistory(ctx context.Context, bulk *firestore.BulkWriter, trashDoc *firestore.DocumentRef) error {
+	revision := trashDoc.Parent.Parent.Collection("revisions").Doc(trashDoc.ID)
+	_, err := revision.Get(ctx)
+	if err == nil {
+		return nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return err
+	}
+	for _, collection := range []string{"notes", "reviews"} {
+		docs, err := revision.Collection(collection).DocumentRefs(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			if _, err := bulk.Delete(doc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PurgeTrash queries the trash of all users at once, which needs a single field index
+// exemption on deleted_at for the trash collection group
+func (ds *Datastore) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	iter := ds.FirestoreClient.CollectionGroup("trash").Where("deleted_at", "<", before).Documents(ctx)
+	defer iter.Stop()
+	bulk := ds.FirestoreClient.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	var errs []error
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if err := ds.purgeHistory(ctx, bulk, doc.Ref); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		job, err := bulk.Delete(doc.Ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	bulk.End()
+	purged := 0
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		purged++
+	}
+	if err := errors.Join(errs...); err != nil {
+		return purged, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,80 @@
+package datastore
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"testing"
+	"time"
+)
+
+func TestPurgeTrashDropsHistory(t *testing.T) {
+	for _, tt := range testStores {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store(t)
+			ctx := context.Background()
+			now := time.Now().UTC()
+			var problems []models.RevisionProblem
+			for _, title := range []string{"Two Sum", "Coin Change"} {
+				problems = append(problems, models.RevisionProblem{
+					LeetCodeSubmission: models.LeetCodeSubmission{Title: title},
+					Confidence_level:   3,
+					Next_revision:      now,
+				})
+			}
+			if err := store.AddRevisionProblems(ctx, "u", problems); err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range []string{"two-sum", "coin-change"} {
+				problem, err := store.GetRevisionProblem(ctx, "u", id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				problem.Notes = "hash map"
+				problem, _, err = store.RecordReview(ctx, "u", problem, models.ReviewRecord{Reviewed_at: now, Grade: 4})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := store.DeleteRevisionProblem(ctx, "u", problem); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// coin-change was added again, its history stays with it
+			if err := store.AddRevisionProblems(ctx, "u", problems[1:]); err != nil {
+				t.Fatal(err)
+			}
+
+			purged, err := store.PurgeTrash(ctx, now.Add(time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if purged != 2 {
+				t.Fatalf("purged %d trashed problems, want 2", purged)
+			}
+			history := func(id string) (int, int) {
+				t.Helper()
+				reviews, err := store.GetReviews(ctx, "u", id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				notes, err := store.GetNoteVersions(ctx, "u", id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return len(reviews), len(notes)
+			}
+			if reviews, notes := history("two-sum"); reviews != 0 || notes != 0 {
+				t.Errorf("purged problem kept %d reviews and %d note versions", reviews, notes)
+			}
+			if reviews, _ := history("coin-change"); reviews != 1 {
+				t.Errorf("problem added again has %d reviews, want its 1", reviews)
+			}
+			// adding the purged problem again starts with an empty history
+			if err := store.AddRevisionProblems(ctx, "u", problems[:1]); err != nil {
+				t.Fatal(err)
+			}
+			if reviews, _ := history("two-sum"); reviews != 0 {
+				t.Errorf("problem added after the purge has %d old reviews", reviews)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Package diff compares texts line by line
+package diff
+
+import "strings"
+
+// Kinds of Line
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// Line is one line of an edit script, Insert lines are only in the new text and Delete
+// lines only in the old one
+type Line struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+// Lines returns the edit script turning a into b, built from the longest common
+// subsequence of their lines
+func Lines(a string, b string) []Line {
+	x, y := splitLines(a), splitLines(b)
+	// the common prefix and suffix are cut before the quadratic part
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+	script := make([]Line, 0, len(x)+len(y))
+	for _, text := range x[:prefix] {
+		script = append(script, Line{Kind: Equal, Text: text})
+	}
+	script = append(script, lcs(x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+	for _, text := range x[len(x)-suffix:] {
+		script = append(script, Line{Kind: Equal, Text: text})
+	}
+	return script
+}
+
+// lcs diffs x and y with the classic dynamic program, common[i][j] is the length of the
+// longest common subsequence of x[i:] and y[j:]
+func lcs(x []string, y []string) []Line {
+	common := make([][]int, len(x)+1)
+	for i := range common {
+		common[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+	var script []Line
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			script = append(script, Line{Kind: Equal, Text: x[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			script = append(script, Line{Kind: Delete, Text: x[i]})
+			i++
+		default:
+			script = append(script, Line{Kind: Insert, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		script = append(script, Line{Kind: Delete, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		script = append(script, Line{Kind: Insert, Text: y[j]})
+	}
+	return script
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"dsa-helper-backend/internals/scheduler"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// maxBatchOperations bounds a batch so it fits in a single transaction of every store
+const maxBatchOperations = 500
+
+// Operations of POST /revisions:batch
+const (
+	batchCreate = "create"
+	batchUpdate = "update"
+	batchDelete = "delete"
+	batchRetag  = "retag"
+	batchReset  = "reset"
+)
+
+// batchOperation is one item of POST /revisions:batch. Create and update take the whole
+// revision, the other operations address an existing problem by Problem_id.
+type batchOperation struct {
+	Op       string                  `json:"op"`
+	Revision *models.RevisionProblem `json:"revision"`
+	// Problem_id and Version select the problem of delete, retag and reset, a zero
+	// version skips the concurrency check
+	Problem_id string `json:"problem_id"`
+	Version    int64  `json:"version"`
+	// Tags replaces the tags of a retag when set, Add_tags and Remove_tags are applied after it
+	Tags        []string `json:"tags"`
+	Add_tags    []string `json:"add_tags"`
+	Remove_tags []string `json:"remove_tags"`
+	// Confidence_level is the confidence a reset starts the schedule over with
+	Confidence_level int `json:"confidence_level"`
+}
+
+// batchResult is the outcome of one operation, Status is ok, failed or aborted when another
+// operation failed and the batch was rolled back
+type batchResult struct {
+	Index      int                     `json:"index"`
+	Op         string                  `json:"op"`
+	Problem_id string                  `json:"problem_id"`
+	Status     string                  `json:"status"`
+	Error      string                  `json:"error,omitempty"`
+	Revision   *models.RevisionProblem `json:"revision,omitempty"`
+}
+
+// batchError is a failed operation with the status the whole batch is answered with
+type batchError struct {
+	index  int
+	status int
+	err    error
+	// current is the server copy of a conflicting problem
+	current *models.RevisionProblem
+}
+
+// HandleBatchRevisions is POST /revisions:batch, it applies a list of create, update, delete,
+// retag and reset operations atomically. Each problem can appear once per batch.
+func (h *Handler) HandleBatchRevisions(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var operations []batchOperation
+	if err := json.NewDecoder(r.Body).Decode(&operations); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(operations) == 0 {
+		http.Error(w, "No operations provided", http.StatusBadRequest)
+		return
+	}
+	if len(operations) > maxBatchOperations {
+		http.Error(w, fmt.Sprintf("A batch holds at most %d operations", maxBatchOperations), http.StatusBadRequest)
+		return
+	}
+	results := make([]batchResult, len(operations))
+	for i, op := range operations {
+		results[i] = batchResult{Index: i, Op: op.Op, Problem_id: op.problemID(), Status: "aborted"}
+	}
+	writes, batchErr := h.batchWrites(r.Context(), userId, operations)
+	if batchErr != nil {
+		writeBatchError(w, results, batchErr)
+		return
+	}
+	written, err := h.Datastore.WriteRevisionProblems(r.Context(), userId, writes)
+	var writeErr *datastore.WriteError
+	if errors.As(err, &writeErr) {
+		batchErr = &batchError{index: writeErr.Index, status: http.StatusInternalServerError, err: writeErr.Err}
+		var conflict *datastore.ConflictError
+		if errors.As(writeErr.Err, &conflict) {
+			batchErr.status, batchErr.current = http.StatusConflict, &conflict.Current
+		} else if errors.Is(writeErr.Err, datastore.ErrNotFound) {
+			batchErr.status = http.StatusNotFound
+		}
+		writeBatchError(w, results, batchErr)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to apply batch: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for i := range results {
+		results[i].Status = "ok"
+		results[i].Problem_id = written[i].Problem_id
+		if writes[i].Kind != datastore.WriteDelete {
+			results[i].Revision = &written[i]
+		}
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Batch applied successfully",
+		Data:    results,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (op batchOperation) problemID() string {
+	if op.Revision != nil {
+		return op.Revision.EnsureProblemID()
+	}
+	return op.Problem_id
+}
+
+// writeBatchError answers a rolled back batch, the failed operation carries the error
+func writeBatchError(w http.ResponseWriter, results []batchResult, batchErr *batchError) {
+	failed := &results[batchErr.index]
+	failed.Status = "failed"
+	failed.Error = batchErr.err.Error()
+	failed.Revision = batchErr.current
+	w.WriteHeader(batchErr.status)
+	json.NewEncoder(w).Encode(models.Response{
+		Status:  "error",
+		Message: fmt.Sprintf("Batch rolled back, operation %d failed: %v", batchErr.index, batchErr.err),
+		Data:    results,
+	})
+}
+
+// batchWrites turns the operations into store writes, scheduling the created and reset
+// problems the way HandleAddRevisions does
+func (h *Handler) batchWrites(ctx context.Context, userId string, operations []batchOperation) ([]datastore.RevisionWrite, *batchError) {
+	failed := func(index int, status int, err error) *batchError {
+		return &batchError{index: index, status: status, err: err}
+	}
+	var problemIds []string
+	for i, op := range operations {
+		problemId := op.problemID()
+		if problemId == "" {
+			return nil, failed(i, http.StatusBadRequest, errors.New("no problem provided"))
+		}
+		if slices.Contains(problemIds, problemId) {
+			return nil, failed(i, http.StatusBadRequest, fmt.Errorf("problem %s appears more than once", problemId))
+		}
+		problemIds = append(problemIds, problemId)
+	}
+	decks, err := h.userDecks(ctx, userId)
+	if err != nil {
+		return nil, failed(0, http.StatusInternalServerError, err)
+	}
+	settings, err := h.Datastore.GetUserSettings(ctx, userId)
+	if err != nil {
+		return nil, failed(0, http.StatusInternalServerError, err)
+	}
+	load, err := h.userLoad(ctx, userId, settings, problemIds...)
+	if err != nil {
+		return nil, failed(0, http.StatusInternalServerError, err)
+	}
+	// creates and resets start a schedule over, they are spread like a bulk add
+	scheduled := 0
+	for _, op := range operations {
+		if op.Op == batchCreate || op.Op == batchReset {
+			scheduled++
+		}
+	}
+	load.SpreadNew(scheduled)
+	now := time.Now().In(settings.Location())
+	schedule := func(problem *models.RevisionProblem) error {
+		sched, err := scheduler.ForName(withDecks(settings, decks, problem.Decks).Scheduler)
+		if err != nil {
+			return err
+		}
+		scheduler.Review(sched, problem, now)
+		load.BalanceNew(problem, now)
+		return nil
+	}
+
+	writes := make([]datastore.RevisionWrite, len(operations))
+	for i, op := range operations {
+		write, status, err := h.batchWrite(ctx, userId, op, decks, schedule)
+		if err != nil {
+			return nil, failed(i, status, err)
+		}
+		writes[i] = write
+	}
+	return writes, nil
+}
+
+func (h *Handler) batchWrite(ctx context.Context, userId string, op batchOperation, decks map[string]models.Deck, schedule func(*models.RevisionProblem) error) (datastore.RevisionWrite, int, error) {
+	switch op.Op {
+	case batchCreate:
+		if op.Revision == nil {
+			return datastore.RevisionWrite{}, http.StatusBadRequest, errors.New("create needs a revision")
+		}
+		problem := *op.Revision
+		if err := problem.Preprocess(); err != nil {
+			return datastore.RevisionWrite{}, http.StatusBadRequest, err
+		}
+		if err := checkDecks(decks, &problem, nil); err != nil {
+			return datastore.RevisionWrite{}, http.StatusBadRequest, err
+		}
+		if err := schedule(&problem); err != nil {
+			return datastore.RevisionWrite{}, http.StatusInternalServerError, err
+		}
+		return datastore.RevisionWrite{Kind: datastore.WriteCreate, Problem: problem}, 0, nil
+	case batchDelete:
+		problem := models.RevisionProblem{Problem_id: op.Problem_id, Version: op.Version}
+		return datastore.RevisionWrite{Kind: datastore.WriteDelete, Problem: problem}, 0, nil
+	case batchUpdate, batchRetag, batchReset:
+	default:
+		return datastore.RevisionWrite{}, http.StatusBadRequest, fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	current, err := h.Datastore.GetRevisionProblem(ctx, userId, op.problemID())
+	if errors.Is(err, datastore.ErrNotFound) {
+		return datastore.RevisionWrite{}, http.StatusNotFound, err
+	}
+	if err != nil {
+		return datastore.RevisionWrite{}, http.StatusInternalServerError, err
+	}
+	problem := current
+	switch op.Op {
+	case batchUpdate:
+		if op.Revision == nil {
+			return datastore.RevisionWrite{}, http.StatusBadRequest, errors.New("update needs a revision")
+		}
+		problem = *op.Revision
+		if err := checkDecks(decks, &problem, current.Decks); err != nil {
+			return datastore.RevisionWrite{}, http.StatusBadRequest, err
+		}
+		if err := keepReviewState(&problem, current); err != nil {
+			return datastore.RevisionWrite{}, http.StatusBadRequest, err
+		}
+	case batchRetag:
+		if op.Tags != nil {
+			problem.Tags = op.Tags
+		}
+		for _, tag := range op.Add_tags {
+			if !slices.Contains(problem.Tags, tag) {
+				problem.Tags = append(problem.Tags, tag)
+			}
+		}
+		problem.Tags = slices.DeleteFunc(problem.Tags, func(tag string) bool { return slices.Contains(op.Remove_tags, tag) })
+		problem.Version = op.Version
+	case batchReset:
+		if op.Confidence_level < 1 || op.Confidence_level > 5 {
+			return datastore.RevisionWrite{}, http.StatusBadRequest, errors.New("reset needs a confidence level between 1 and 5")
+		}
+		problem.Confidence_level = op.Confidence_level
+		problem.Schedule = models.ScheduleState{}
+		problem.Failure_count = 0
+		problem.Is_leech = false
+		problem.Relearning = false
+		problem.Relearning_step = 0
+		problem.Version = op.Version
+		if err := schedule(&problem); err != nil {
+			return datastore.RevisionWrite{}, http.StatusInternalServerError, err
+		}
+	}
+	// without a client version the write is still guarded against changes since the read above
+	if problem.Version == 0 {
+		problem.Version = current.Version
+	}
+	return datastore.RevisionWrite{Kind: datastore.WriteUpdate, Problem: problem}, 0, nil
+}
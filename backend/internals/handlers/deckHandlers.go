@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"dsa-helper-backend/internals/scheduler"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// errInvalidDeck is returned when a revision references a deck that does not exist or is
+// archived, it is answered with 400
+var errInvalidDeck = errors.New("invalid deck")
+
+// HandleGetDecks is GET /decks, archived decks are only listed with ?archived=true
+func (h *Handler) HandleGetDecks(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	decks, err := h.Datastore.GetDecks(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get decks: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if r.URL.Query().Get("archived") != "true" {
+		decks = slices.DeleteFunc(decks, func(d models.Deck) bool { return d.Archived })
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Decks fetched successfully",
+		Data:    decks,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleCreateDeck is POST /decks
+func (h *Handler) HandleCreateDeck(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var deck models.Deck
+	if err := json.NewDecoder(r.Body).Decode(&deck); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if status, err := h.validateDeck(r.Context(), userId, &deck); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid deck: %v", err), status)
+		return
+	}
+	deck, err := h.Datastore.CreateDeck(r.Context(), userId, deck)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create deck: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Deck created successfully",
+		Data:    deck,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleUpdateDeck is PUT /decks/{deckId}, it renames a deck, archives or unarchives it and
+// changes its scheduling settings. Archived decks keep their problems but take no new ones.
+func (h *Handler) HandleUpdateDeck(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var deck models.Deck
+	if err := json.NewDecoder(r.Body).Decode(&deck); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	deck.ID = chi.URLParam(r, "deckId")
+	if status, err := h.validateDeck(r.Context(), userId, &deck); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid deck: %v", err), status)
+		return
+	}
+	err := h.Datastore.UpdateDeck(r.Context(), userId, deck)
+	if errors.Is(err, datastore.ErrNotFound) {
+		http.Error(w, fmt.Sprintf("Deck %s not found", deck.ID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update deck: %v", err), http.StatusInternalServerError)
+		return
+	}
+	deck, err = h.Datastore.GetDeck(r.Context(), userId, deck.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get deck: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Deck updated successfully",
+		Data:    deck,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// validateDeck checks the fields of a deck about to be saved and returns the status to answer with
+func (h *Handler) validateDeck(ctx context.Context, userId string, deck *models.Deck) (int, error) {
+	deck.Name = strings.TrimSpace(deck.Name)
+	if deck.Name == "" {
+		return http.StatusBadRequest, errors.New("name is required")
+	}
+	if deck.Scheduler != "" {
+		if _, err := scheduler.ForName(deck.Scheduler); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	if deck.DailyReviewLimit < 0 {
+		return http.StatusBadRequest, errors.New("daily review limit cannot be negative")
+	}
+	if deck.LeechThreshold < 0 {
+		return http.StatusBadRequest, errors.New("leech threshold cannot be negative")
+	}
+	decks, err := h.Datastore.GetDecks(ctx, userId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	for _, d := range decks {
+		if d.ID != deck.ID && strings.EqualFold(d.Name, deck.Name) {
+			return http.StatusConflict, fmt.Errorf("a deck named %q already exists", d.Name)
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// userDecks returns the decks of the user by ID
+func (h *Handler) userDecks(ctx context.Context, userId string) (map[string]models.Deck, error) {
+	decks, err := h.Datastore.GetDecks(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Deck, len(decks))
+	for _, d := range decks {
+		byID[d.ID] = d
+	}
+	return byID, nil
+}
+
+// checkDecks removes duplicates from the deck IDs of problem and makes sure they exist.
+// Archived decks are rejected unless the problem was already in them.
+func checkDecks(decks map[string]models.Deck, problem *models.RevisionProblem, current []string) error {
+	var deckIDs []string
+	for _, id := range problem.Decks {
+		if slices.Contains(deckIDs, id) {
+			continue
+		}
+		deck, ok := decks[id]
+		if !ok {
+			return fmt.Errorf("%w: deck %s does not exist", errInvalidDeck, id)
+		}
+		if deck.Archived && !slices.Contains(current, id) {
+			return fmt.Errorf("%w: deck %q is archived", errInvalidDeck, deck.Name)
+		}
+		deckIDs = append(deckIDs, id)
+	}
+	problem.Decks = deckIDs
+	return nil
+}
+
+// withDecks applies the scheduling overrides of the first of deckIDs to settings, a problem
+// in several decks is scheduled by the deck it was put in first
+func withDecks(settings models.UserSettings, decks map[string]models.Deck, deckIDs []string) models.UserSettings {
+	for _, id := range deckIDs {
+		if deck, ok := decks[id]; ok {
+			return settings.WithDeck(deck)
+		}
+	}
+	return settings
+}
+
+// deckFilter resolves the ?deck= parameter, it returns a nil deck when there is none
+func (h *Handler) deckFilter(ctx context.Context, userId string, r *http.Request) (*models.Deck, error) {
+	deckID := r.URL.Query().Get("deck")
+	if deckID == "" {
+		return nil, nil
+	}
+	deck, err := h.Datastore.GetDeck(ctx, userId, deckID)
+	if err != nil {
+		return nil, err
+	}
+	return &deck, nil
+}
+
+// inDeck keeps the problems of deck, all of them when deck is nil
+func inDeck(problems []models.RevisionProblem, deck *models.Deck) []models.RevisionProblem {
+	if deck == nil {
+		return problems
+	}
+	return slices.DeleteFunc(problems, func(p models.RevisionProblem) bool {
+		return !slices.Contains(p.Decks, deck.ID)
+	})
+}
+
+// writeDeckFilterError answers a failed deckFilter
+func writeDeckFilterError(w http.ResponseWriter, err error) {
+	if errors.Is(err, datastore.ErrNotFound) {
+		http.Error(w, "Deck not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, fmt.Sprintf("Failed to get deck: %v", err), http.StatusInternalServerError)
+}
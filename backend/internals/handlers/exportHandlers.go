@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"bytes"
+	"dsa-helper-backend/internals/archive"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// maxArchiveBytes bounds the size of an uploaded export archive
+const maxArchiveBytes = 64 << 20
+
+// HandleExport is GET /export, it streams a zip archive of the revisions of the user with
+// their reviews, notes history, cached analyses and a Markdown file per problem
+func (h *Handler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	export, err := archive.NewExport(r.Context(), h.Datastore, userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to export revisions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="revisions-%s.zip"`, time.Now().UTC().Format("2006-01-02")))
+	// the status is sent with the first bytes, a failure past this point can only cut the archive short
+	if err := export.Write(r.Context(), w); err != nil {
+		log.Printf("Failed to write export of user %s: %v", userId, err)
+	}
+}
+
+// HandleImport is POST /import, it restores an archive written by GET /export. Revisions in
+// the archive replace the stored problems with the same ID, the other problems are kept.
+func (h *Handler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxArchiveBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	contents, err := archive.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid archive: %v", err), http.StatusBadRequest)
+		return
+	}
+	report, err := archive.Restore(r.Context(), h.Datastore, userId, contents)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to restore archive: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Archive restored successfully",
+		Data:    report,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
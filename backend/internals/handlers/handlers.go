@@ -1,16 +1,22 @@
 package handlers
 
 import (
+	"context"
 	"dsa-helper-backend/internals/ai"
 	"dsa-helper-backend/internals/auth"
 	"dsa-helper-backend/internals/config"
 	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/leetcode"
+	"dsa-helper-backend/internals/middlewares"
 	"dsa-helper-backend/internals/models"
-	"dsa-helper-backend/internals/utils"
+	"dsa-helper-backend/internals/submissions"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"firebase.google.com/go/v4/auth"
 )
@@ -58,57 +64,161 @@ func GoogleSignInHandler(firebaseAuth *auth.Client) http.HandlerFunc {
 	}
 }
 
-func SubmissionFetchHandler(config config.GeminiConfig) http.HandlerFunc {
+// submissionsPage is the data of GET /get-submissions, Sync is left out when no sync ran
+type submissionsPage struct {
+	datastore.SubmissionPage
+	Sync *submissions.Report `json:"sync,omitempty"`
+}
+
+// SubmissionFetchHandler is GET /get-submissions, a page of the stored LeetCode submissions of
+// the user, newest first. When the request carries an X-LeetCode-Cookie header the submissions
+// made since the last sync are fetched and analysed first, from the site leetCodeClient picks,
+// sync=false skips that. Only submissions of that site are listed. The page is selected by status, language, slug, q to search the
+// title, since and until (dates in the user's time zone or RFC 3339 times), limit and cursor,
+// the next_cursor of the previous page.
+func (h *Handler) SubmissionFetchHandler(config config.GeminiConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		limit := r.URL.Query().Get("limit")
-		limitNum, err := strconv.ParseInt(limit, 10, 10)
-		if err != nil {
-			limitNum = 20
+		userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+		if !ok || userId == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
 		}
-		limitNumInt := int(limitNum)
-		cookie := r.Header.Get("X-LeetCode-Cookie")
-		if cookie == "" {
-			http.Error(w, "No cookie provided", http.StatusBadRequest)
+		query, err := h.submissionQuery(r.Context(), userId, r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid query: %v", err), http.StatusBadRequest)
 			return
 		}
-		submissions, err := utils.LeetCodeSubmissionsFetch(cookie, limitNumInt)
+		client, region, err := h.leetCodeClient(r)
 		if err != nil {
-			http.Error(w, "Error fetching submissions: "+err.Error(), http.StatusInternalServerError)
+			writeLeetCodeError(w, err)
 			return
 		}
-		submissions, err = ai.HighLevelAnalysis(submissions, config)
+		query.Region = string(region)
+		var page submissionsPage
+		cookie := r.Header.Get("X-LeetCode-Cookie")
+		if cookie != "" && r.URL.Query().Get("sync") != "false" {
+			report, err := submissions.Sync(r.Context(), h.Datastore, client, userId, cookie, submissions.Options{
+				Analyze: func(ctx context.Context, fetched []models.LeetCodeSubmission) ([]models.LeetCodeSubmission, error) {
+					return h.HighLevelAnalysis(fetched, h.submissionProblems(ctx, client, cookie, fetched), config)
+				},
+				Region: region,
+			})
+			if err != nil {
+				writeLeetCodeError(w, err)
+				return
+			}
+			page.Sync = &report
+		}
+		page.SubmissionPage, err = h.Datastore.QuerySubmissions(r.Context(), userId, query)
 		if err != nil {
-			http.Error(w, "Error analysing submissions: "+err.Error(), http.StatusInternalServerError)
+			http.Error(w, fmt.Sprintf("Failed to get submissions: %v", err), http.StatusInternalServerError)
 			return
 		}
 		json.NewEncoder(w).Encode(models.Response{
 			Status:  "success",
-			Message: fmt.Sprintf("Fetched %d submissions successfully", len(submissions)),
-			Data:    submissions,
+			Message: fmt.Sprintf("Fetched %d submissions successfully", len(page.Submissions)),
+			Data:    page,
 		})
 	}
 }
 
-func (fs *Firestore) SubmissionFeedbackHandler(config config.GeminiConfig) http.HandlerFunc {
+// submissionQuery reads the query parameters of GET /get-submissions
+func (h *Handler) submissionQuery(ctx context.Context, userId string, r *http.Request) (datastore.SubmissionQuery, error) {
+	params := r.URL.Query()
+	query := datastore.SubmissionQuery{
+		Status:   params.Get("status"),
+		Language: params.Get("language"),
+		Slug:     params.Get("slug"),
+		Search:   strings.TrimSpace(params.Get("q")),
+		Cursor:   params.Get("cursor"),
+	}
+	var err error
+	if query.Limit, err = intParam(params.Get("limit"), 20, 1, 200); err != nil {
+		return query, fmt.Errorf("limit: %w", err)
+	}
+	if params.Get("since") != "" || params.Get("until") != "" {
+		settings, err := h.Datastore.GetUserSettings(ctx, userId)
+		if err != nil {
+			return query, err
+		}
+		if query.Since, err = timeParam(params.Get("since"), settings.Location()); err != nil {
+			return query, fmt.Errorf("since: %w", err)
+		}
+		if query.Until, err = timeParam(params.Get("until"), settings.Location()); err != nil {
+			return query, fmt.Errorf("until: %w", err)
+		}
+	}
+	return query, query.Validate()
+}
+
+// writeLeetCodeError answers a failed LeetCode call with a status the frontend can act on,
+// nothing is written when the client went away
+func writeLeetCodeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var rateLimited *leetcode.RateLimitError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return
+	case errors.Is(err, leetcode.ErrUnknownRegion):
+		status = http.StatusBadRequest
+	case errors.Is(err, leetcode.ErrCookieExpired):
+		status = http.StatusForbidden
+	case errors.As(err, &rateLimited):
+		status = http.StatusTooManyRequests
+		if rateLimited.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimited.RetryAfter.Seconds()))))
+		}
+	case errors.Is(err, leetcode.ErrUpstreamDown), errors.Is(err, leetcode.ErrBadResponse):
+		status = http.StatusBadGateway
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	}
+	http.Error(w, "Error fetching submissions: "+err.Error(), status)
+}
+
+// leetCodeClient returns the client of the LeetCode site the request is for, the site of the
+// X-LeetCode-Region header when set and the leetcode_region setting of the user otherwise.
+// A session cookie only works on its own site.
+func (h *Handler) leetCodeClient(r *http.Request) (leetcode.LeetCodeClient, leetcode.Region, error) {
+	value := r.Header.Get("X-LeetCode-Region")
+	if userId, ok := r.Context().Value(middlewares.UserIDContext).(string); value == "" && ok && userId != "" {
+		settings, err := h.Datastore.GetUserSettings(r.Context(), userId)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get settings: %w", err)
+		}
+		value = settings.LeetCodeRegion
+	}
+	region, err := leetcode.ParseRegion(value)
+	if err != nil {
+		return nil, "", err
+	}
+	if region == leetcode.RegionCN {
+		return h.LeetCodeCN, region, nil
+	}
+	return h.LeetCode, region, nil
+}
+
+func (h *Handler) SubmissionFeedbackHandler(config config.GeminiConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		toCheck := ai.ToCheck{}
-		collectionName := "submissionFeedback"
+		collectionName := datastore.SubmissionFeedbackCollection
 		err := json.NewDecoder(r.Body).Decode(&toCheck)
 		if err != nil {
 			http.Error(w, "Error parsing input: "+err.Error(), http.StatusBadRequest)
 
 		}
 		var dataMap models.SubmissionFeedbackResponse
-		retrievedProblem, _ := datastore.GetAnalysisProblems[models.SubmissionFeedbackResponse](r.Context(), fmt.Sprintf("%d", toCheck.ProblemId), fs.Datastore, collectionName)
+		retrievedProblem, _ := datastore.GetAnalysisProblems[models.SubmissionFeedbackResponse](r.Context(), fmt.Sprintf("%d", toCheck.ProblemId), h.Datastore, collectionName)
 		if retrievedProblem.CodeStyleAndReadability != "" {
 			dataMap = retrievedProblem
 		} else {
+			h.fillProblemStatement(r, &toCheck)
 			dataMap, err = ai.SubmissionFeedback(&config, &toCheck)
 			if err != nil {
 				http.Error(w, "Error analyzing code: "+err.Error(), http.StatusInternalServerError)
 				return
 			}
-			err = datastore.AddAnalysisProblems(r.Context(), fs.Datastore, dataMap, fmt.Sprintf("%d", toCheck.ProblemId), collectionName)
+			err = datastore.AddAnalysisProblems(r.Context(), h.Datastore, dataMap, fmt.Sprintf("%d", toCheck.ProblemId), collectionName)
 			if err != nil {
 				http.Error(w, "Error saving analyzed code: "+err.Error(), http.StatusInternalServerError)
 				return
@@ -123,26 +233,27 @@ func (fs *Firestore) SubmissionFeedbackHandler(config config.GeminiConfig) http.
 	}
 }
 
-func (fs *Firestore) AnalyseSubmissionHandler(config config.GeminiConfig) http.HandlerFunc {
+func (h *Handler) AnalyseSubmissionHandler(config config.GeminiConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		toCheck := ai.ToCheck{}
-		collectionName := "analyseSubmission"
+		collectionName := datastore.AnalyseSubmissionCollection
 		err := json.NewDecoder(r.Body).Decode(&toCheck)
 		if err != nil {
 			http.Error(w, "Error parsing input: "+err.Error(), http.StatusBadRequest)
 			return
 		}
 		var analysis models.AnalyseSubmissionResponse
-		retrievedProblem, _ := datastore.GetAnalysisProblems[models.AnalyseSubmissionResponse](r.Context(), fmt.Sprintf("%d", toCheck.ProblemId), fs.Datastore, collectionName)
+		retrievedProblem, _ := datastore.GetAnalysisProblems[models.AnalyseSubmissionResponse](r.Context(), fmt.Sprintf("%d", toCheck.ProblemId), h.Datastore, collectionName)
 		if retrievedProblem.OptimalCode != "" {
 			analysis = retrievedProblem
 		} else {
+			h.fillProblemStatement(r, &toCheck)
 			analysis, err = ai.AnalyseSubmission(&toCheck, config)
 			if err != nil {
 				http.Error(w, "Error analyzing submission: "+err.Error(), http.StatusInternalServerError)
 				return
 			}
-			err = datastore.AddAnalysisProblems(r.Context(), fs.Datastore, analysis, fmt.Sprintf("%d", toCheck.ProblemId), collectionName)
+			err = datastore.AddAnalysisProblems(r.Context(), h.Datastore, analysis, fmt.Sprintf("%d", toCheck.ProblemId), collectionName)
 			if err != nil {
 				http.Error(w, "Error saving analyzed code: "+err.Error(), http.StatusInternalServerError)
 				return
@@ -181,7 +292,7 @@ func PatternInfoHandler(config config.GeminiConfig) http.HandlerFunc {
 		})
 	}
 }
-func OverallAnalysisHandler(config config.GeminiConfig) http.HandlerFunc {
+func (h *Handler) OverallAnalysisHandler(config config.GeminiConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		limit := 1
@@ -190,12 +301,17 @@ func OverallAnalysisHandler(config config.GeminiConfig) http.HandlerFunc {
 			http.Error(w, "No cookie provided", http.StatusBadRequest)
 			return
 		}
-		submissions, err := utils.LeetCodeSubmissionsFetch(cookie, limit)
+		client, _, err := h.leetCodeClient(r)
+		if err != nil {
+			writeLeetCodeError(w, err)
+			return
+		}
+		submissions, err := client.FetchSubmissions(r.Context(), cookie, limit)
 		if err != nil {
-			http.Error(w, "Error fetching submissions: "+err.Error(), http.StatusInternalServerError)
+			writeLeetCodeError(w, err)
 			return
 		}
-		analysis, err := ai.OverallAnalysis(submissions, config)
+		analysis, err := ai.OverallAnalysis(submissions, h.submissionProblems(r.Context(), client, cookie, submissions), config)
 		if err != nil {
 			http.Error(w, "Error analyzing code: "+err.Error(), http.StatusInternalServerError)
 			return
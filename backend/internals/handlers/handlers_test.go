@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"dsa-helper-backend/internals/config"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/leetcode"
+	"dsa-helper-backend/internals/leetcode/leetcodetest"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newTestHandler returns a handler on a memory store whose LeetCode clients talk to fake
+// sites and whose analysis marks every submission O(n), the sites are stopped with the test
+func newTestHandler(t *testing.T) (*Handler, *leetcodetest.Server) {
+	t.Helper()
+	site := leetcodetest.NewServer()
+	t.Cleanup(site.Close)
+	cnSite := leetcodetest.NewCNServer()
+	t.Cleanup(cnSite.Close)
+	h := NewHandler(datastore.NewMemoryStore())
+	h.LeetCode = fastClient(site)
+	h.LeetCodeCN = fastClient(cnSite)
+	h.HighLevelAnalysis = func(submissions []models.LeetCodeSubmission, _ map[string]models.ProblemMetadata, _ config.GeminiConfig) ([]models.LeetCodeSubmission, error) {
+		analysed := slices.Clone(submissions)
+		for i := range analysed {
+			analysed[i].BestTimeComplexity = "O(n)"
+		}
+		return analysed, nil
+	}
+	return h, site
+}
+
+// fastClient is a client of site that neither paces nor backs off, so tests do not sleep
+func fastClient(site *leetcodetest.Server) *leetcode.Client {
+	client := site.LeetCodeClient()
+	client.Limiter = leetcode.NewLimiter(1000, 1000)
+	client.Retry.BaseDelay = 0
+	return client
+}
+
+// testRequest describes a request made by serve
+type testRequest struct {
+	method  string
+	target  string
+	body    string
+	userId  string
+	params  map[string]string
+	headers map[string]string
+}
+
+// serve runs handler on req as the route would after the auth middleware
+func serve(handler http.HandlerFunc, req testRequest) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(req.method, req.target, strings.NewReader(req.body))
+	rc := chi.NewRouteContext()
+	for k, v := range req.params {
+		rc.URLParams.Add(k, v)
+	}
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rc)
+	if req.userId != "" {
+		ctx = context.WithValue(ctx, middlewares.UserIDContext, req.userId)
+		ctx = leetcode.WithUser(ctx, req.userId)
+	}
+	for k, v := range req.headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r.WithContext(ctx))
+	return w
+}
+
+// decodeData decodes the data of a models.Response body into data
+func decodeData(t *testing.T, w *httptest.ResponseRecorder, data any) {
+	t.Helper()
+	resp := struct {
+		Data any `json:"data"`
+	}{Data: data}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+}
+
+func TestSubmissionFetchHandler(t *testing.T) {
+	h, site := newTestHandler(t)
+	cookie := site.AddUser("li", leetcodetest.Submissions(25))
+	handler := h.SubmissionFetchHandler(config.GeminiConfig{})
+	fetch := func(target string, headers map[string]string) (submissionsPage, *httptest.ResponseRecorder) {
+		t.Helper()
+		w := serve(handler, testRequest{method: "GET", target: target, userId: "u", headers: headers})
+		var page submissionsPage
+		if w.Code == http.StatusOK {
+			decodeData(t, w, &page)
+		}
+		return page, w
+	}
+
+	page, w := fetch("/get-submissions?limit=10", map[string]string{"X-LeetCode-Cookie": cookie})
+	if w.Code != http.StatusOK {
+		t.Fatalf("first sync: %d %s", w.Code, w.Body)
+	}
+	if page.Sync == nil || page.Sync.New != 25 || page.Sync.LastSubmissionID != 25 {
+		t.Fatalf("first sync report %+v", page.Sync)
+	}
+	if len(page.Submissions) != 10 || page.Submissions[0].ID != 25 || page.Submissions[0].BestTimeComplexity != "O(n)" || page.NextCursor == "" {
+		t.Fatalf("first page %+v", page)
+	}
+	next, _ := fetch("/get-submissions?limit=10&cursor="+page.NextCursor, nil)
+	if next.Sync != nil || len(next.Submissions) != 10 || next.Submissions[0].ID != 15 {
+		t.Fatalf("second page without a cookie %+v", next)
+	}
+
+	// only what was submitted since the last sync is fetched
+	site.AddUser("li", leetcodetest.Submissions(27))
+	before := site.Requests()
+	page, _ = fetch("/get-submissions?limit=50&status=Accepted", map[string]string{"X-LeetCode-Cookie": cookie})
+	if page.Sync == nil || page.Sync.New != 2 || site.Requests()-before > 2 {
+		t.Fatalf("incremental sync report %+v after %d requests", page.Sync, site.Requests()-before)
+	}
+	for _, s := range page.Submissions {
+		if s.StatusDisplay != "Accepted" {
+			t.Fatalf("status filter returned %+v", s)
+		}
+	}
+	before = site.Requests()
+	if page, _ = fetch("/get-submissions?sync=false", map[string]string{"X-LeetCode-Cookie": cookie}); page.Sync != nil || site.Requests() != before {
+		t.Fatal("sync=false still synced")
+	}
+	// the submissions synced from leetcode.com are not listed for leetcode.cn
+	if page, w = fetch("/get-submissions", map[string]string{"X-LeetCode-Region": "cn"}); w.Code != http.StatusOK || len(page.Submissions) != 0 {
+		t.Fatalf("leetcode.cn listing: %d with %d submissions, want none", w.Code, len(page.Submissions))
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		setup   func()
+		code    int
+	}{
+		{"expired cookie", "/get-submissions", map[string]string{"X-LeetCode-Cookie": "LEETCODE_SESSION=gone"}, nil, http.StatusForbidden},
+		{"upstream down", "/get-submissions", map[string]string{"X-LeetCode-Cookie": cookie}, func() { site.FailNext(4, http.StatusServiceUnavailable, "") }, http.StatusBadGateway},
+		{"malformed", "/get-submissions", map[string]string{"X-LeetCode-Cookie": cookie}, func() { site.SetMalformed(true) }, http.StatusBadGateway},
+		{"unknown region", "/get-submissions", map[string]string{"X-LeetCode-Cookie": cookie, "X-LeetCode-Region": "leetcode.jp"}, nil, http.StatusBadRequest},
+		{"invalid limit", "/get-submissions?limit=0", nil, nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			t.Cleanup(func() { site.SetMalformed(false) })
+			if _, w := fetch(tt.target, tt.headers); w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+		})
+	}
+}
+
+func TestSubmissionFetchHandlerPassesOnRetryAfter(t *testing.T) {
+	h, site := newTestHandler(t)
+	cookie := site.AddUser("li", leetcodetest.Submissions(3))
+	site.FailNext(1, http.StatusTooManyRequests, "120")
+	w := serve(h.SubmissionFetchHandler(config.GeminiConfig{}), testRequest{method: "GET", target: "/get-submissions", userId: "u",
+		headers: map[string]string{"X-LeetCode-Cookie": cookie}})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "120" {
+		t.Fatalf("got %d with Retry-After %q, want 429 with 120", w.Code, w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"dsa-helper-backend/internals/importer"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// maxImportBytes bounds the size of an uploaded file
+	maxImportBytes = 5 << 20
+	// maxImportRows bounds the number of problems of one import
+	maxImportRows = 2000
+	// defaultListConfidence is the confidence problems of a built-in list start with
+	defaultListConfidence = 1
+)
+
+// importIssue is a row of an import that was not added
+type importIssue struct {
+	Row    int    `json:"row"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+// importReport is the body of POST /revisions/import, Skipped rows are already in the
+// revisions or repeat an earlier row, Invalid rows failed validation
+type importReport struct {
+	Imported int                      `json:"imported"`
+	Dry_run  bool                     `json:"dry_run"`
+	Problems []models.RevisionProblem `json:"problems"`
+	Skipped  []importIssue            `json:"skipped"`
+	Invalid  []importIssue            `json:"invalid"`
+}
+
+// HandleImportRevisions is POST /revisions/import, it adds the problems of a CSV or JSON file
+// or of a built-in list to the revisions. Query parameters:
+//   - list imports a built-in list by name instead of the request body
+//   - format is csv or json, taken from the Content-Type when missing
+//   - map overrides the column mapping, as Column=field pairs separated by commas
+//   - confidence is the confidence of rows without one, 1 for built-in lists
+//   - deck puts every imported problem in a deck
+//   - dry_run=true reports what would be imported without adding anything
+func (h *Handler) HandleImportRevisions(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query()
+	confidence := 0
+	var rows []importer.Row
+	var err error
+	if list := query.Get("list"); list != "" {
+		confidence = defaultListConfidence
+		rows, err = importer.ReadList(list)
+		if errors.Is(err, importer.ErrUnknownList) {
+			http.Error(w, fmt.Sprintf("Problem list %s not found", list), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read problem list: %v", err), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		mapping, err := importer.ParseMapping(query.Get("map"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid map: %v", err), http.StatusBadRequest)
+			return
+		}
+		body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+		switch importFormat(r) {
+		case "csv":
+			rows, err = importer.ReadCSV(body, mapping)
+		case "json":
+			rows, err = importer.ReadJSON(body, mapping)
+		default:
+			http.Error(w, "Unsupported import format, send text/csv or application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read import file: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if len(rows) == 0 {
+		http.Error(w, "No problems provided", http.StatusBadRequest)
+		return
+	}
+	if len(rows) > maxImportRows {
+		http.Error(w, fmt.Sprintf("An import holds at most %d problems", maxImportRows), http.StatusBadRequest)
+		return
+	}
+	if param := query.Get("confidence"); param != "" {
+		confidence, err = strconv.Atoi(param)
+		if err != nil || confidence < 1 || confidence > 5 {
+			http.Error(w, "Invalid confidence, it must be between 1 and 5", http.StatusBadRequest)
+			return
+		}
+	}
+	decks, err := h.userDecks(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get decks: %v", err), http.StatusInternalServerError)
+		return
+	}
+	var deckIDs []string
+	if deckID := query.Get("deck"); deckID != "" {
+		deckIDs = []string{deckID}
+		if err := checkDecks(decks, &models.RevisionProblem{Decks: deckIDs}, nil); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid deck: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	existing, err := h.Datastore.GetRevisionProblems(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get revision problems: %v", err), http.StatusInternalServerError)
+		return
+	}
+	report := importReport{Dry_run: query.Get("dry_run") == "true", Problems: []models.RevisionProblem{}, Skipped: []importIssue{}, Invalid: []importIssue{}}
+	// seen maps the problem IDs already in the revisions or in an earlier row to the reason a
+	// repeat is skipped
+	seen := make(map[string]string, len(existing)+len(rows))
+	for _, p := range existing {
+		seen[p.Problem_id] = "already in revisions"
+	}
+	for _, row := range rows {
+		problem := row.Problem
+		if row.Err != nil {
+			report.Invalid = append(report.Invalid, importIssue{Row: row.Number, Title: problem.Title, Reason: row.Err.Error()})
+			continue
+		}
+		if problem.Confidence_level == 0 {
+			problem.Confidence_level = confidence
+		}
+		if err := problem.Preprocess(); err != nil {
+			report.Invalid = append(report.Invalid, importIssue{Row: row.Number, Title: problem.Title, Reason: err.Error()})
+			continue
+		}
+		if reason, ok := seen[problem.Problem_id]; ok {
+			report.Skipped = append(report.Skipped, importIssue{Row: row.Number, Title: problem.Title, Reason: reason})
+			continue
+		}
+		seen[problem.Problem_id] = fmt.Sprintf("same problem as row %d", row.Number)
+		problem.Decks = deckIDs
+		report.Problems = append(report.Problems, problem)
+	}
+	report.Imported = len(report.Problems)
+	// rows that come with a difficulty and tags are trusted, looking up a whole list on
+	// LeetCode would make large imports slow
+	var incomplete []models.RevisionProblem
+	var incompleteAt []int
+	for i, p := range report.Problems {
+		if p.Difficulty == "" || len(p.Tags) == 0 {
+			incomplete = append(incomplete, p)
+			incompleteAt = append(incompleteAt, i)
+		}
+	}
+	if len(incomplete) > 0 {
+		h.fillProblemMetadata(r, incomplete)
+		for i, at := range incompleteAt {
+			report.Problems[at] = incomplete[i]
+		}
+	}
+	if report.Imported > 0 {
+		if err := h.scheduleNewProblems(r.Context(), userId, report.Problems, decks); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to schedule revision problems: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	if report.Imported > 0 && !report.Dry_run {
+		if err := h.Datastore.AddRevisionProblems(r.Context(), userId, report.Problems); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to add revision problems: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Revision problems imported successfully",
+		Data:    report,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// importFormat returns csv or json from the format parameter or the Content-Type of the request
+func importFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return "csv"
+	case "application/json":
+		return "json"
+	}
+	return ""
+}
+
+// HandleGetImportLists is GET /revisions/import/lists, the built-in lists POST
+// /revisions/import?list= accepts
+func (h *Handler) HandleGetImportLists(w http.ResponseWriter, r *http.Request) {
+	lists, err := importer.Lists()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read problem lists: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Problem lists fetched successfully",
+		Data:    lists,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestImportSkipsDuplicateSlugs(t *testing.T) {
+	h, _ := newTestHandler(t)
+	w := serve(h.HandleAddRevisions, testRequest{method: "POST", target: "/revisions", userId: "u",
+		body: `[{"title":"Valid Anagram","confidence_level":3}]`})
+	if w.Code != http.StatusOK {
+		t.Fatalf("add: %d %s", w.Code, w.Body)
+	}
+	body := "Title,Difficulty,Tags,Confidence\n" +
+		"Two Sum,Easy,Array,3\n" +
+		"two sum,Easy,Array,4\n" +
+		"Valid Anagram,Easy,String,2\n" +
+		"!!!,Easy,Array,2\n" +
+		"Group Anagrams,Medium,String,6\n"
+	w = serve(h.HandleImportRevisions, testRequest{method: "POST", target: "/revisions/import", userId: "u", body: body,
+		headers: map[string]string{"Content-Type": "text/csv"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", w.Code, w.Body)
+	}
+	var report importReport
+	decodeData(t, w, &report)
+	if report.Imported != 1 || report.Problems[0].Problem_id != "two-sum" || report.Problems[0].Confidence_level != 3 {
+		t.Fatalf("imported %+v", report.Problems)
+	}
+	skipped := map[int]string{}
+	for _, issue := range report.Skipped {
+		skipped[issue.Row] = issue.Reason
+	}
+	if skipped[2] != "same problem as row 1" || skipped[3] != "already in revisions" || len(skipped) != 2 {
+		t.Fatalf("skipped %+v", report.Skipped)
+	}
+	invalid := map[int]bool{}
+	for _, issue := range report.Invalid {
+		invalid[issue.Row] = true
+	}
+	if !invalid[4] || !invalid[5] || len(invalid) != 2 {
+		t.Fatalf("invalid %+v", report.Invalid)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"dsa-helper-backend/internals/ai"
+	"dsa-helper-backend/internals/config"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+type leechResponse struct {
+	Revision    models.RevisionProblem           `json:"revision"`
+	Explanation *models.LeechExplanationResponse `json:"explanation,omitempty"`
+}
+
+// HandleGetLeeches is GET /revisions/leeches, the problems that keep failing their reviews,
+// most failed first. With ?explain=true every leech comes with an AI explanation of the
+// concept that is probably being missed.
+func (h *Handler) HandleGetLeeches(config config.GeminiConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+		if !ok || userId == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		revisionProblems, err := h.Datastore.GetRevisionProblems(r.Context(), userId)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get revision problems: %v", err), http.StatusInternalServerError)
+			return
+		}
+		var leeches []models.RevisionProblem
+		for _, p := range revisionProblems {
+			if p.Is_leech {
+				leeches = append(leeches, p)
+			}
+		}
+		sort.SliceStable(leeches, func(i, j int) bool {
+			return leeches[i].Failure_count > leeches[j].Failure_count
+		})
+		response := make([]leechResponse, len(leeches))
+		for i, leech := range leeches {
+			response[i].Revision = leech
+		}
+		if r.URL.Query().Get("explain") == "true" && len(leeches) > 0 {
+			explanations, err := ai.ExplainLeeches(leeches, config)
+			if err != nil {
+				http.Error(w, "Error explaining leeches: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			// the model echoes the problem id, matching on it survives a reordered answer
+			for i := range explanations {
+				for j := range response {
+					if response[j].Revision.Problem_id == explanations[i].ProblemId {
+						response[j].Explanation = &explanations[i]
+					}
+				}
+			}
+		}
+		err = json.NewEncoder(w).Encode(models.Response{
+			Status:  "success",
+			Message: fmt.Sprintf("Fetched %d leeches successfully", len(leeches)),
+			Data:    response,
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+}
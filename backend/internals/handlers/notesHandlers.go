@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/diff"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// notesDiffResponse is the body of GET /revisions/{problemId}/notes/diff
+type notesDiffResponse struct {
+	From    int64       `json:"from"`
+	To      int64       `json:"to"`
+	Added   int         `json:"added"`
+	Removed int         `json:"removed"`
+	Lines   []diff.Line `json:"lines"`
+}
+
+// HandleGetNoteVersions is GET /revisions/{problemId}/notes, the notes history of a problem
+// oldest first, each entry is keyed by the problem version that saved it
+func (h *Handler) HandleGetNoteVersions(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	problemId := chi.URLParam(r, "problemId")
+	versions, err := h.Datastore.GetNoteVersions(r.Context(), userId, problemId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get note versions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(versions) == 0 {
+		http.Error(w, fmt.Sprintf("Revision problem %s has no notes history", problemId), http.StatusNotFound)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Note versions fetched successfully",
+		Data:    versions,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleDiffNoteVersions is GET /revisions/{problemId}/notes/diff?from=&to=, a line diff between
+// two note versions. to defaults to the latest version and from to the one before to.
+func (h *Handler) HandleDiffNoteVersions(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	problemId := chi.URLParam(r, "problemId")
+	versions, err := h.Datastore.GetNoteVersions(r.Context(), userId, problemId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get note versions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(versions) == 0 {
+		http.Error(w, fmt.Sprintf("Revision problem %s has no notes history", problemId), http.StatusNotFound)
+		return
+	}
+	to := len(versions) - 1
+	if param := r.URL.Query().Get("to"); param != "" {
+		if to, err = noteVersionIndex(versions, param); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid to: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	from := max(to-1, 0)
+	if param := r.URL.Query().Get("from"); param != "" {
+		if from, err = noteVersionIndex(versions, param); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid from: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	response := notesDiffResponse{
+		From:  versions[from].Version,
+		To:    versions[to].Version,
+		Lines: diff.Lines(versions[from].Notes, versions[to].Notes),
+	}
+	for _, line := range response.Lines {
+		switch line.Kind {
+		case diff.Insert:
+			response.Added++
+		case diff.Delete:
+			response.Removed++
+		}
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Note versions compared successfully",
+		Data:    response,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// noteVersionIndex finds the position of a version number in the notes history
+func noteVersionIndex(versions []models.NoteVersion, param string) (int, error) {
+	version, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	for i, v := range versions {
+		if v.Version == version {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no notes saved at version %d", version)
+}
+
+// HandleRestoreNoteVersion is POST /revisions/{problemId}/notes/{version}/restore, it saves the
+// notes of an old version as the current notes, which adds a new entry to the history
+func (h *Handler) HandleRestoreNoteVersion(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	problemId := chi.URLParam(r, "problemId")
+	version, err := strconv.ParseInt(chi.URLParam(r, "version"), 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid version: %v", err), http.StatusBadRequest)
+		return
+	}
+	noteVersion, err := h.Datastore.GetNoteVersion(r.Context(), userId, problemId, version)
+	if errors.Is(err, datastore.ErrNotFound) {
+		http.Error(w, fmt.Sprintf("Revision problem %s has no notes saved at version %d", problemId, version), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get note version: %v", err), http.StatusInternalServerError)
+		return
+	}
+	problem, err := h.Datastore.GetRevisionProblem(r.Context(), userId, problemId)
+	if err != nil {
+		writeRevisionWriteError(w, "Failed to restore notes", err)
+		return
+	}
+	if problem.Notes != noteVersion.Notes {
+		problem.Notes = noteVersion.Notes
+		problem, err = h.Datastore.UpdateRevisionProblem(r.Context(), userId, problem)
+		if err != nil {
+			writeRevisionWriteError(w, "Failed to restore notes", err)
+			return
+		}
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Notes restored successfully",
+		Data:    problem,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"dsa-helper-backend/internals/scheduler"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// resumeRequest is the optional body of POST /revisions/resume
+type resumeRequest struct {
+	// Mode is scheduler.ResumeShift (the default) or scheduler.ResumeRamp
+	Mode string `json:"mode"`
+	// Ramp_days is the number of days the due problems are spread over in ramp mode
+	Ramp_days int `json:"ramp_days"`
+}
+
+type resumeResponse struct {
+	Mode        string `json:"mode"`
+	Paused_days int    `json:"paused_days"`
+	Rescheduled int    `json:"rescheduled"`
+}
+
+// HandlePauseRevisions is POST /revisions/pause, nothing new becomes due until the schedule is resumed
+func (h *Handler) HandlePauseRevisions(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	settings, err := h.Datastore.GetUserSettings(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if settings.Paused() {
+		http.Error(w, "Revision schedule is already paused", http.StatusConflict)
+		return
+	}
+	settings.PausedAt = time.Now()
+	err = h.Datastore.SaveUserSettings(r.Context(), settings)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Revision schedule paused successfully",
+		Data:    settings,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleResumeRevisions is POST /revisions/resume. By default every due date moves by the
+// paused duration, {"mode": "ramp", "ramp_days": N} instead spreads what is due over N days.
+// Problems are written in chunks the store can take in one transaction, a shift that fails
+// part way is continued by the next resume.
+func (h *Handler) HandleResumeRevisions(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req := resumeRequest{Mode: scheduler.ResumeShift}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	switch {
+	case req.Mode == scheduler.ResumeRamp && (req.Ramp_days < 1 || req.Ramp_days > 90):
+		http.Error(w, "Ramp days must be between 1 and 90", http.StatusBadRequest)
+		return
+	case req.Mode != scheduler.ResumeShift && req.Mode != scheduler.ResumeRamp:
+		http.Error(w, fmt.Sprintf("Unknown resume mode %q", req.Mode), http.StatusBadRequest)
+		return
+	}
+	settings, err := h.Datastore.GetUserSettings(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if !settings.Paused() {
+		http.Error(w, "Revision schedule is not paused", http.StatusConflict)
+		return
+	}
+	revisionProblems, err := h.Datastore.GetRevisionProblems(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get revision problems: %v", err), http.StatusInternalServerError)
+		return
+	}
+	now := time.Now().In(settings.Location())
+	response := resumeResponse{Mode: req.Mode}
+	if req.Mode == scheduler.ResumeRamp {
+		revisionProblems = scheduler.RampUp(revisionProblems, now, req.Ramp_days)
+	} else {
+		// a resume that failed part way already moved the problems up to the cursor
+		slices.SortFunc(revisionProblems, func(a, b models.RevisionProblem) int { return strings.Compare(a.Problem_id, b.Problem_id) })
+		revisionProblems = slices.DeleteFunc(revisionProblems, func(p models.RevisionProblem) bool {
+			return p.Problem_id <= settings.ResumeCursor
+		})
+		response.Paused_days = scheduler.Shift(revisionProblems, settings.PausedAt, now)
+		if response.Paused_days == 0 {
+			revisionProblems = nil
+		}
+	}
+	// a store transaction takes a limited number of writes, the versions read above make a
+	// chunk fail if one of its problems changed meanwhile
+	for chunk := range slices.Chunk(revisionProblems, datastore.MaxUpdateRevisionProblems) {
+		_, err = h.Datastore.UpdateRevisionProblems(r.Context(), userId, chunk)
+		if err != nil {
+			writeRevisionWriteError(w, "Failed to reschedule revision problems", err)
+			return
+		}
+		response.Rescheduled += len(chunk)
+		if req.Mode == scheduler.ResumeShift && response.Rescheduled < len(revisionProblems) {
+			settings.ResumeCursor = chunk[len(chunk)-1].Problem_id
+			if err := h.Datastore.SaveUserSettings(r.Context(), settings); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to save settings: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	settings.ResumeCursor = ""
+	settings.PausedAt = time.Time{}
+	err = h.Datastore.SaveUserSettings(r.Context(), settings)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Revision schedule resumed successfully",
+		Data:    response,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResumeShiftsInChunks(t *testing.T) {
+	h, _ := newTestHandler(t)
+	ctx := context.Background()
+	due := time.Now().UTC().AddDate(0, 0, 10).Truncate(24 * time.Hour)
+	problems := make([]models.RevisionProblem, datastore.MaxUpdateRevisionProblems+20)
+	for i := range problems {
+		problems[i] = models.RevisionProblem{
+			LeetCodeSubmission: models.LeetCodeSubmission{Title: fmt.Sprintf("Problem %04d", i)},
+			Confidence_level:   3,
+			Next_revision:      due,
+		}
+	}
+	if err := h.Datastore.AddRevisionProblems(ctx, "u", problems); err != nil {
+		t.Fatal(err)
+	}
+	// the first 10 problems were moved by a resume that failed afterwards
+	settings := models.UserSettings{UserID: "u", PausedAt: time.Now().AddDate(0, 0, -3), ResumeCursor: "problem-0009"}
+	if err := h.Datastore.SaveUserSettings(ctx, settings); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(h.HandleResumeRevisions, testRequest{method: "POST", target: "/revisions/resume", userId: "u"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("resume: %d %s", w.Code, w.Body)
+	}
+	var resp resumeResponse
+	decodeData(t, w, &resp)
+	if resp.Paused_days != 3 || resp.Rescheduled != len(problems)-10 {
+		t.Fatalf("resume moved %d problems by %d days, want %d by 3", resp.Rescheduled, resp.Paused_days, len(problems)-10)
+	}
+	for i, id := range []string{"problem-0000", "problem-0009", "problem-0010", fmt.Sprintf("problem-%04d", len(problems)-1)} {
+		p, err := h.Datastore.GetRevisionProblem(ctx, "u", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := due
+		if i >= 2 {
+			want = due.AddDate(0, 0, 3)
+		}
+		if !p.Next_revision.Equal(want) {
+			t.Errorf("%s is due %v, want %v", id, p.Next_revision, want)
+		}
+	}
+	settings, err := h.Datastore.GetUserSettings(ctx, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Paused() || settings.ResumeCursor != "" {
+		t.Fatalf("settings after resume %+v", settings)
+	}
+}
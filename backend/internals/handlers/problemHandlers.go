@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"dsa-helper-backend/internals/ai"
+	"dsa-helper-backend/internals/leetcode"
+	"dsa-helper-backend/internals/models"
+	"dsa-helper-backend/internals/problems"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HandleGetProblem is GET /problems/{slug}, the LeetCode metadata of a problem. The
+// X-LeetCode-Cookie header is optional, a premium session is needed for the content of
+// premium problems.
+func (h *Handler) HandleGetProblem(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	client, _, err := h.leetCodeClient(r)
+	if err != nil {
+		writeLeetCodeError(w, err)
+		return
+	}
+	meta, err := problems.Get(r.Context(), h.Datastore, client, r.Header.Get("X-LeetCode-Cookie"), slug)
+	if errors.Is(err, leetcode.ErrProblemNotFound) {
+		http.Error(w, fmt.Sprintf("Problem %q not found", slug), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeLeetCodeError(w, err)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Problem fetched successfully",
+		Data:    meta,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// metadataTimeout bounds the metadata lookups of a request, metadata only improves the
+// answer so a slow LeetCode must not hold it up
+const metadataTimeout = 5 * time.Second
+
+// problemMetadata looks up the metadata of the problems with the given slugs. Problems that
+// cannot be looked up in time are logged and left out, callers fall back to the title.
+func (h *Handler) problemMetadata(ctx context.Context, client leetcode.LeetCodeClient, cookie string, slugs []string) map[string]models.ProblemMetadata {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	found, err := problems.Lookup(ctx, h.Datastore, client, cookie, slugs, problems.DefaultFetchLimit)
+	if err != nil {
+		log.Printf("Failed to look up problem metadata: %v", err)
+	}
+	return found
+}
+
+// metadataClient is leetCodeClient for the lookups that only improve an answer, with the
+// cookie to send. Both sites publish the same problems, when the site of the request cannot
+// be told the metadata is looked up on leetcode.com without a session.
+func (h *Handler) metadataClient(r *http.Request) (leetcode.LeetCodeClient, string) {
+	client, _, err := h.leetCodeClient(r)
+	if err != nil {
+		log.Printf("Failed to pick the LeetCode site: %v", err)
+		return h.LeetCode, ""
+	}
+	return client, r.Header.Get("X-LeetCode-Cookie")
+}
+
+// fillProblemMetadata sets the difficulty and the missing tags of revision problems from
+// their LeetCode metadata
+func (h *Handler) fillProblemMetadata(r *http.Request, revisions []models.RevisionProblem) {
+	slugs := make([]string, len(revisions))
+	for i := range revisions {
+		slugs[i] = revisions[i].EnsureProblemID()
+	}
+	client, cookie := h.metadataClient(r)
+	found := h.problemMetadata(r.Context(), client, cookie, slugs)
+	for i := range revisions {
+		if meta, ok := found[revisions[i].Problem_id]; ok {
+			problems.Fill(&revisions[i], meta)
+		}
+	}
+}
+
+// submissionProblems is problemMetadata for the problems of submissions
+func (h *Handler) submissionProblems(ctx context.Context, client leetcode.LeetCodeClient, cookie string, submissions []models.LeetCodeSubmission) map[string]models.ProblemMetadata {
+	slugs := make([]string, len(submissions))
+	for i := range submissions {
+		slugs[i] = submissions[i].ProblemSlug()
+	}
+	return h.problemMetadata(ctx, client, cookie, slugs)
+}
+
+// fillProblemStatement replaces the title the frontend sends as the problem statement with
+// the statement LeetCode publishes, when the problem can be looked up
+func (h *Handler) fillProblemStatement(r *http.Request, toCheck *ai.ToCheck) {
+	slug := toCheck.TitleSlug
+	if slug == "" {
+		slug = models.SlugifyTitle(toCheck.ProblemStatement)
+	}
+	client, cookie := h.metadataClient(r)
+	if meta, ok := h.problemMetadata(r.Context(), client, cookie, []string{slug})[slug]; ok {
+		toCheck.ProblemStatement = ai.ProblemStatement(toCheck.ProblemStatement, &meta)
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"dsa-helper-backend/internals/leetcode/leetcodetest"
+	"dsa-helper-backend/internals/models"
+	"net/http"
+	"testing"
+)
+
+func TestHandleGetProblem(t *testing.T) {
+	h, site := newTestHandler(t)
+	want := leetcodetest.Problems()[1]
+	get := func(slug string) *http.Response {
+		t.Helper()
+		w := serve(h.HandleGetProblem, testRequest{method: "GET", target: "/problems/" + slug, userId: "u", params: map[string]string{"slug": slug}})
+		return w.Result()
+	}
+	w := serve(h.HandleGetProblem, testRequest{method: "GET", target: "/problems/" + want.Slug, userId: "u", params: map[string]string{"slug": want.Slug}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: %d %s", w.Code, w.Body)
+	}
+	var meta models.ProblemMetadata
+	decodeData(t, w, &meta)
+	if meta.Title != want.Title || meta.Difficulty != want.Difficulty {
+		t.Fatalf("got %+v, want %+v", meta, want)
+	}
+	// the metadata is cached, a second lookup does not reach LeetCode
+	before := site.Requests()
+	if resp := get(want.Slug); resp.StatusCode != http.StatusOK || site.Requests() != before {
+		t.Fatalf("cached get: %d after %d requests", resp.StatusCode, site.Requests()-before)
+	}
+	if resp := get("no-such-problem"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown slug: %d", resp.StatusCode)
+	}
+	site.FailNext(4, http.StatusBadGateway, "")
+	if resp := get(leetcodetest.Problems()[2].Slug); resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("upstream down: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"dsa-helper-backend/internals/scheduler"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// reviewRequest is the body of POST /revisions/{problemId}/reviews
+type reviewRequest struct {
+	Grade              int    `json:"grade"`
+	Time_spent_seconds int    `json:"time_spent_seconds"`
+	Notes_delta        string `json:"notes_delta"`
+	// Version optionally guards the review like the version of a revision update
+	Version int64 `json:"version"`
+}
+
+type reviewResponse struct {
+	Revision models.RevisionProblem `json:"revision"`
+	Review   models.ReviewRecord    `json:"review"`
+}
+
+// HandleAddReview is POST /revisions/{problemId}/reviews, it appends a review to the history
+// and moves the counters and the next due date of the problem
+func (h *Handler) HandleAddReview(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req reviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Grade < 1 || req.Grade > 5 {
+		http.Error(w, "Grade must be between 1 and 5", http.StatusBadRequest)
+		return
+	}
+	if req.Time_spent_seconds < 0 {
+		http.Error(w, "Time spent cannot be negative", http.StatusBadRequest)
+		return
+	}
+	result, err := h.recordReview(r.Context(), userId, chi.URLParam(r, "problemId"), req)
+	if err != nil {
+		writeRevisionWriteError(w, "Failed to record review", err)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Review recorded successfully",
+		Data:    result,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *Handler) recordReview(ctx context.Context, userId string, problemId string, req reviewRequest) (reviewResponse, error) {
+	problem, err := h.Datastore.GetRevisionProblem(ctx, userId, problemId)
+	if err != nil {
+		return reviewResponse{}, err
+	}
+	sched, settings, err := h.userScheduler(ctx, userId, problem.Decks...)
+	if err != nil {
+		return reviewResponse{}, err
+	}
+	load, err := h.userLoad(ctx, userId, settings, problem.Problem_id)
+	if err != nil {
+		return reviewResponse{}, err
+	}
+	// without a client version the review is still guarded against a concurrent one
+	if req.Version != 0 {
+		problem.Version = req.Version
+	}
+	now := time.Now().In(settings.Location())
+	problem.Confidence_level = req.Grade
+	// Review reads Last_revised for the elapsed time, so it runs before the counters move
+	scheduler.Review(sched, &problem, now)
+	if !scheduler.TrackLeech(&problem, req.Grade, settings.LeechThreshold, now) {
+		load.Balance(&problem, now)
+	}
+	problem.Revision_count++
+	problem.Last_revised = now.Format("2006-01-02")
+	problem.Notes = appendNotes(problem.Notes, req.Notes_delta)
+	review := models.ReviewRecord{
+		Reviewed_at:        now,
+		Grade:              req.Grade,
+		Time_spent_seconds: req.Time_spent_seconds,
+		Notes_delta:        req.Notes_delta,
+		Next_revision:      problem.Next_revision,
+	}
+	problem, review, err = h.Datastore.RecordReview(ctx, userId, problem, review)
+	if err != nil {
+		return reviewResponse{}, err
+	}
+	return reviewResponse{Revision: problem, Review: review}, nil
+}
+
+func appendNotes(notes string, delta string) string {
+	if delta == "" {
+		return notes
+	}
+	if notes == "" || notes == "No notes available" {
+		return delta
+	}
+	return notes + "\n" + delta
+}
+
+func (h *Handler) HandleGetReviews(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	problemId := chi.URLParam(r, "problemId")
+	_, err := h.Datastore.GetRevisionProblem(r.Context(), userId, problemId)
+	if errors.Is(err, datastore.ErrNotFound) {
+		http.Error(w, fmt.Sprintf("Revision problem %s not found", problemId), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get revision problem: %v", err), http.StatusInternalServerError)
+		return
+	}
+	reviews, err := h.Datastore.GetReviews(r.Context(), userId, problemId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get reviews: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Reviews fetched successfully",
+		Data:    reviews,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
@@ -2,25 +2,52 @@ package handlers
 
 import (
 	"context"
+	"dsa-helper-backend/internals/ai"
+	"dsa-helper-backend/internals/config"
 	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/leetcode"
 	"dsa-helper-backend/internals/middlewares"
 	"dsa-helper-backend/internals/models"
+	"dsa-helper-backend/internals/scheduler"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
 )
 
-type Firestore struct {
-	Datastore *datastore.Datastore
+// Handler serves the routes that need a datastore, it works with any Store backend
+type Handler struct {
+	Datastore datastore.Store
+	// TrashRetention is how long deleted revisions stay restorable
+	TrashRetention time.Duration
+	// LeetCode fetches the submissions of the user the X-LeetCode-Cookie header belongs to
+	LeetCode leetcode.LeetCodeClient
+	// LeetCodeCN is LeetCode for the requests of users on leetcode.cn
+	LeetCodeCN leetcode.LeetCodeClient
+	// HighLevelAnalysis fills in the complexity fields of synced submissions, tests swap in a stub
+	HighLevelAnalysis func(submissions []models.LeetCodeSubmission, problems map[string]models.ProblemMetadata, config config.GeminiConfig) ([]models.LeetCodeSubmission, error)
 }
 
-func NewFirestoreHandler(datastore *datastore.Datastore) *Firestore {
-	return &Firestore{
-		Datastore: datastore,
+// defaultTrashRetention matches the TRASH_RETENTION_DAYS default
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+func NewHandler(store datastore.Store) *Handler {
+	return &Handler{
+		Datastore:         store,
+		TrashRetention:    defaultTrashRetention,
+		LeetCode:          leetcode.NewClient(leetcode.DefaultBaseURL, nil),
+		LeetCodeCN:        leetcode.NewRegionClient(leetcode.RegionCN, leetcode.CNBaseURL, nil),
+		HighLevelAnalysis: ai.HighLevelAnalysis,
 	}
 }
 
-func (fs *Firestore) HandleAddRevisions(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleAddRevisions(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
 	if !ok || userId == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -39,7 +66,27 @@ func (fs *Firestore) HandleAddRevisions(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "No new problems provided", http.StatusBadRequest)
 		return
 	}
-	err := fs.Datastore.AddRevisionProblems(context.Background(), userId, revisionProblems)
+	if err := models.PreprocessRevisionProblems(revisionProblems); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid revision problem: %v", err), http.StatusBadRequest)
+		return
+	}
+	h.fillProblemMetadata(r, revisionProblems)
+	decks, err := h.userDecks(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get decks: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for i := range revisionProblems {
+		if err := checkDecks(decks, &revisionProblems[i], nil); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid revision problem: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := h.scheduleNewProblems(r.Context(), userId, revisionProblems, decks); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to schedule revision problems: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = h.Datastore.AddRevisionProblems(context.Background(), userId, revisionProblems)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to add revision problems: %v", err), http.StatusInternalServerError)
 		return
@@ -55,7 +102,12 @@ func (fs *Firestore) HandleAddRevisions(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-func (fs *Firestore) HandleGetRevisions(w http.ResponseWriter, r *http.Request) {
+// HandleGetRevisions is GET /revisions, a page of the revisions matching the query parameters:
+// tag, deck, difficulty, language, min_confidence, max_confidence, due_before, due_after
+// (dates in the user's time zone or RFC 3339 times), q to search title and notes, sort
+// (next_revision, last_revised, confidence, title or revision_count), order=desc, limit and
+// cursor, the next_cursor of the previous page.
+func (h *Handler) HandleGetRevisions(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
 	if !ok || userId == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -65,15 +117,32 @@ func (fs *Firestore) HandleGetRevisions(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
-	revisionProblems, err := fs.Datastore.GetRevisionProblems(context.Background(), userId)
+	deck, err := h.deckFilter(r.Context(), userId, r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to add revision problems: %v", err), http.StatusInternalServerError)
+		writeDeckFilterError(w, err)
+		return
+	}
+	query, err := h.revisionQuery(r.Context(), userId, r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid query: %v", err), http.StatusBadRequest)
+		return
+	}
+	if deck != nil {
+		query.Deck = deck.ID
+	}
+	page, err := h.Datastore.QueryRevisionProblems(r.Context(), userId, query)
+	if errors.Is(err, datastore.ErrInvalidQuery) {
+		http.Error(w, fmt.Sprintf("Invalid query: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get revision problems: %v", err), http.StatusInternalServerError)
 		return
 	}
 	err = json.NewEncoder(w).Encode(models.Response{
 		Status:  "success",
 		Message: "Revision problems fetched successfully",
-		Data:    revisionProblems,
+		Data:    page,
 	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
@@ -81,7 +150,55 @@ func (fs *Firestore) HandleGetRevisions(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-func (fs *Firestore) HandleDeleteRevision(w http.ResponseWriter, r *http.Request) {
+// revisionQuery reads the query parameters of GET /revisions
+func (h *Handler) revisionQuery(ctx context.Context, userId string, r *http.Request) (datastore.RevisionQuery, error) {
+	params := r.URL.Query()
+	query := datastore.RevisionQuery{
+		Tag:        params.Get("tag"),
+		Difficulty: params.Get("difficulty"),
+		Language:   params.Get("language"),
+		Search:     strings.TrimSpace(params.Get("q")),
+		Sort:       params.Get("sort"),
+		Desc:       params.Get("order") == "desc",
+		Cursor:     params.Get("cursor"),
+	}
+	var err error
+	if query.MinConfidence, err = intParam(params.Get("min_confidence"), 0, 1, 5); err != nil {
+		return query, fmt.Errorf("min_confidence: %w", err)
+	}
+	if query.MaxConfidence, err = intParam(params.Get("max_confidence"), 0, 1, 5); err != nil {
+		return query, fmt.Errorf("max_confidence: %w", err)
+	}
+	if query.Limit, err = intParam(params.Get("limit"), 50, 1, 200); err != nil {
+		return query, fmt.Errorf("limit: %w", err)
+	}
+	if params.Get("due_before") != "" || params.Get("due_after") != "" {
+		settings, err := h.Datastore.GetUserSettings(ctx, userId)
+		if err != nil {
+			return query, err
+		}
+		if query.DueBefore, err = timeParam(params.Get("due_before"), settings.Location()); err != nil {
+			return query, fmt.Errorf("due_before: %w", err)
+		}
+		if query.DueAfter, err = timeParam(params.Get("due_after"), settings.Location()); err != nil {
+			return query, fmt.Errorf("due_after: %w", err)
+		}
+	}
+	return query, query.Validate()
+}
+
+// timeParam parses an optional RFC 3339 time or a date, which starts at midnight in loc
+func timeParam(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+func (h *Handler) HandleDeleteRevision(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
 	if !ok || userId == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -96,9 +213,9 @@ func (fs *Firestore) HandleDeleteRevision(w http.ResponseWriter, r *http.Request
 		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
 		return
 	}
-	err := fs.Datastore.DeleteRevisionProblem(context.Background(), userId, revisionProblem)
+	err := h.Datastore.DeleteRevisionProblem(context.Background(), userId, revisionProblem)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to delete revision problem: %v", err), http.StatusInternalServerError)
+		writeRevisionWriteError(w, "Failed to delete revision problem", err)
 		return
 	}
 	err = json.NewEncoder(w).Encode(models.Response{
@@ -112,7 +229,7 @@ func (fs *Firestore) HandleDeleteRevision(w http.ResponseWriter, r *http.Request
 	}
 }
 
-func (fs *Firestore) HandleUpdateRevision(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleUpdateRevision(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
 	if !ok || userId == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -127,15 +244,15 @@ func (fs *Firestore) HandleUpdateRevision(w http.ResponseWriter, r *http.Request
 		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
 		return
 	}
-	err := fs.Datastore.UpdateRevisionProblem(context.Background(), userId, revisionProblem)
+	updatedProblem, err := h.editRevisionProblem(r.Context(), userId, revisionProblem)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to update revision problem: %v", err), http.StatusInternalServerError)
+		writeRevisionWriteError(w, "Failed to update revision problem", err)
 		return
 	}
 	err = json.NewEncoder(w).Encode(models.Response{
 		Status:  "success",
 		Message: "Revision problem updated successfully",
-		Data:    nil,
+		Data:    updatedProblem,
 	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
@@ -143,7 +260,7 @@ func (fs *Firestore) HandleUpdateRevision(w http.ResponseWriter, r *http.Request
 	}
 }
 
-func (fs *Firestore) HandleGetDueRevisions(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleGetDueRevisions(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
 	if !ok || userId == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -154,11 +271,38 @@ func (fs *Firestore) HandleGetDueRevisions(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	dueProblems, err := fs.Datastore.GetDueRevisionProblems(context.Background(), userId)
+	settings, err := h.Datastore.GetUserSettings(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	deck, err := h.deckFilter(r.Context(), userId, r)
+	if err != nil {
+		writeDeckFilterError(w, err)
+		return
+	}
+	// a deck view is capped by the daily limit of the deck
+	if deck != nil {
+		settings = settings.WithDeck(*deck)
+	}
+	// everything due at any time of the current day of the user counts as due, a paused
+	// schedule stays on the day it was paused
+	now := time.Now()
+	if settings.Paused() {
+		now = settings.PausedAt
+	}
+	endOfToday := scheduler.StartOfDay(now.In(settings.Location())).AddDate(0, 0, 1)
+	dueProblems, err := h.Datastore.GetDueRevisionProblems(context.Background(), userId, endOfToday)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get due revision problems: %v", err), http.StatusInternalServerError)
 		return
 	}
+	dueProblems = inDeck(dueProblems, deck)
+	// past the daily limit the remaining problems stay due and lead tomorrow's list
+	scheduler.Prioritize(dueProblems)
+	if settings.DailyReviewLimit > 0 && len(dueProblems) > settings.DailyReviewLimit {
+		dueProblems = dueProblems[:settings.DailyReviewLimit]
+	}
 
 	err = json.NewEncoder(w).Encode(models.Response{
 		Status:  "success",
@@ -170,3 +314,369 @@ func (fs *Firestore) HandleGetDueRevisions(w http.ResponseWriter, r *http.Reques
 		return
 	}
 }
+
+// writeRevisionWriteError answers a conflict with 409 and the current server copy so the
+// client can merge its edit and retry with the new version
+func writeRevisionWriteError(w http.ResponseWriter, message string, err error) {
+	var conflict *datastore.ConflictError
+	if errors.As(err, &conflict) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.Response{
+			Status:  "conflict",
+			Message: fmt.Sprintf("%s: %v", message, err),
+			Data:    conflict.Current,
+		})
+		return
+	}
+	if errors.Is(err, datastore.ErrNotFound) {
+		http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errInvalidDeck) || errors.Is(err, errReviewState) || errors.Is(err, models.ErrInvalidProblemID) {
+		http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusInternalServerError)
+}
+
+// userScheduler returns the scheduler the user picked in their settings along with the settings,
+// the overrides of the first existing deck of deckIDs are applied to both
+func (h *Handler) userScheduler(ctx context.Context, userId string, deckIDs ...string) (scheduler.Scheduler, models.UserSettings, error) {
+	settings, err := h.Datastore.GetUserSettings(ctx, userId)
+	if err != nil {
+		return nil, settings, err
+	}
+	if len(deckIDs) > 0 {
+		decks, err := h.userDecks(ctx, userId)
+		if err != nil {
+			return nil, settings, err
+		}
+		settings = withDecks(settings, decks, deckIDs)
+	}
+	sched, err := scheduler.ForName(settings.Scheduler)
+	return sched, settings, err
+}
+
+// userLoad counts the due days of the stored revisions of the user, leaving out the
+// problems that are about to be rescheduled
+func (h *Handler) userLoad(ctx context.Context, userId string, settings models.UserSettings, rescheduled ...string) (*scheduler.Load, error) {
+	revisionProblems, err := h.Datastore.GetRevisionProblems(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	kept := revisionProblems[:0]
+	for _, p := range revisionProblems {
+		if !slices.Contains(rescheduled, p.Problem_id) {
+			kept = append(kept, p)
+		}
+	}
+	return scheduler.NewLoad(kept, settings.Location(), settings.DailyReviewLimit), nil
+}
+
+// scheduleNewProblems gives problems about to be added their first revision date. A bulk add
+// would put every problem of the same confidence on one day, BalanceNew spreads them.
+func (h *Handler) scheduleNewProblems(ctx context.Context, userId string, problems []models.RevisionProblem, decks map[string]models.Deck) error {
+	settings, err := h.Datastore.GetUserSettings(ctx, userId)
+	if err != nil {
+		return err
+	}
+	problemIds := make([]string, len(problems))
+	for i := range problems {
+		problemIds[i] = problems[i].Problem_id
+	}
+	load, err := h.userLoad(ctx, userId, settings, problemIds...)
+	if err != nil {
+		return err
+	}
+	load.SpreadNew(len(problems))
+	now := time.Now().In(settings.Location())
+	for i := range problems {
+		sched, err := scheduler.ForName(withDecks(settings, decks, problems[i].Decks).Scheduler)
+		if err != nil {
+			return err
+		}
+		scheduler.Review(sched, &problems[i], now)
+		load.BalanceNew(&problems[i], now)
+	}
+	return nil
+}
+
+// editRevisionProblem saves a client edit of problem. The review state is maintained by the
+// server through the reviews endpoint, an edit that changes it is rejected with errReviewState.
+func (h *Handler) editRevisionProblem(ctx context.Context, userId string, problem models.RevisionProblem) (models.RevisionProblem, error) {
+	if err := models.ValidateProblemID(problem.EnsureProblemID()); err != nil {
+		return models.RevisionProblem{}, err
+	}
+	current, err := h.Datastore.GetRevisionProblem(ctx, userId, problem.Problem_id)
+	if err != nil {
+		return models.RevisionProblem{}, err
+	}
+	decks, err := h.userDecks(ctx, userId)
+	if err != nil {
+		return models.RevisionProblem{}, err
+	}
+	if err := checkDecks(decks, &problem, current.Decks); err != nil {
+		return models.RevisionProblem{}, err
+	}
+	if err := keepReviewState(&problem, current); err != nil {
+		return models.RevisionProblem{}, err
+	}
+	return h.Datastore.UpdateRevisionProblem(ctx, userId, problem)
+}
+
+// errReviewState is returned when an edit tries to change the review state of a problem, which
+// only moves through POST /revisions/{problemId}/reviews
+var errReviewState = errors.New("review state is maintained by the server, record a review instead")
+
+// keepReviewState copies the server maintained review fields of current to problem. Fields the
+// client left out are filled in, fields it sent with another value fail with errReviewState.
+func keepReviewState(problem *models.RevisionProblem, current models.RevisionProblem) error {
+	var changed []string
+	check := func(name string, sent bool, same bool) {
+		if sent && !same {
+			changed = append(changed, name)
+		}
+	}
+	check("confidence_level", problem.Confidence_level != 0, problem.Confidence_level == current.Confidence_level)
+	check("revision_count", problem.Revision_count != 0, problem.Revision_count == current.Revision_count)
+	check("last_revised", problem.Last_revised != "", problem.Last_revised == current.Last_revised)
+	check("next_revision", !problem.Next_revision.IsZero(), problem.Next_revision.Equal(current.Next_revision))
+	check("schedule", problem.Schedule != models.ScheduleState{}, problem.Schedule == current.Schedule)
+	check("failure_count", problem.Failure_count != 0, problem.Failure_count == current.Failure_count)
+	check("is_leech", problem.Is_leech, problem.Is_leech == current.Is_leech)
+	check("relearning", problem.Relearning, problem.Relearning == current.Relearning)
+	check("relearning_step", problem.Relearning_step != 0, problem.Relearning_step == current.Relearning_step)
+	if len(changed) > 0 {
+		return fmt.Errorf("%w: %s", errReviewState, strings.Join(changed, ", "))
+	}
+	problem.Confidence_level = current.Confidence_level
+	problem.Revision_count = current.Revision_count
+	problem.Last_revised = current.Last_revised
+	problem.Next_revision = current.Next_revision
+	problem.Schedule = current.Schedule
+	problem.Failure_count = current.Failure_count
+	problem.Is_leech = current.Is_leech
+	problem.Relearning = current.Relearning
+	problem.Relearning_step = current.Relearning_step
+	return nil
+}
+
+func (h *Handler) HandleGetRevision(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	problemId := chi.URLParam(r, "problemId")
+	revisionProblem, err := h.Datastore.GetRevisionProblem(r.Context(), userId, problemId)
+	if errors.Is(err, datastore.ErrNotFound) {
+		http.Error(w, fmt.Sprintf("Revision problem %s not found", problemId), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get revision problem: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Revision problem fetched successfully",
+		Data:    revisionProblem,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleUpdateRevisionByID is PUT /revisions/{problemId}, the path wins over any problem_id in the body
+func (h *Handler) HandleUpdateRevisionByID(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var revisionProblem models.RevisionProblem
+	if err := json.NewDecoder(r.Body).Decode(&revisionProblem); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	revisionProblem.Problem_id = chi.URLParam(r, "problemId")
+	updatedProblem, err := h.editRevisionProblem(r.Context(), userId, revisionProblem)
+	if err != nil {
+		writeRevisionWriteError(w, "Failed to update revision problem", err)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Revision problem updated successfully",
+		Data:    updatedProblem,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleDeleteRevisionByID is DELETE /revisions/{problemId}, an optional ?version= guards the delete
+func (h *Handler) HandleDeleteRevisionByID(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	revisionProblem := models.RevisionProblem{Problem_id: chi.URLParam(r, "problemId")}
+	if version := r.URL.Query().Get("version"); version != "" {
+		versionNum, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid version: %v", err), http.StatusBadRequest)
+			return
+		}
+		revisionProblem.Version = versionNum
+	}
+	err := h.Datastore.DeleteRevisionProblem(r.Context(), userId, revisionProblem)
+	if err != nil {
+		writeRevisionWriteError(w, "Failed to delete revision problem", err)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Revision problem deleted successfully",
+		Data:    nil,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// forecastResponse is the body of GET /revisions/forecast
+type forecastResponse struct {
+	Days []models.ForecastDay `json:"days"`
+	// Simulated is the number of hypothetical problems added today by the what if mode
+	Simulated int `json:"simulated"`
+	// Paused is set while the schedule is paused, the stored problems are left out then
+	Paused bool `json:"paused"`
+}
+
+// HandleGetForecast is GET /revisions/forecast?days=N, it returns the reviews due per day.
+// Every problem is replayed with the scheduler of its decks and each day is capped by the daily limit like /due.
+// ?deck= limits the forecast to one deck and uses the scheduler of that deck. With ?add=N the forecast includes N problems added today with ?confidence= (default 3)
+// and ?difficulty=, so the cost of taking on new problems can be seen before adding them.
+func (h *Handler) HandleGetForecast(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query()
+	days, err := intParam(query.Get("days"), 14, 1, 365)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid days: %v", err), http.StatusBadRequest)
+		return
+	}
+	add, err := intParam(query.Get("add"), 0, 0, 1000)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid add: %v", err), http.StatusBadRequest)
+		return
+	}
+	confidence, err := intParam(query.Get("confidence"), 3, 1, 5)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid confidence: %v", err), http.StatusBadRequest)
+		return
+	}
+	deck, err := h.deckFilter(r.Context(), userId, r)
+	if err != nil {
+		writeDeckFilterError(w, err)
+		return
+	}
+	var deckIDs []string
+	if deck != nil {
+		deckIDs = []string{deck.ID}
+	}
+	settings, err := h.Datastore.GetUserSettings(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	decks, err := h.userDecks(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get decks: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// the daily limit is the one /due applies to the same view
+	limit := withDecks(settings, decks, deckIDs).DailyReviewLimit
+	schedulerOf, err := problemSchedulers(settings, decks, deck)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load scheduler: %v", err), http.StatusInternalServerError)
+		return
+	}
+	var revisionProblems []models.RevisionProblem
+	// nothing becomes due while the schedule is paused and resuming moves every due date,
+	// so the stored problems only show up again once it runs
+	if !settings.Paused() {
+		revisionProblems, err = h.Datastore.GetRevisionProblems(r.Context(), userId)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get revision problems: %v", err), http.StatusInternalServerError)
+			return
+		}
+		revisionProblems = inDeck(revisionProblems, deck)
+	}
+	now := time.Now().In(settings.Location())
+	for range add {
+		simulated := models.RevisionProblem{Confidence_level: confidence, Difficulty: query.Get("difficulty"), Decks: deckIDs}
+		scheduler.Review(schedulerOf(simulated), &simulated, now)
+		simulated.Last_revised = now.Format("2006-01-02")
+		revisionProblems = append(revisionProblems, simulated)
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Revision forecast computed successfully",
+		Data: forecastResponse{
+			Days:      scheduler.Forecast(schedulerOf, revisionProblems, now, days, limit),
+			Simulated: add,
+			Paused:    settings.Paused(),
+		},
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// problemSchedulers resolves the scheduler of a problem from the overrides of its decks, or
+// from deck for every problem when the view is limited to one deck
+func problemSchedulers(settings models.UserSettings, decks map[string]models.Deck, deck *models.Deck) (func(models.RevisionProblem) scheduler.Scheduler, error) {
+	byName := make(map[string]scheduler.Scheduler)
+	names := []string{settings.Scheduler}
+	for _, d := range decks {
+		names = append(names, settings.WithDeck(d).Scheduler)
+	}
+	for _, name := range names {
+		sched, err := scheduler.ForName(name)
+		if err != nil {
+			return nil, err
+		}
+		byName[name] = sched
+	}
+	return func(p models.RevisionProblem) scheduler.Scheduler {
+		if deck != nil {
+			return byName[settings.WithDeck(*deck).Scheduler]
+		}
+		return byName[withDecks(settings, decks, p.Decks).Scheduler]
+	}, nil
+}
+
+// intParam parses an optional integer query parameter within [lo, hi]
+func intParam(value string, fallback int, lo int, hi int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < lo || n > hi {
+		return 0, fmt.Errorf("%d is outside [%d, %d]", n, lo, hi)
+	}
+	return n, nil
+}
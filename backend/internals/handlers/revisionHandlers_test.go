@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateRevisionRejectsReviewState(t *testing.T) {
+	h, _ := newTestHandler(t)
+	w := serve(h.HandleAddRevisions, testRequest{method: "POST", target: "/revisions", userId: "u",
+		body: `[{"title":"Two Sum","confidence_level":3}]`})
+	if w.Code != http.StatusOK {
+		t.Fatalf("add: %d %s", w.Code, w.Body)
+	}
+	stored, err := h.Datastore.GetRevisionProblem(context.Background(), "u", "two-sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"omitted", `{"title":"Two Sum","notes":"hash map"}`, http.StatusOK},
+		{"unchanged", `{"title":"Two Sum","notes":"hash map","confidence_level":3,"next_revision":"` + stored.Next_revision.Format("2006-01-02T15:04:05Z07:00") + `"}`, http.StatusOK},
+		{"confidence", `{"title":"Two Sum","confidence_level":5}`, http.StatusBadRequest},
+		{"revision_count", `{"title":"Two Sum","revision_count":4}`, http.StatusBadRequest},
+		{"next_revision", `{"title":"Two Sum","next_revision":"2030-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"last_revised", `{"title":"Two Sum","last_revised":"2030-01-01"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.HandleUpdateRevisionByID, testRequest{method: "PUT", target: "/revisions/two-sum", userId: "u",
+				body: tt.body, params: map[string]string{"problemId": "two-sum"}})
+			if w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			if tt.code == http.StatusBadRequest && !strings.Contains(w.Body.String(), tt.name) {
+				t.Errorf("error %q does not name the field %s", w.Body, tt.name)
+			}
+			if tt.code == http.StatusOK {
+				var got models.RevisionProblem
+				decodeData(t, w, &got)
+				if got.Confidence_level != 3 || !got.Next_revision.Equal(stored.Next_revision) {
+					t.Errorf("review state changed: %+v", got)
+				}
+			}
+		})
+	}
+	w = serve(h.HandleAddReview, testRequest{method: "POST", target: "/revisions/two-sum/reviews", userId: "u",
+		body: `{"grade":5}`, params: map[string]string{"problemId": "two-sum"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("review: %d %s", w.Code, w.Body)
+	}
+	reviewed, _ := h.Datastore.GetRevisionProblem(context.Background(), "u", "two-sum")
+	if reviewed.Confidence_level != 5 || reviewed.Revision_count != 1 || !reviewed.Next_revision.After(stored.Next_revision) {
+		t.Errorf("review did not move the state: %+v", reviewed)
+	}
+}
+
+func TestAddRevisionsValidatesProblemIDs(t *testing.T) {
+	h, _ := newTestHandler(t)
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"slug from title", `[{"title":"Two Sum","confidence_level":3}]`, http.StatusOK},
+		{"slash", `[{"title":"Two Sum","problem_id":"a/b","confidence_level":3}]`, http.StatusBadRequest},
+		{"reserved", `[{"title":"Two Sum","problem_id":"__meta__","confidence_level":3}]`, http.StatusBadRequest},
+		{"empty slug", `[{"title":"!!!","confidence_level":3}]`, http.StatusBadRequest},
+		{"duplicate", `[{"title":"Valid Anagram","confidence_level":3},{"title":"valid anagram","confidence_level":3}]`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.HandleAddRevisions, testRequest{method: "POST", target: "/revisions", userId: "u", body: tt.body})
+			if w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+		})
+	}
+	if _, err := h.Datastore.GetRevisionProblem(context.Background(), "u", "valid-anagram"); err == nil {
+		t.Fatal("a rejected batch was partly stored")
+	}
+}
+
+func TestForecastFollowsDecksLimitAndPause(t *testing.T) {
+	h, _ := newTestHandler(t)
+	ctx := context.Background()
+	deck, err := h.Datastore.CreateDeck(ctx, "u", models.Deck{Name: "Graphs", Scheduler: "fixed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	err = h.Datastore.AddRevisionProblems(ctx, "u", []models.RevisionProblem{
+		{Problem_id: "two-sum", LeetCodeSubmission: models.LeetCodeSubmission{Title: "Two Sum"}, Confidence_level: 5, Next_revision: yesterday},
+		{Problem_id: "course-schedule", LeetCodeSubmission: models.LeetCodeSubmission{Title: "Course Schedule"}, Confidence_level: 5, Next_revision: yesterday, Decks: []string{deck.ID}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forecast := func() forecastResponse {
+		t.Helper()
+		w := serve(h.HandleGetForecast, testRequest{method: "GET", target: "/revisions/forecast?days=2", userId: "u"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("forecast: %d %s", w.Code, w.Body)
+		}
+		var resp forecastResponse
+		decodeData(t, w, &resp)
+		return resp
+	}
+	totals := func(resp forecastResponse) []int {
+		var totals []int
+		for _, day := range resp.Days {
+			totals = append(totals, day.Total)
+		}
+		return totals
+	}
+
+	// SM-2 brings two-sum back tomorrow, the fixed scheduler of the deck waits 30 days
+	if got := totals(forecast()); !slices.Equal(got, []int{2, 1}) {
+		t.Fatalf("reviews per day %v, want [2 1]", got)
+	}
+	settings := models.UserSettings{UserID: "u", DailyReviewLimit: 1}
+	if err := h.Datastore.SaveUserSettings(ctx, settings); err != nil {
+		t.Fatal(err)
+	}
+	if got := totals(forecast()); !slices.Equal(got, []int{1, 1}) {
+		t.Fatalf("reviews per day with a limit of 1 %v, want [1 1]", got)
+	}
+	settings.PausedAt = time.Now()
+	if err := h.Datastore.SaveUserSettings(ctx, settings); err != nil {
+		t.Fatal(err)
+	}
+	resp := forecast()
+	if got := totals(resp); !resp.Paused || !slices.Equal(got, []int{0, 0}) {
+		t.Fatalf("paused forecast %v paused=%v, want [0 0] paused", got, resp.Paused)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"dsa-helper-backend/internals/leetcode"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"dsa-helper-backend/internals/scheduler"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func (h *Handler) HandleGetSettings(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	settings, err := h.Datastore.GetUserSettings(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if settings.Scheduler == "" {
+		settings.Scheduler = scheduler.DefaultName
+	}
+	if settings.Timezone == "" {
+		settings.Timezone = "UTC"
+	}
+	if settings.LeechThreshold == 0 {
+		settings.LeechThreshold = scheduler.DefaultLeechThreshold
+	}
+	if settings.LeetCodeRegion == "" {
+		settings.LeetCodeRegion = string(leetcode.RegionGlobal)
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Settings fetched successfully",
+		Data:    settings,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleUpdateSettings is PUT /settings, switching scheduler keeps existing due dates and
+// each problem moves to the new algorithm at its next review
+func (h *Handler) HandleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var settings models.UserSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if _, err := scheduler.ForName(settings.Scheduler); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid settings: %v", err), http.StatusBadRequest)
+		return
+	}
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid settings: unknown timezone %q", settings.Timezone), http.StatusBadRequest)
+		return
+	}
+	if settings.DailyReviewLimit < 0 {
+		http.Error(w, "Invalid settings: daily review limit cannot be negative", http.StatusBadRequest)
+		return
+	}
+	if settings.LeechThreshold < 0 {
+		http.Error(w, "Invalid settings: leech threshold cannot be negative", http.StatusBadRequest)
+		return
+	}
+	region, err := leetcode.ParseRegion(settings.LeetCodeRegion)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid settings: %v", err), http.StatusBadRequest)
+		return
+	}
+	settings.LeetCodeRegion = string(region)
+	current, err := h.Datastore.GetUserSettings(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// pausing goes through its own routes
+	settings.PausedAt = current.PausedAt
+	settings.ResumeCursor = current.ResumeCursor
+	settings.UserID = userId
+	err = h.Datastore.SaveUserSettings(r.Context(), settings)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Settings saved successfully",
+		Data:    settings,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/middlewares"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// trashItem is a deleted revision with the time it will be purged at
+type trashItem struct {
+	models.TrashedRevision
+	Expires_at time.Time `json:"expires_at"`
+}
+
+// HandleGetTrash is GET /revisions/trash, the deleted revisions that can still be restored
+func (h *Handler) HandleGetTrash(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	trash, err := h.Datastore.GetTrash(r.Context(), userId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get trash: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// the purge runs periodically, items past their retention are hidden until it does
+	now := time.Now()
+	items := make([]trashItem, 0, len(trash))
+	for _, t := range trash {
+		expiresAt := t.Deleted_at.Add(h.TrashRetention)
+		if expiresAt.After(now) {
+			items = append(items, trashItem{TrashedRevision: t, Expires_at: expiresAt})
+		}
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Trash fetched successfully",
+		Data:    items,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleRestoreRevision is POST /revisions/{problemId}/restore, it answers 409 with the
+// current copy when the problem was added again after it was deleted
+func (h *Handler) HandleRestoreRevision(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(middlewares.UserIDContext).(string)
+	if !ok || userId == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	problemId := chi.URLParam(r, "problemId")
+	restored, err := h.Datastore.RestoreRevisionProblem(r.Context(), userId, problemId)
+	if errors.Is(err, datastore.ErrNotFound) {
+		http.Error(w, fmt.Sprintf("Revision problem %s is not in the trash", problemId), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeRevisionWriteError(w, "Failed to restore revision problem", err)
+		return
+	}
+	err = json.NewEncoder(w).Encode(models.Response{
+		Status:  "success",
+		Message: "Revision problem restored successfully",
+		Data:    restored,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,257 @@
+// Package importer reads revision problems from CSV and JSON files and from the built-in
+// problem lists
+package importer
+
+import (
+	"dsa-helper-backend/internals/models"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Fields of a revision problem a column can be mapped to
+const (
+	FieldTitle      = "title"
+	FieldURL        = "url"
+	FieldDifficulty = "difficulty"
+	FieldTags       = "tags"
+	FieldConfidence = "confidence"
+	FieldNotes      = "notes"
+)
+
+// columnAliases are the column names recognised without an explicit mapping
+var columnAliases = map[string]string{
+	"title":            FieldTitle,
+	"name":             FieldTitle,
+	"problem":          FieldTitle,
+	"url":              FieldURL,
+	"link":             FieldURL,
+	"difficulty":       FieldDifficulty,
+	"level":            FieldDifficulty,
+	"tags":             FieldTags,
+	"tag":              FieldTags,
+	"topics":           FieldTags,
+	"pattern":          FieldTags,
+	"confidence":       FieldConfidence,
+	"confidence_level": FieldConfidence,
+	"notes":            FieldNotes,
+	"note":             FieldNotes,
+	"comments":         FieldNotes,
+}
+
+// Mapping maps column names, compared case insensitively, to the Field constants. It takes
+// precedence over the recognised column names.
+type Mapping map[string]string
+
+// ParseMapping reads a mapping written as Column=field pairs separated by commas
+func ParseMapping(s string) (Mapping, error) {
+	mapping := Mapping{}
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		column, field, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("mapping %q is not Column=field", pair)
+		}
+		column, field = strings.TrimSpace(column), strings.ToLower(strings.TrimSpace(field))
+		if !slices.Contains([]string{FieldTitle, FieldURL, FieldDifficulty, FieldTags, FieldConfidence, FieldNotes}, field) {
+			return nil, fmt.Errorf("unknown field %q for column %q", field, column)
+		}
+		mapping[strings.ToLower(column)] = field
+	}
+	return mapping, nil
+}
+
+// field returns the field a column is read into, empty when the column is ignored
+func (m Mapping) field(column string) string {
+	column = strings.ToLower(strings.TrimSpace(column))
+	if field, ok := m[column]; ok {
+		return field
+	}
+	return columnAliases[column]
+}
+
+// Row is one problem of an import. Number is the 1 based position of the row among the
+// data rows, Err is set when one of its values could not be read.
+type Row struct {
+	Number  int
+	Problem models.RevisionProblem
+	Err     error
+}
+
+// ReadCSV reads problems from a CSV file whose first record names the columns
+func ReadCSV(r io.Reader, mapping Mapping) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, len(header))
+	for i, column := range header {
+		fields[i] = mapping.field(strings.TrimPrefix(column, "\ufeff"))
+	}
+	if err := checkFields(fields); err != nil {
+		return nil, err
+	}
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := Row{Number: len(rows) + 1}
+		for i, value := range record {
+			if i < len(fields) && fields[i] != "" && row.Err == nil {
+				row.Err = setField(&row.Problem, fields[i], value)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// ReadJSON reads problems from a JSON array of objects, the keys are mapped like CSV columns.
+// Values may be strings or numbers, tags may also be an array of strings.
+func ReadJSON(r io.Reader, mapping Mapping) ([]Row, error) {
+	var objects []map[string]any
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, err
+	}
+	var rows []Row
+	seen := map[string]bool{}
+	for i, object := range objects {
+		row := Row{Number: i + 1}
+		for key, value := range object {
+			field := mapping.field(key)
+			if field == "" {
+				continue
+			}
+			seen[field] = true
+			text, err := jsonText(field, value)
+			if err == nil {
+				err = setField(&row.Problem, field, text)
+			}
+			if err != nil && row.Err == nil {
+				row.Err = fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) > 0 && !seen[FieldTitle] {
+		return nil, errors.New("no key maps to title")
+	}
+	return rows, nil
+}
+
+// checkFields makes sure a title column exists and no field is read from two columns
+func checkFields(fields []string) error {
+	for i, field := range fields {
+		if field != "" && slices.Contains(fields[:i], field) {
+			return fmt.Errorf("more than one column maps to %s", field)
+		}
+	}
+	if !slices.Contains(fields, FieldTitle) {
+		return errors.New("no column maps to title")
+	}
+	return nil
+}
+
+// jsonText turns a JSON value into the text a CSV cell would hold
+func jsonText(field string, value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		if field != FieldTags {
+			return "", errors.New("a list is only allowed for tags")
+		}
+		tags := make([]string, len(v))
+		for i, tag := range v {
+			s, ok := tag.(string)
+			if !ok {
+				return "", errors.New("tags must be strings")
+			}
+			tags[i] = s
+		}
+		return strings.Join(tags, ";"), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// setField reads value into the field of problem
+func setField(problem *models.RevisionProblem, field string, value string) error {
+	value = strings.TrimSpace(value)
+	switch field {
+	case FieldTitle:
+		problem.Title = value
+	case FieldURL:
+		problem.URL = value
+	case FieldDifficulty:
+		difficulty, err := parseDifficulty(value)
+		if err != nil {
+			return err
+		}
+		problem.Difficulty = difficulty
+	case FieldTags:
+		problem.Tags = splitTags(value)
+	case FieldConfidence:
+		if value == "" {
+			return nil
+		}
+		confidence, err := strconv.Atoi(value)
+		if err != nil || confidence < 1 || confidence > 5 {
+			return fmt.Errorf("confidence %q is not a number between 1 and 5", value)
+		}
+		problem.Confidence_level = confidence
+	case FieldNotes:
+		problem.Notes = value
+	}
+	return nil
+}
+
+// parseDifficulty normalises a difficulty to the spelling LeetCode uses
+func parseDifficulty(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	for _, difficulty := range []string{"Easy", "Medium", "Hard"} {
+		if strings.EqualFold(value, difficulty) {
+			return difficulty, nil
+		}
+	}
+	return "", fmt.Errorf("unknown difficulty %q", value)
+}
+
+// splitTags splits a cell on semicolons or pipes, and on commas when it has neither
+func splitTags(value string) []string {
+	separators := ";|"
+	if !strings.ContainsAny(value, separators) {
+		separators = ","
+	}
+	var tags []string
+	for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return strings.ContainsRune(separators, r) }) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
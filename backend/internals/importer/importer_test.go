@@ -0,0 +1,146 @@
+package importer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	input := "\ufeffName,Level,Topics,Confidence,Ignored\n" +
+		"Two Sum,easy,Array;Hash Table,4,x\n" +
+		"Course Schedule,Medium,\"Graph, BFS\",,\n" +
+		"Bad Confidence,Hard,,7\n" +
+		"Bad Difficulty,Impossible,,2\n" +
+		"Short Row\n"
+	rows, err := ReadCSV(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("read %d rows, want 5", len(rows))
+	}
+	first := rows[0].Problem
+	if rows[0].Err != nil || first.Title != "Two Sum" || first.Difficulty != "Easy" || first.Confidence_level != 4 ||
+		!slices.Equal(first.Tags, []string{"Array", "Hash Table"}) {
+		t.Errorf("row 1 = %+v, %v", first, rows[0].Err)
+	}
+	if second := rows[1].Problem; rows[1].Err != nil || second.Confidence_level != 0 || !slices.Equal(second.Tags, []string{"Graph", "BFS"}) {
+		t.Errorf("row 2 = %+v, %v", second, rows[1].Err)
+	}
+	for _, i := range []int{2, 3} {
+		if rows[i].Err == nil {
+			t.Errorf("row %d was accepted", rows[i].Number)
+		}
+	}
+	if rows[4].Err != nil || rows[4].Problem.Title != "Short Row" || rows[4].Number != 5 {
+		t.Errorf("row 5 = %+v, %v", rows[4].Problem, rows[4].Err)
+	}
+}
+
+func TestReadCSVRejectsMalformedFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		mapping string
+	}{
+		{"empty", "", ""},
+		{"no title column", "Difficulty,Tags\nEasy,Array\n", ""},
+		{"two title columns", "Title,Name\nTwo Sum,Two Sum\n", ""},
+		{"mapped onto title twice", "Title,Problem\nTwo Sum,x\n", "Problem=title"},
+		{"unterminated quote", "Title,Tags\n\"Two Sum,Array\n", ""},
+		{"bare quote", "Title,Tags\nTwo \"Sum\",Array\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := ParseMapping(tt.mapping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rows, err := ReadCSV(strings.NewReader(tt.input), mapping); err == nil {
+				t.Fatalf("read %d rows, want an error", len(rows))
+			}
+		})
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	input := `[
+		{"title": "Two Sum", "difficulty": "EASY", "tags": ["Array", "Array", "Hash Table"], "confidence": 3},
+		{"name": "Valid Anagram", "confidence": "9"},
+		{"title": "Merge Intervals", "notes": {"nested": true}},
+		{"title": "Course Schedule", "difficulty": ["Medium"]}
+	]`
+	rows, err := ReadJSON(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("read %d rows, want 4", len(rows))
+	}
+	first := rows[0].Problem
+	if rows[0].Err != nil || first.Difficulty != "Easy" || first.Confidence_level != 3 || !slices.Equal(first.Tags, []string{"Array", "Hash Table"}) {
+		t.Errorf("row 1 = %+v, %v", first, rows[0].Err)
+	}
+	for _, row := range rows[1:] {
+		if row.Err == nil {
+			t.Errorf("row %d was accepted", row.Number)
+		}
+	}
+}
+
+func TestReadJSONRejectsMalformedFiles(t *testing.T) {
+	for _, input := range []string{``, `{"title": "Two Sum"}`, `[{"title": "Two Sum"`, `[{"difficulty": "Easy"}]`} {
+		if rows, err := ReadJSON(strings.NewReader(input), nil); err == nil {
+			t.Errorf("%q read %d rows, want an error", input, len(rows))
+		}
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	mapping, err := ParseMapping(" Question = Title , Rating=confidence")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping.field("QUESTION") != FieldTitle || mapping.field("rating") != FieldConfidence || mapping.field("topics") != FieldTags {
+		t.Errorf("mapping = %v", mapping)
+	}
+	for _, s := range []string{"Question", "Question=answer"} {
+		if _, err := ParseMapping(s); err == nil {
+			t.Errorf("%q was accepted", s)
+		}
+	}
+}
+
+func TestReadListHasUniqueSlugs(t *testing.T) {
+	lists, err := Lists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, list := range lists {
+		rows, err := ReadList(list.Name)
+		if err != nil {
+			t.Fatalf("%s: %v", list.Name, err)
+		}
+		seen := make(map[string]int)
+		for _, row := range rows {
+			problem := row.Problem
+			problem.Confidence_level = 1
+			if row.Err != nil {
+				t.Errorf("%s row %d: %v", list.Name, row.Number, row.Err)
+				continue
+			}
+			if err := problem.Preprocess(); err != nil {
+				t.Errorf("%s row %d: %v", list.Name, row.Number, err)
+				continue
+			}
+			if first, ok := seen[problem.Problem_id]; ok {
+				t.Errorf("%s rows %d and %d are both %s", list.Name, first, row.Number, problem.Problem_id)
+			}
+			seen[problem.Problem_id] = row.Number
+		}
+	}
+	if _, err := ReadList("nope"); err == nil {
+		t.Error("an unknown list was read")
+	}
+}
@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownList is returned by ReadList for a name that is not a built-in list
+var ErrUnknownList = errors.New("unknown problem list")
+
+//go:embed lists/*.csv
+var listFiles embed.FS
+
+// List describes a built-in problem list, Name is what the list is imported by
+type List struct {
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	Problems int    `json:"problems"`
+}
+
+// lists are the built-in lists, each is read from lists/{Name}.csv
+var lists = []List{
+	{Name: "blind75", Title: "Blind 75"},
+	{Name: "neetcode150", Title: "NeetCode 150"},
+	{Name: "grind75", Title: "Grind 75"},
+}
+
+// Lists returns the built-in problem lists with their number of problems
+func Lists() ([]List, error) {
+	result := make([]List, len(lists))
+	for i, list := range lists {
+		rows, err := ReadList(list.Name)
+		if err != nil {
+			return nil, err
+		}
+		list.Problems = len(rows)
+		result[i] = list
+	}
+	return result, nil
+}
+
+// ReadList reads the problems of a built-in list, they carry no confidence level
+func ReadList(name string) ([]Row, error) {
+	for _, list := range lists {
+		if list.Name != name {
+			continue
+		}
+		file, err := listFiles.Open("lists/" + name + ".csv")
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return ReadCSV(file, nil)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownList, name)
+}
@@ -0,0 +1,241 @@
+// Package leetcode talks to the LeetCode API on behalf of a user, identified by the session
+// cookie the frontend forwards in the X-LeetCode-Cookie header. Both leetcode.com and
+// leetcode.cn are supported, see Region.
+package leetcode
+
+import (
+	"bytes"
+	"context"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultBaseURL is the LeetCode site the client talks to unless configured otherwise
+const DefaultBaseURL = "https://leetcode.com"
+
+// PageSize is the number of submissions LeetCode returns per page
+const PageSize = 20
+
+// LeetCodeClient is what the handlers need from LeetCode, tests swap in a client pointed at
+// a leetcodetest.Server
+type LeetCodeClient interface {
+	// FetchSubmissions returns the latest submissions of the user the cookie belongs to,
+	// newest first. Failures wrap ErrCookieExpired, ErrRateLimited, ErrUpstreamDown or
+	// ErrBadResponse, or the error of ctx.
+	FetchSubmissions(ctx context.Context, cookie string, limit int) ([]models.LeetCodeSubmission, error)
+	// FetchSubmissionsSince is FetchSubmissions that stops at the first submission with an ID
+	// of sinceID or lower, only the newer ones are returned
+	FetchSubmissionsSince(ctx context.Context, cookie string, sinceID int64, limit int) ([]models.LeetCodeSubmission, error)
+	// FetchSubmissionsBetween is FetchSubmissionsSince that also passes over the submissions
+	// with an ID of beforeID or higher, they do not count towards the limit. A beforeID of 0
+	// passes over nothing.
+	FetchSubmissionsBetween(ctx context.Context, cookie string, sinceID int64, beforeID int64, limit int) ([]models.LeetCodeSubmission, error)
+	// FetchProblem returns the metadata of the problem with the given slug, or
+	// ErrProblemNotFound. The cookie may be empty, premium problems only come with their
+	// content for a premium session.
+	FetchProblem(ctx context.Context, cookie string, slug string) (models.ProblemMetadata, error)
+}
+
+// Client is the LeetCodeClient backed by the LeetCode REST API. Requests of each user go
+// through a token bucket and failed ones are retried with Retry.
+type Client struct {
+	region     Region
+	baseURL    string
+	httpClient *http.Client
+	// Retry decides how transient failures are retried
+	Retry RetryPolicy
+	// Limiter paces the requests of each user
+	Limiter *Limiter
+}
+
+// NewClient returns a client for the leetcode.com site at baseURL, DefaultBaseURL when empty.
+// A nil httpClient uses http.DefaultClient.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	return NewRegionClient(RegionGlobal, baseURL, httpClient)
+}
+
+// NewRegionClient is NewClient for the site of region, baseURL defaults to region.BaseURL()
+func NewRegionClient(region Region, baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = region.BaseURL()
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		region:     region,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+		Retry:      DefaultRetryPolicy,
+		Limiter:    NewLimiter(DefaultRequestsPerSecond, DefaultBurst),
+	}
+}
+
+// submissionsPage is the body of /api/submissions/. LeetCode answers some logged out
+// requests with a 200 and only a detail message, Submissions is nil then.
+type submissionsPage struct {
+	Submissions []models.LeetCodeSubmission `json:"submissions_dump"`
+	HasNext     bool                        `json:"has_next"`
+	Detail      string                      `json:"detail"`
+}
+
+func (c *Client) FetchSubmissions(ctx context.Context, cookie string, limit int) ([]models.LeetCodeSubmission, error) {
+	return c.FetchSubmissionsSince(ctx, cookie, 0, limit)
+}
+
+func (c *Client) FetchSubmissionsSince(ctx context.Context, cookie string, sinceID int64, limit int) ([]models.LeetCodeSubmission, error) {
+	return c.FetchSubmissionsBetween(ctx, cookie, sinceID, 0, limit)
+}
+
+func (c *Client) FetchSubmissionsBetween(ctx context.Context, cookie string, sinceID int64, beforeID int64, limit int) ([]models.LeetCodeSubmission, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	submissions := make([]models.LeetCodeSubmission, 0, min(limit, PageSize))
+	for offset := 0; len(submissions) < limit; {
+		size := min(PageSize, limit-len(submissions))
+		if beforeID > 0 {
+			// the submissions passed over do not fill the limit, full pages get past them sooner
+			size = PageSize
+		}
+		page, err := c.fetchSubmissionsPage(ctx, cookie, offset, size)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range page.Submissions {
+			if s.ID <= sinceID {
+				return submissions, nil
+			}
+			if beforeID > 0 && s.ID >= beforeID {
+				continue
+			}
+			// a submission made while paging shifts the offsets, the next page repeats the
+			// last submissions of the previous one
+			if n := len(submissions); n > 0 && s.ID >= submissions[n-1].ID {
+				continue
+			}
+			submissions = append(submissions, s)
+			if len(submissions) == limit {
+				return submissions, nil
+			}
+		}
+		if !page.HasNext {
+			break
+		}
+		offset += size
+	}
+	return submissions, nil
+}
+
+func (c *Client) fetchSubmissionsPage(ctx context.Context, cookie string, offset int, limit int) (submissionsPage, error) {
+	query := url.Values{}
+	query.Set("offset", fmt.Sprint(offset))
+	query.Set("limit", fmt.Sprint(limit))
+	var page submissionsPage
+	err := c.get(ctx, cookie, "/api/submissions/?"+query.Encode(), func(body io.Reader) error {
+		if err := c.decodeSubmissionsPage(body, &page); err != nil {
+			return fmt.Errorf("%w: decoding submissions: %v", ErrBadResponse, err)
+		}
+		if page.Submissions == nil {
+			if page.Detail != "" {
+				return fmt.Errorf("%w: %s", ErrCookieExpired, page.Detail)
+			}
+			return fmt.Errorf("%w: no submissions_dump in response", ErrBadResponse)
+		}
+		return nil
+	})
+	return page, err
+}
+
+// decodeSubmissionsPage reads a page in the JSON of the site of c into page
+func (c *Client) decodeSubmissionsPage(body io.Reader, page *submissionsPage) error {
+	if c.region != RegionCN {
+		return json.NewDecoder(body).Decode(page)
+	}
+	var cnPage cnSubmissionsPage
+	if err := json.NewDecoder(body).Decode(&cnPage); err != nil {
+		return err
+	}
+	*page = cnPage.normalize()
+	return nil
+}
+
+// get requests path for the user of cookie and hands a successful body to decode, retrying
+// the failures Retry allows
+func (c *Client) get(ctx context.Context, cookie string, path string, decode func(io.Reader) error) error {
+	return c.send(ctx, cookie, http.MethodGet, path, nil, decode)
+}
+
+// post is get with a JSON body
+func (c *Client) post(ctx context.Context, cookie string, path string, body any, decode func(io.Reader) error) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error encoding request: %w", err)
+	}
+	return c.send(ctx, cookie, http.MethodPost, path, payload, decode)
+}
+
+func (c *Client) send(ctx context.Context, cookie string, method string, path string, body []byte, decode func(io.Reader) error) error {
+	key, err := limiterKey(ctx, cookie)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		if err := c.Limiter.Wait(ctx, key); err != nil {
+			return err
+		}
+		err := c.do(ctx, cookie, method, path, body, decode)
+		if err == nil {
+			return nil
+		}
+		delay, retry := c.Retry.next(attempt, err)
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// do sends a single request
+func (c *Client) do(ctx context.Context, cookie string, method string, path string, body []byte, decode func(io.Reader) error) error {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	req.Header.Set("Referer", c.baseURL+"/")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		// LeetCode checks the CSRF token of logged in POST requests against the csrftoken cookie
+		if token := cookieValue(cookie, "csrftoken"); token != "" {
+			req.Header.Set("X-CSRFToken", token)
+		}
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %v", ErrUpstreamDown, err)
+	}
+	defer resp.Body.Close()
+	if err := statusError(resp); err != nil {
+		// drain what is left so the connection can be reused
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return err
+	}
+	return decode(resp.Body)
+}
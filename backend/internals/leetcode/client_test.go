@@ -0,0 +1,138 @@
+package leetcode_test
+
+import (
+	"context"
+	"dsa-helper-backend/internals/leetcode"
+	"dsa-helper-backend/internals/leetcode/leetcodetest"
+	"errors"
+	"testing"
+)
+
+// newTestClient starts a fake site and returns a client of it that neither paces nor backs off
+func newTestClient(t *testing.T, newServer func() *leetcodetest.Server) (*leetcode.Client, *leetcodetest.Server) {
+	t.Helper()
+	site := newServer()
+	t.Cleanup(site.Close)
+	client := site.LeetCodeClient()
+	client.Limiter = leetcode.NewLimiter(1000, 1000)
+	client.Retry.BaseDelay = 0
+	return client, site
+}
+
+func TestFetchSubmissions(t *testing.T) {
+	client, site := newTestClient(t, leetcodetest.NewServer)
+	cookie := site.AddUser("li", leetcodetest.Submissions(45))
+	tests := []struct {
+		name     string
+		sinceID  int64
+		beforeID int64
+		limit    int
+		ids      []int64
+		requests int
+	}{
+		{"first page", 0, 0, 5, []int64{45, 41}, 1},
+		{"every page", 0, 0, 100, []int64{45, 1}, 3},
+		{"limit across pages", 0, 0, 30, []int64{45, 16}, 2},
+		{"since", 40, 0, 100, []int64{45, 41}, 1},
+		{"since on a later page", 10, 0, 100, []int64{45, 11}, 2},
+		{"nothing new", 45, 0, 100, nil, 1},
+		{"no limit", 0, 0, 0, nil, 0},
+		{"before", 0, 30, 5, []int64{29, 25}, 2},
+		{"between", 10, 30, 100, []int64{29, 11}, 2},
+		{"nothing between", 29, 30, 100, nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := site.Requests()
+			submissions, err := client.FetchSubmissionsBetween(context.Background(), cookie, tt.sinceID, tt.beforeID, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if requests := site.Requests() - before; requests != tt.requests {
+				t.Errorf("%d requests, want %d", requests, tt.requests)
+			}
+			if tt.ids == nil {
+				if len(submissions) != 0 {
+					t.Fatalf("got %d submissions, want none", len(submissions))
+				}
+				return
+			}
+			first, last := tt.ids[0], tt.ids[1]
+			if len(submissions) != int(first-last+1) || submissions[0].ID != first || submissions[len(submissions)-1].ID != last {
+				t.Fatalf("got %d submissions, want %d down to %d", len(submissions), first, last)
+			}
+			for i := 1; i < len(submissions); i++ {
+				if submissions[i].ID >= submissions[i-1].ID {
+					t.Fatalf("submission %d is not older than the one before", submissions[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchSubmissionsCN(t *testing.T) {
+	client, site := newTestClient(t, leetcodetest.NewCNServer)
+	want := leetcodetest.Submissions(25)
+	cookie := site.AddUser("li", want)
+	submissions, err := client.FetchSubmissions(context.Background(), cookie, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submissions) != len(want) {
+		t.Fatalf("got %d submissions, want %d", len(submissions), len(want))
+	}
+	for i, s := range submissions {
+		w := want[i]
+		if s.ID != w.ID || s.QuestionID != w.QuestionID || s.Timestamp != w.Timestamp || s.Title != w.Title || s.TitleSlug != w.TitleSlug {
+			t.Errorf("submission %d: got %+v, want %+v", i, s, w)
+		}
+		// leetcode.cn leaves out lang_name, the lang stands in for it
+		if s.LangName != w.Lang {
+			t.Errorf("submission %d has lang_name %q, want %q", s.ID, s.LangName, w.Lang)
+		}
+	}
+}
+
+func TestFetchSubmissionsErrors(t *testing.T) {
+	client, site := newTestClient(t, leetcodetest.NewServer)
+	cookie := site.AddUser("li", leetcodetest.Submissions(3))
+	if _, err := client.FetchSubmissions(context.Background(), "LEETCODE_SESSION=unknown", 20); !errors.Is(err, leetcode.ErrCookieExpired) {
+		t.Errorf("unknown session: %v, want ErrCookieExpired", err)
+	}
+	if _, err := client.FetchSubmissions(leetcode.WithUser(context.Background(), "u"), "", 20); !errors.Is(err, leetcode.ErrCookieExpired) {
+		t.Errorf("no cookie: %v, want ErrCookieExpired", err)
+	}
+	site.SetMalformed(true)
+	if _, err := client.FetchSubmissions(context.Background(), cookie, 20); !errors.Is(err, leetcode.ErrBadResponse) {
+		t.Errorf("malformed page: %v, want ErrBadResponse", err)
+	}
+	site.SetMalformed(false)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.FetchSubmissions(ctx, cookie, 20); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled: %v, want context.Canceled", err)
+	}
+}
+
+func TestFetchProblem(t *testing.T) {
+	client, site := newTestClient(t, leetcodetest.NewServer)
+	want := leetcodetest.Problems()[0]
+	// problems are public, the user alone is enough
+	ctx := leetcode.WithUser(context.Background(), "u")
+	meta, err := client.FetchProblem(ctx, "", want.Slug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Slug != want.Slug || meta.Title != want.Title || meta.Difficulty != want.Difficulty || meta.QuestionID != want.QuestionID ||
+		len(meta.TopicTags) != len(want.TopicTags) || meta.AcceptanceRate != want.AcceptanceRate ||
+		len(meta.SimilarQuestions) != 1 || meta.SimilarQuestions[0].Slug != want.SimilarQuestions[0].Slug {
+		t.Errorf("got %+v, want %+v", meta, want)
+	}
+	if _, err := client.FetchProblem(ctx, "", "no-such-problem"); !errors.Is(err, leetcode.ErrProblemNotFound) {
+		t.Errorf("unknown slug: %v, want ErrProblemNotFound", err)
+	}
+	site.SetMalformed(true)
+	if _, err := client.FetchProblem(ctx, "", want.Slug); !errors.Is(err, leetcode.ErrBadResponse) {
+		t.Errorf("malformed answer: %v, want ErrBadResponse", err)
+	}
+}
@@ -0,0 +1,90 @@
+package leetcode
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrCookieExpired is returned when LeetCode does not accept the session cookie, the user
+	// has to log in again and send a fresh one
+	ErrCookieExpired = errors.New("leetcode session cookie is missing or expired")
+	// ErrRateLimited is returned when LeetCode still throttles the requests after the retries
+	ErrRateLimited = errors.New("rate limited by leetcode")
+	// ErrUpstreamDown is returned when LeetCode cannot be reached or keeps failing
+	ErrUpstreamDown = errors.New("leetcode is unavailable")
+	// ErrBadResponse is returned for a payload that cannot be read
+	ErrBadResponse = errors.New("unexpected response from leetcode")
+	// ErrProblemNotFound is returned when LeetCode has no problem with the requested slug
+	ErrProblemNotFound = errors.New("leetcode problem not found")
+	// ErrUnknownRegion is returned by ParseRegion for a site that is not supported
+	ErrUnknownRegion = errors.New("unknown leetcode region")
+	// ErrNoUser is returned for a call made without a user marked by WithUser nor a
+	// LEETCODE_SESSION, the limiter has nobody to count it for
+	ErrNoUser = errors.New("leetcode call without a user or a session")
+)
+
+// RateLimitError is a 429 answer, it matches ErrRateLimited with errors.Is
+type RateLimitError struct {
+	// RetryAfter is how long LeetCode asked to wait, 0 when it did not say
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%v, retry after %v", ErrRateLimited, e.RetryAfter)
+	}
+	return ErrRateLimited.Error()
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// UpstreamError is a 5xx answer, it matches ErrUpstreamDown with errors.Is
+type UpstreamError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("%v: status %d", ErrUpstreamDown, e.StatusCode)
+}
+
+func (e *UpstreamError) Is(target error) bool {
+	return target == ErrUpstreamDown
+}
+
+// statusError maps the status of a LeetCode response to the errors of this package, nil for a 2xx
+func statusError(resp *http.Response) error {
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: status %d", ErrCookieExpired, resp.StatusCode)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	case resp.StatusCode >= 500:
+		return &UpstreamError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	default:
+		return fmt.Errorf("%w: status %d", ErrBadResponse, resp.StatusCode)
+	}
+}
+
+// retryAfter reads a Retry-After header given in seconds or as an HTTP date, 0 when it is
+// missing or malformed
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package leetcode
+
+import (
+	"context"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// graphqlRequest is the body of a POST to /graphql/
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// graphqlError is one entry of the errors LeetCode answers a failed query with
+type graphqlError struct {
+	Message string `json:"message"`
+}
+
+const questionQuery = `query questionData($titleSlug: String!) {
+  question(titleSlug: $titleSlug) {
+    questionFrontendId
+    title
+    titleSlug
+    content
+    difficulty
+    isPaidOnly
+    stats
+    similarQuestions
+    topicTags {
+      name
+      slug
+    }
+  }
+}`
+
+// question is the question field of questionQuery. LeetCode sends stats and
+// similarQuestions as JSON encoded strings.
+type question struct {
+	QuestionFrontendID string `json:"questionFrontendId"`
+	Title              string `json:"title"`
+	TitleSlug          string `json:"titleSlug"`
+	Content            string `json:"content"`
+	Difficulty         string `json:"difficulty"`
+	IsPaidOnly         bool   `json:"isPaidOnly"`
+	Stats              string `json:"stats"`
+	SimilarQuestions   string `json:"similarQuestions"`
+	TopicTags          []struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	} `json:"topicTags"`
+}
+
+type questionResponse struct {
+	Data struct {
+		Question *question `json:"question"`
+	} `json:"data"`
+	Errors []graphqlError `json:"errors"`
+}
+
+func (c *Client) FetchProblem(ctx context.Context, cookie string, slug string) (models.ProblemMetadata, error) {
+	var resp questionResponse
+	req := graphqlRequest{Query: questionQuery, Variables: map[string]any{"titleSlug": slug}, OperationName: "questionData"}
+	err := c.post(ctx, cookie, "/graphql/", req, func(body io.Reader) error {
+		if err := json.NewDecoder(body).Decode(&resp); err != nil {
+			return fmt.Errorf("%w: decoding question: %v", ErrBadResponse, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.ProblemMetadata{}, err
+	}
+	if resp.Data.Question == nil {
+		if len(resp.Errors) > 0 {
+			// LeetCode reports an unknown slug as an error rather than a null question
+			if strings.Contains(strings.ToLower(resp.Errors[0].Message), "does not exist") {
+				return models.ProblemMetadata{}, fmt.Errorf("%w: %s", ErrProblemNotFound, slug)
+			}
+			return models.ProblemMetadata{}, fmt.Errorf("%w: %s", ErrBadResponse, resp.Errors[0].Message)
+		}
+		return models.ProblemMetadata{}, fmt.Errorf("%w: %s", ErrProblemNotFound, slug)
+	}
+	return resp.Data.Question.metadata(), nil
+}
+
+// metadata converts q, stats and similar questions that cannot be read are left out
+func (q *question) metadata() models.ProblemMetadata {
+	meta := models.ProblemMetadata{
+		Slug:             q.TitleSlug,
+		QuestionID:       q.QuestionFrontendID,
+		Title:            q.Title,
+		Content:          q.Content,
+		Difficulty:       q.Difficulty,
+		TopicTags:        make([]string, 0, len(q.TopicTags)),
+		SimilarQuestions: []models.SimilarQuestion{},
+		IsPaidOnly:       q.IsPaidOnly,
+		FetchedAt:        time.Now().UTC(),
+	}
+	for _, tag := range q.TopicTags {
+		meta.TopicTags = append(meta.TopicTags, tag.Name)
+	}
+	var stats struct {
+		ACRate string `json:"acRate"`
+	}
+	if json.Unmarshal([]byte(q.Stats), &stats) == nil {
+		meta.AcceptanceRate, _ = strconv.ParseFloat(strings.TrimSuffix(stats.ACRate, "%"), 64)
+	}
+	var similar []struct {
+		Title      string `json:"title"`
+		TitleSlug  string `json:"titleSlug"`
+		Difficulty string `json:"difficulty"`
+	}
+	if json.Unmarshal([]byte(q.SimilarQuestions), &similar) == nil {
+		for _, s := range similar {
+			meta.SimilarQuestions = append(meta.SimilarQuestions, models.SimilarQuestion{
+				Title:      s.Title,
+				Slug:       s.TitleSlug,
+				Difficulty: s.Difficulty,
+			})
+		}
+	}
+	return meta
+}
@@ -0,0 +1,371 @@
+// Package leetcodetest provides a fake LeetCode site for tests, in the spirit of net/http/httptest.
+// It serves canned submission pages to known session cookies, the error LeetCode answers
+// logged out requests with, problem metadata over GraphQL, and malformed payloads and error
+// statuses on demand. NewCNServer speaks the submission JSON of leetcode.cn instead.
+package leetcodetest
+
+import (
+	"dsa-helper-backend/internals/leetcode"
+	"dsa-helper-backend/internals/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SessionCookie is the cookie LeetCode identifies a user by
+const SessionCookie = "LEETCODE_SESSION"
+
+// Server is a fake LeetCode site. Submissions are served newest first, the way LeetCode does.
+type Server struct {
+	*httptest.Server
+	region leetcode.Region
+
+	mu sync.Mutex
+	// users maps a session to the submissions of its user
+	users map[string][]models.LeetCodeSubmission
+	// problems maps a slug to the metadata served for it
+	problems  map[string]models.ProblemMetadata
+	malformed bool
+	requests  int
+	// failures are the statuses the next requests fail with, with their Retry-After
+	failures []failure
+}
+
+type failure struct {
+	status     int
+	retryAfter string
+}
+
+// NewServer starts a fake leetcode.com, it is stopped with Close
+func NewServer() *Server {
+	return newServer(leetcode.RegionGlobal)
+}
+
+// NewCNServer starts a fake leetcode.cn, it is stopped with Close
+func NewCNServer() *Server {
+	return newServer(leetcode.RegionCN)
+}
+
+func newServer(region leetcode.Region) *Server {
+	s := &Server{
+		region:   region,
+		users:    map[string][]models.LeetCodeSubmission{},
+		problems: map[string]models.ProblemMetadata{},
+	}
+	for _, p := range Problems() {
+		s.problems[p.Slug] = p
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/submissions/", s.handleSubmissions)
+	mux.HandleFunc("POST /graphql/", s.handleGraphQL)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// LeetCodeClient returns a client talking to the fake site
+func (s *Server) LeetCodeClient() *leetcode.Client {
+	return leetcode.NewRegionClient(s.region, s.URL, s.Client())
+}
+
+// AddUser registers a session with its submissions, newest first, and returns the Cookie
+// header that logs in as that user
+func (s *Server) AddUser(session string, submissions []models.LeetCodeSubmission) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[session] = submissions
+	return SessionCookie + "=" + session + "; csrftoken=fake"
+}
+
+// AddProblem serves meta for its slug, the canned problems of Submissions are served from the start
+func (s *Server) AddProblem(meta models.ProblemMetadata) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.problems[meta.Slug] = meta
+}
+
+// SetMalformed makes every following response a truncated JSON body
+func (s *Server) SetMalformed(malformed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.malformed = malformed
+}
+
+// FailNext makes the next n requests fail with status, sending retryAfter as the Retry-After
+// header unless it is empty
+func (s *Server) FailNext(n int, status int, retryAfter string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for range n {
+		s.failures = append(s.failures, failure{status: status, retryAfter: retryAfter})
+	}
+}
+
+// Requests returns the number of requests served so far
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// submissionsPage is the body of /api/submissions/
+type submissionsPage struct {
+	Submissions []models.LeetCodeSubmission `json:"submissions_dump"`
+	HasNext     bool                        `json:"has_next"`
+	LastKey     string                      `json:"last_key"`
+}
+
+func (s *Server) handleSubmissions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	fail := s.nextFailure()
+	malformed := s.malformed
+	var submissions []models.LeetCodeSubmission
+	known := false
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		submissions, known = s.users[cookie.Value]
+	}
+	s.mu.Unlock()
+
+	if writeFailure(w, fail) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if malformed {
+		fmt.Fprint(w, `{"submissions_dump": [{"id": "not a number", "title": `)
+		return
+	}
+	if !known {
+		// LeetCode answers a missing or expired session like this
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"detail": "Authentication credentials were not provided."}`)
+		return
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > leetcode.PageSize {
+		limit = leetcode.PageSize
+	}
+	offset = min(max(offset, 0), len(submissions))
+	end := min(offset+limit, len(submissions))
+	page := submissionsPage{Submissions: submissions[offset:end], HasNext: end < len(submissions)}
+	if page.Submissions == nil {
+		page.Submissions = []models.LeetCodeSubmission{}
+	}
+	if s.region == leetcode.RegionCN {
+		json.NewEncoder(w).Encode(cnPage(page))
+		return
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+// cnSubmission is a submission in the JSON of leetcode.cn, with string ids and timestamps, a
+// translated title and no lang_name
+type cnSubmission struct {
+	ID              string `json:"id"`
+	QuestionID      string `json:"question_id"`
+	Title           string `json:"title"`
+	TranslatedTitle string `json:"translated_title"`
+	TitleSlug       string `json:"title_slug"`
+	Code            string `json:"code"`
+	Lang            string `json:"lang"`
+	Timestamp       string `json:"timestamp"`
+	StatusDisplay   string `json:"status_display"`
+	Runtime         string `json:"runtime"`
+	URL             string `json:"url"`
+	IsPending       string `json:"is_pending"`
+	Memory          string `json:"memory"`
+}
+
+type cnSubmissionsPage struct {
+	Submissions []cnSubmission `json:"submissions_dump"`
+	HasNext     bool           `json:"has_next"`
+	LastKey     string         `json:"last_key"`
+}
+
+func cnPage(page submissionsPage) cnSubmissionsPage {
+	cn := cnSubmissionsPage{Submissions: make([]cnSubmission, len(page.Submissions)), HasNext: page.HasNext, LastKey: page.LastKey}
+	for i, sub := range page.Submissions {
+		cn.Submissions[i] = cnSubmission{
+			ID:              strconv.FormatInt(sub.ID, 10),
+			QuestionID:      strconv.FormatInt(sub.QuestionID, 10),
+			Title:           sub.Title,
+			TranslatedTitle: translatedTitles[sub.TitleSlug],
+			TitleSlug:       sub.TitleSlug,
+			Code:            sub.Code,
+			Lang:            sub.Lang,
+			Timestamp:       strconv.FormatInt(sub.Timestamp, 10),
+			StatusDisplay:   sub.StatusDisplay,
+			Runtime:         sub.Runtime,
+			URL:             sub.URL,
+			IsPending:       sub.IsPending,
+			Memory:          sub.Memory,
+		}
+	}
+	return cn
+}
+
+// translatedTitles are the leetcode.cn titles of the canned problems
+var translatedTitles = map[string]string{
+	"two-sum":                "两数之和",
+	"valid-parentheses":      "有效的括号",
+	"merge-two-sorted-lists": "合并两个有序链表",
+	"climbing-stairs":        "爬楼梯",
+	"number-of-islands":      "岛屿数量",
+	"coin-change":            "零钱兑换",
+}
+
+// nextFailure returns the failure the next request should answer with and counts the request,
+// it is nil when the request should succeed
+func (s *Server) nextFailure() *failure {
+	s.requests++
+	if len(s.failures) == 0 {
+		return nil
+	}
+	fail := &s.failures[0]
+	s.failures = s.failures[1:]
+	return fail
+}
+
+// writeFailure answers with fail, it reports false when there is none
+func writeFailure(w http.ResponseWriter, fail *failure) bool {
+	if fail == nil {
+		return false
+	}
+	if fail.retryAfter != "" {
+		w.Header().Set("Retry-After", fail.retryAfter)
+	}
+	http.Error(w, http.StatusText(fail.status), fail.status)
+	return true
+}
+
+// handleGraphQL answers the question query of the client, it ignores which fields are asked for
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Variables struct {
+			TitleSlug string `json:"titleSlug"`
+		} `json:"variables"`
+	}
+	decodeErr := json.NewDecoder(r.Body).Decode(&req)
+	s.mu.Lock()
+	fail := s.nextFailure()
+	malformed := s.malformed
+	meta, known := s.problems[req.Variables.TitleSlug]
+	s.mu.Unlock()
+
+	if writeFailure(w, fail) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if decodeErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"errors": [{"message": %q}]}`, decodeErr.Error())
+		return
+	}
+	if malformed {
+		fmt.Fprint(w, `{"data": {"question": {"title": `)
+		return
+	}
+	if !known {
+		fmt.Fprint(w, `{"data": {"question": null}}`)
+		return
+	}
+	type topicTag struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	}
+	type similarQuestion struct {
+		Title      string `json:"title"`
+		TitleSlug  string `json:"titleSlug"`
+		Difficulty string `json:"difficulty"`
+	}
+	tags := make([]topicTag, len(meta.TopicTags))
+	for i, name := range meta.TopicTags {
+		tags[i] = topicTag{Name: name, Slug: models.SlugifyTitle(name)}
+	}
+	similar := make([]similarQuestion, len(meta.SimilarQuestions))
+	for i, q := range meta.SimilarQuestions {
+		similar[i] = similarQuestion{Title: q.Title, TitleSlug: q.Slug, Difficulty: q.Difficulty}
+	}
+	// LeetCode sends stats and similarQuestions as JSON encoded strings
+	stats, _ := json.Marshal(map[string]string{"acRate": fmt.Sprintf("%.1f%%", meta.AcceptanceRate)})
+	similarJSON, _ := json.Marshal(similar)
+	json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"question": map[string]any{
+		"questionFrontendId": meta.QuestionID,
+		"title":              meta.Title,
+		"titleSlug":          meta.Slug,
+		"content":            meta.Content,
+		"difficulty":         meta.Difficulty,
+		"isPaidOnly":         meta.IsPaidOnly,
+		"stats":              string(stats),
+		"similarQuestions":   string(similarJSON),
+		"topicTags":          tags,
+	}}})
+}
+
+// cannedProblems are the problems Submissions cycles through
+var cannedProblems = []struct {
+	questionID int64
+	title      string
+	slug       string
+	difficulty string
+	tags       []string
+}{
+	{1, "Two Sum", "two-sum", "Easy", []string{"Array", "Hash Table"}},
+	{20, "Valid Parentheses", "valid-parentheses", "Easy", []string{"String", "Stack"}},
+	{21, "Merge Two Sorted Lists", "merge-two-sorted-lists", "Easy", []string{"Linked List", "Recursion"}},
+	{70, "Climbing Stairs", "climbing-stairs", "Easy", []string{"Math", "Dynamic Programming", "Memoization"}},
+	{200, "Number of Islands", "number-of-islands", "Medium", []string{"Array", "Depth-First Search", "Breadth-First Search", "Union Find", "Matrix"}},
+	{322, "Coin Change", "coin-change", "Medium", []string{"Array", "Dynamic Programming", "Breadth-First Search"}},
+}
+
+// Problems returns the metadata of the canned problems, each lists the next one as similar
+func Problems() []models.ProblemMetadata {
+	problems := make([]models.ProblemMetadata, len(cannedProblems))
+	for i, p := range cannedProblems {
+		next := cannedProblems[(i+1)%len(cannedProblems)]
+		problems[i] = models.ProblemMetadata{
+			Slug:             p.slug,
+			QuestionID:       strconv.FormatInt(p.questionID, 10),
+			Title:            p.title,
+			Content:          fmt.Sprintf("<p>Solve <strong>%s</strong>.</p>\n<p>&nbsp;</p>\n<p><strong>Constraints:</strong></p>\n<ul><li><code>1 &lt;= n &lt;= 10<sup>4</sup></code></li></ul>", p.title),
+			Difficulty:       p.difficulty,
+			TopicTags:        p.tags,
+			AcceptanceRate:   float64(40 + 5*i),
+			SimilarQuestions: []models.SimilarQuestion{{Title: next.title, Slug: next.slug, Difficulty: next.difficulty}},
+		}
+	}
+	return problems
+}
+
+// cannedStatuses are the verdicts Submissions cycles through, most are accepted
+var cannedStatuses = []string{"Accepted", "Accepted", "Wrong Answer", "Accepted", "Time Limit Exceeded"}
+
+// Submissions returns n canned submissions, newest first, with IDs from n down to 1 and one
+// submission per hour ending at the current hour
+func Submissions(n int) []models.LeetCodeSubmission {
+	latest := time.Now().Truncate(time.Hour)
+	submissions := make([]models.LeetCodeSubmission, n)
+	for i := range submissions {
+		id := int64(n - i)
+		problem := cannedProblems[int(id)%len(cannedProblems)]
+		submissions[i] = models.LeetCodeSubmission{
+			ID:            id,
+			QuestionID:    problem.questionID,
+			Title:         problem.title,
+			TitleSlug:     problem.slug,
+			Code:          "class Solution:\n    def solve(self):\n        pass\n",
+			Lang:          "python3",
+			LangName:      "Python3",
+			Timestamp:     latest.Add(-time.Duration(i) * time.Hour).Unix(),
+			StatusDisplay: cannedStatuses[int(id)%len(cannedStatuses)],
+			Runtime:       "40 ms",
+			URL:           fmt.Sprintf("/submissions/detail/%d/", id),
+			IsPending:     "Not Pending",
+			Memory:        "16.5 MB",
+		}
+	}
+	return submissions
+}
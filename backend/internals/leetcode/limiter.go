@@ -0,0 +1,92 @@
+package leetcode
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Defaults of the limiter NewClient sets up, about the pace of the LeetCode web app
+const (
+	DefaultRequestsPerSecond = 2
+	DefaultBurst             = 3
+)
+
+// limiterIdle is how long an unused bucket is kept, a bucket idle that long is full again anyway
+const limiterIdle = 10 * time.Minute
+
+// Limiter keeps a token bucket per user so one user syncing a long history does not get
+// every user of the server throttled by LeetCode
+type Limiter struct {
+	limit rate.Limit
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	used    time.Time
+}
+
+// NewLimiter allows each user perSecond requests on average and burst at once
+func NewLimiter(perSecond float64, burst int) *Limiter {
+	return &Limiter{limit: rate.Limit(perSecond), burst: burst, buckets: map[string]*bucket{}}
+}
+
+// Wait blocks until the user identified by key may send a request, or ctx is done
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	now := time.Now()
+	l.mu.Lock()
+	if now.Sub(l.swept) > limiterIdle {
+		for k, b := range l.buckets {
+			if now.Sub(b.used) > limiterIdle {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = b
+	}
+	b.used = now
+	l.mu.Unlock()
+	return b.limiter.Wait(ctx)
+}
+
+type userKey struct{}
+
+// WithUser returns ctx marked with the signed in user the LeetCode calls made with it are
+// for, the limiter paces each user on their own whatever cookie they send
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// limiterKey picks the bucket of a call: the user of ctx, or the LEETCODE_SESSION of the
+// cookie for calls made outside a request. A call with neither would share one bucket with
+// every other such call, it is refused with ErrNoUser.
+func limiterKey(ctx context.Context, cookie string) (string, error) {
+	if userID, _ := ctx.Value(userKey{}).(string); userID != "" {
+		return "user:" + userID, nil
+	}
+	if session := cookieValue(cookie, "LEETCODE_SESSION"); session != "" {
+		return "session:" + session, nil
+	}
+	return "", ErrNoUser
+}
+
+// cookieValue returns the value of the named cookie in a Cookie header, empty when missing
+func cookieValue(cookie string, name string) string {
+	header := http.Header{"Cookie": {cookie}}
+	if c, err := (&http.Request{Header: header}).Cookie(name); err == nil {
+		return c.Value
+	}
+	return ""
+}
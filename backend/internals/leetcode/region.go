@@ -0,0 +1,117 @@
+package leetcode
+
+import (
+	"cmp"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Region is a LeetCode site. Accounts do not carry over between sites, each has its own host,
+// session cookies and submission JSON.
+type Region string
+
+const (
+	// RegionGlobal is leetcode.com
+	RegionGlobal Region = "com"
+	// RegionCN is leetcode.cn, LeetCode China
+	RegionCN Region = "cn"
+)
+
+// CNBaseURL is the leetcode.cn site a RegionCN client talks to unless configured otherwise
+const CNBaseURL = "https://leetcode.cn"
+
+// ParseRegion reads a region setting, "com" or "cn". Empty is RegionGlobal, the host names
+// are accepted too.
+func ParseRegion(value string) (Region, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "com", "leetcode.com":
+		return RegionGlobal, nil
+	case "cn", "leetcode.cn":
+		return RegionCN, nil
+	}
+	return "", fmt.Errorf("%w %q, expected com or cn", ErrUnknownRegion, value)
+}
+
+// BaseURL returns the default address of the site
+func (r Region) BaseURL() string {
+	if r == RegionCN {
+		return CNBaseURL
+	}
+	return DefaultBaseURL
+}
+
+// cnSubmission is a submission as leetcode.cn sends it: ids and timestamps are strings, the
+// title comes along with its translation and lang_name may be missing
+type cnSubmission struct {
+	ID              flexInt `json:"id"`
+	QuestionID      flexInt `json:"question_id"`
+	Title           string  `json:"title"`
+	TranslatedTitle string  `json:"translated_title"`
+	TitleSlug       string  `json:"title_slug"`
+	Code            string  `json:"code"`
+	Lang            string  `json:"lang"`
+	LangName        string  `json:"lang_name"`
+	Timestamp       flexInt `json:"timestamp"`
+	StatusDisplay   string  `json:"status_display"`
+	Runtime         string  `json:"runtime"`
+	URL             string  `json:"url"`
+	IsPending       string  `json:"is_pending"`
+	Memory          string  `json:"memory"`
+}
+
+// submission normalizes s into the leetcode.com shape. The English title is kept so slugs,
+// revisions and the problem metadata cache are shared with leetcode.com.
+func (s cnSubmission) submission() models.LeetCodeSubmission {
+	return models.LeetCodeSubmission{
+		ID:            int64(s.ID),
+		QuestionID:    int64(s.QuestionID),
+		Title:         cmp.Or(s.Title, s.TranslatedTitle),
+		TitleSlug:     s.TitleSlug,
+		Code:          s.Code,
+		Lang:          s.Lang,
+		LangName:      cmp.Or(s.LangName, s.Lang),
+		Timestamp:     int64(s.Timestamp),
+		StatusDisplay: s.StatusDisplay,
+		Runtime:       s.Runtime,
+		URL:           s.URL,
+		IsPending:     s.IsPending,
+		Memory:        s.Memory,
+	}
+}
+
+// cnSubmissionsPage is submissionsPage as leetcode.cn sends it
+type cnSubmissionsPage struct {
+	Submissions []cnSubmission `json:"submissions_dump"`
+	HasNext     bool           `json:"has_next"`
+	Detail      string         `json:"detail"`
+}
+
+func (p cnSubmissionsPage) normalize() submissionsPage {
+	page := submissionsPage{HasNext: p.HasNext, Detail: p.Detail}
+	if p.Submissions != nil {
+		page.Submissions = make([]models.LeetCodeSubmission, len(p.Submissions))
+		for i, s := range p.Submissions {
+			page.Submissions[i] = s.submission()
+		}
+	}
+	return page
+}
+
+// flexInt is an integer sent either as a JSON number or as a string
+type flexInt int64
+
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*n = flexInt(v)
+	return nil
+}
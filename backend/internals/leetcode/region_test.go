@@ -0,0 +1,68 @@
+package leetcode
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFlexInt(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    flexInt
+		wantErr bool
+	}{
+		{`42`, 42, false},
+		{`"42"`, 42, false},
+		{`"-7"`, -7, false},
+		{`null`, 0, false},
+		{`""`, 0, false},
+		{`"1.5"`, 0, true},
+		{`"abc"`, 0, true},
+		{`true`, 0, true},
+	}
+	for _, tt := range tests {
+		var n flexInt
+		err := json.Unmarshal([]byte(tt.json), &n)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error %v, want error %v", tt.json, err, tt.wantErr)
+			continue
+		}
+		if n != tt.want {
+			t.Errorf("%s = %d, want %d", tt.json, n, tt.want)
+		}
+	}
+}
+
+func TestCNSubmissionsPageNormalize(t *testing.T) {
+	var cn cnSubmissionsPage
+	err := json.Unmarshal([]byte(`{
+		"submissions_dump": [
+			{"id": "1002", "question_id": "1", "title": "Two Sum", "translated_title": "两数之和", "title_slug": "two-sum",
+				"lang": "python3", "timestamp": "1767225600", "status_display": "Accepted"},
+			{"id": 1001, "question_id": 20, "title": "", "translated_title": "有效的括号", "title_slug": "valid-parentheses",
+				"lang": "cpp", "lang_name": "C++", "timestamp": 1767222000, "status_display": "Wrong Answer"}
+		],
+		"has_next": true
+	}`), &cn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := cn.normalize()
+	if !page.HasNext || len(page.Submissions) != 2 {
+		t.Fatalf("got %+v, want 2 submissions and a next page", page)
+	}
+	first, second := page.Submissions[0], page.Submissions[1]
+	if first.ID != 1002 || first.QuestionID != 1 || first.Timestamp != 1767225600 {
+		t.Errorf("numbers of the first submission: %+v", first)
+	}
+	if first.Title != "Two Sum" || first.LangName != "python3" {
+		t.Errorf("first submission has title %q and lang_name %q, want the English title and lang", first.Title, first.LangName)
+	}
+	if second.ID != 1001 || second.Title != "有效的括号" || second.LangName != "C++" {
+		t.Errorf("second submission has ID %d, title %q and lang_name %q, want the translated title and its own lang_name",
+			second.ID, second.Title, second.LangName)
+	}
+	if empty := (cnSubmissionsPage{}).normalize(); empty.Submissions != nil {
+		t.Errorf("page without submissions_dump normalized to %+v", empty.Submissions)
+	}
+}
@@ -0,0 +1,59 @@
+package leetcode
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy retries rate limited and failed requests with exponential backoff. A
+// Retry-After sent by LeetCode replaces the backoff delay, one longer than MaxDelay is not
+// waited for and the error is returned instead.
+type RetryPolicy struct {
+	// Retries is the number of retries after the first attempt
+	Retries   int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy waits about 0.5s, 1s and 2s before its three retries
+var DefaultRetryPolicy = RetryPolicy{Retries: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+// next returns how long to wait before retrying a request that failed with err on the given
+// attempt, counted from 0, and whether to retry at all
+func (p RetryPolicy) next(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.Retries {
+		return 0, false
+	}
+	var wait time.Duration
+	var rateLimited *RateLimitError
+	var upstream *UpstreamError
+	switch {
+	case errors.As(err, &rateLimited):
+		wait = rateLimited.RetryAfter
+	case errors.As(err, &upstream):
+		wait = upstream.RetryAfter
+	case errors.Is(err, ErrUpstreamDown):
+	default:
+		return 0, false
+	}
+	if wait > 0 {
+		return wait, wait <= p.MaxDelay
+	}
+	backoff := min(p.BaseDelay<<attempt, p.MaxDelay)
+	// half of the delay is jitter so users throttled together do not retry together
+	return backoff/2 + rand.N(backoff/2+1), true
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
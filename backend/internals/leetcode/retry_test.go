@@ -0,0 +1,133 @@
+package leetcode_test
+
+import (
+	"context"
+	"dsa-helper-backend/internals/leetcode"
+	"dsa-helper-backend/internals/leetcode/leetcodetest"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		status     int
+		retryAfter string
+		retries    int
+		maxDelay   time.Duration
+		// err is nil when the fetch eventually succeeds
+		err      error
+		requests int
+		// waited is the least time the retries took
+		waited time.Duration
+	}{
+		{"429 then success", 1, http.StatusTooManyRequests, "", 3, time.Second, nil, 2, 0},
+		{"429 waits for Retry-After", 1, http.StatusTooManyRequests, "1", 3, 2 * time.Second, nil, 2, time.Second},
+		{"429 Retry-After past MaxDelay", 1, http.StatusTooManyRequests, "120", 3, 2 * time.Second, leetcode.ErrRateLimited, 1, 0},
+		{"429 exhausts retries", 4, http.StatusTooManyRequests, "", 3, time.Second, leetcode.ErrRateLimited, 4, 0},
+		{"5xx then success", 2, http.StatusInternalServerError, "", 3, time.Second, nil, 3, 0},
+		{"5xx exhausts retries", 4, http.StatusServiceUnavailable, "", 3, time.Second, leetcode.ErrUpstreamDown, 4, 0},
+		{"5xx without retries", 1, http.StatusBadGateway, "", 0, time.Second, leetcode.ErrUpstreamDown, 1, 0},
+		{"404 is not retried", 1, http.StatusNotFound, "", 3, time.Second, leetcode.ErrBadResponse, 1, 0},
+		{"400 is not retried", 1, http.StatusBadRequest, "", 3, time.Second, leetcode.ErrBadResponse, 1, 0},
+		{"401 is not retried", 1, http.StatusUnauthorized, "", 3, time.Second, leetcode.ErrCookieExpired, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, site := newTestClient(t, leetcodetest.NewServer)
+			client.Retry.Retries = tt.retries
+			client.Retry.MaxDelay = tt.maxDelay
+			cookie := site.AddUser("li", leetcodetest.Submissions(3))
+			site.FailNext(tt.failures, tt.status, tt.retryAfter)
+			start := time.Now()
+			submissions, err := client.FetchSubmissions(context.Background(), cookie, 20)
+			if tt.err == nil && (err != nil || len(submissions) != 3) {
+				t.Fatalf("got %d submissions, %v", len(submissions), err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if site.Requests() != tt.requests {
+				t.Errorf("%d requests, want %d", site.Requests(), tt.requests)
+			}
+			if waited := time.Since(start); waited < tt.waited {
+				t.Errorf("retried after %v, want at least %v", waited, tt.waited)
+			}
+		})
+	}
+}
+
+func TestRateLimitErrorCarriesRetryAfter(t *testing.T) {
+	client, site := newTestClient(t, leetcodetest.NewServer)
+	cookie := site.AddUser("li", leetcodetest.Submissions(3))
+	site.FailNext(1, http.StatusTooManyRequests, "120")
+	_, err := client.FetchSubmissions(context.Background(), cookie, 20)
+	var rateLimited *leetcode.RateLimitError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 120*time.Second {
+		t.Fatalf("got %v, want a RateLimitError asking for 120s", err)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	client, site := newTestClient(t, leetcodetest.NewServer)
+	// one request per minute with a burst of one
+	client.Limiter = leetcode.NewLimiter(1.0/60, 1)
+	li := site.AddUser("li", leetcodetest.Submissions(3))
+	ana := site.AddUser("ana", leetcodetest.Submissions(3))
+	tests := []struct {
+		name     string
+		user     string
+		cookie   string
+		denied   bool
+		requests int
+	}{
+		{"first request", "", li, false, 1},
+		// the token comes back in a minute, past the deadline, so the limiter gives up at once
+		{"denied", "", li, true, 1},
+		{"same session in another cookie", "", "csrftoken=other; " + li, true, 1},
+		{"other session has its own bucket", "", ana, false, 2},
+		{"signed in user has their own bucket", "u", li, false, 3},
+		{"signed in user denied with another session", "u", ana, true, 3},
+		{"no user nor session", "", "csrftoken=other", true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if tt.user != "" {
+				ctx = leetcode.WithUser(ctx, tt.user)
+			}
+			start := time.Now()
+			_, err := client.FetchSubmissions(ctx, tt.cookie, 20)
+			if !tt.denied && err != nil {
+				t.Fatal(err)
+			}
+			if tt.denied {
+				if err == nil {
+					t.Fatal("the request was let through")
+				}
+				if time.Since(start) > time.Second {
+					t.Errorf("denial took %v", time.Since(start))
+				}
+			}
+			if site.Requests() != tt.requests {
+				t.Errorf("%d requests reached the site, want %d", site.Requests(), tt.requests)
+			}
+		})
+	}
+}
+
+func TestLimiterRefusesCallsWithoutUser(t *testing.T) {
+	client, site := newTestClient(t, leetcodetest.NewServer)
+	for _, cookie := range []string{"", "csrftoken=other", "LEETCODE_SESSION="} {
+		if _, err := client.FetchProblem(context.Background(), cookie, "two-sum"); !errors.Is(err, leetcode.ErrNoUser) {
+			t.Errorf("cookie %q: %v, want ErrNoUser", cookie, err)
+		}
+	}
+	if site.Requests() != 0 {
+		t.Errorf("%d requests reached the site, want none", site.Requests())
+	}
+}
@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"context"
+	"dsa-helper-backend/internals/leetcode"
 	"firebase.google.com/go/v4/auth"
 	"log"
 	"net/http"
@@ -67,6 +68,7 @@ func FirebaseAuthMiddleware(firebaseAuth *auth.Client) func(next http.Handler) h
 			log.Printf("Successfully verified ID token for user UID: %s\n", token.UID)
 			ctx := r.Context()
 			ctx = context.WithValue(ctx, UserIDContext, token.UID)
+			ctx = leetcode.WithUser(ctx, token.UID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -58,3 +58,10 @@ type DSAPatternAnalysisResponse struct {
 	LearningRecommendations []string `json:"learningRecommendations"`
 	CommonMistakesSummary   *string  `json:"commonMistakesSummary,omitempty"` // Use pointer for nullable
 }
+
+type LeechExplanationResponse struct {
+	ProblemId     string   `json:"problemId"`
+	MissedConcept string   `json:"missedConcept"`
+	Explanation   string   `json:"explanation"`
+	PracticeTips  []string `json:"practiceTips"`
+}
@@ -1,19 +1,171 @@
 package models
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 )
 
 type RevisionProblem struct {
 	LeetCodeSubmission
-	Notes            string   `json:"notes" firestore:"notes"`
-	Last_revised     string   `json:"last_revised" firestore:"last_revised"`
-	Next_revision    string   `json:"next_revision" firestore:"next_revision"`
-	Difficulty       string   `json:"difficulty" firestore:"difficulty"`
-	Confidence_level int      `json:"confidence_level" firestore:"confidence_level"`
-	Revision_count   int      `json:"revision_count" firestore:"revision_count"`
-	Tags             []string `json:"tags" firestore:"tags"`
+	// Problem_id is the LeetCode problem slug, it identifies the revision for its whole life
+	Problem_id string `json:"problem_id" firestore:"problem_id"`
+	Notes      string `json:"notes" firestore:"notes"`
+	// Last_revised is the day of the last review in the time zone of the user
+	Last_revised string `json:"last_revised" firestore:"last_revised"`
+	// Next_revision is the start of the day the problem is due, midnight in the time zone of the user
+	Next_revision    time.Time `json:"next_revision" firestore:"next_revision"`
+	Difficulty       string    `json:"difficulty" firestore:"difficulty"`
+	Confidence_level int       `json:"confidence_level" firestore:"confidence_level"`
+	Revision_count   int       `json:"revision_count" firestore:"revision_count"`
+	Tags             []string  `json:"tags" firestore:"tags"`
+	// Decks holds the IDs of the decks the problem belongs to
+	Decks []string `json:"decks" firestore:"decks"`
+	// Schedule is the state of the spaced repetition scheduler, maintained by the server
+	Schedule ScheduleState `json:"schedule" firestore:"schedule"`
+	// Failure_count counts the reviews graded below 3, past the threshold of the user the
+	// problem is a leech and goes back to short relearning intervals after every failure
+	Failure_count int  `json:"failure_count" firestore:"failure_count"`
+	Is_leech      bool `json:"is_leech" firestore:"is_leech"`
+	Relearning    bool `json:"relearning" firestore:"relearning"`
+	// Relearning_step is the index into the relearning intervals while Relearning is set
+	Relearning_step int `json:"relearning_step" firestore:"relearning_step"`
+	// Version is bumped by the store on every write, clients send back the version they
+	// last read so concurrent edits are detected instead of silently overwritten
+	Version int64 `json:"version" firestore:"version"`
+}
+
+// ScheduleState is what a scheduler remembers about a problem between reviews, each
+// algorithm only uses the fields it needs
+type ScheduleState struct {
+	Algorithm string `json:"algorithm" firestore:"algorithm"`
+	// Interval is the number of days between the last review and the next one
+	Interval    int `json:"interval" firestore:"interval"`
+	Repetitions int `json:"repetitions" firestore:"repetitions"`
+	Lapses      int `json:"lapses" firestore:"lapses"`
+	// Ease is the SM-2 ease factor
+	Ease float64 `json:"ease" firestore:"ease"`
+	// Stability and Difficulty are the FSRS memory model
+	Stability  float64 `json:"stability" firestore:"stability"`
+	Difficulty float64 `json:"difficulty" firestore:"difficulty"`
+}
+
+// ReviewRecord is one entry of the review history of a problem, records are never changed once written
+type ReviewRecord struct {
+	ID          string    `json:"id" firestore:"id"`
+	Problem_id  string    `json:"problem_id" firestore:"problem_id"`
+	Reviewed_at time.Time `json:"reviewed_at" firestore:"reviewed_at"`
+	// Grade is the 1-5 confidence the user had while solving the problem again
+	Grade              int `json:"grade" firestore:"grade"`
+	Time_spent_seconds int `json:"time_spent_seconds" firestore:"time_spent_seconds"`
+	// Notes_delta is the text appended to the notes during the review
+	Notes_delta string `json:"notes_delta" firestore:"notes_delta"`
+	// Next_revision is the due time the review produced
+	Next_revision time.Time `json:"next_revision" firestore:"next_revision"`
+}
+
+// ForecastDay is the number of reviews expected on one day of a workload forecast
+type ForecastDay struct {
+	// Date is the day in the time zone of the user
+	Date         string         `json:"date"`
+	Total        int            `json:"total"`
+	ByDifficulty map[string]int `json:"by_difficulty"`
+	ByTag        map[string]int `json:"by_tag"`
+}
+
+// NoteVersion is the notes of a revision problem as saved by one write, Version is the
+// version of the problem that write produced
+type NoteVersion struct {
+	Problem_id string    `json:"problem_id" firestore:"problem_id"`
+	Version    int64     `json:"version" firestore:"version"`
+	Notes      string    `json:"notes" firestore:"notes"`
+	Created_at time.Time `json:"created_at" firestore:"created_at"`
+}
+
+// TrashedRevision is a deleted revision problem, it can be restored until the trash retention ends
+type TrashedRevision struct {
+	RevisionProblem
+	Deleted_at time.Time `json:"deleted_at" firestore:"deleted_at"`
+}
+
+// Deck is a named group of revision problems, a problem can be in several decks.
+// The scheduling fields override the user settings for the problems of the deck when set.
+type Deck struct {
+	ID       string `json:"id" firestore:"id"`
+	Name     string `json:"name" firestore:"name"`
+	Archived bool   `json:"archived" firestore:"archived"`
+	// Scheduler, DailyReviewLimit and LeechThreshold follow UserSettings, zero values fall back to it
+	Scheduler        string    `json:"scheduler" firestore:"scheduler"`
+	DailyReviewLimit int       `json:"daily_review_limit" firestore:"daily_review_limit"`
+	LeechThreshold   int       `json:"leech_threshold" firestore:"leech_threshold"`
+	CreatedAt        time.Time `json:"created_at" firestore:"created_at"`
+}
+
+// UserSettings holds the per user preferences of the revision subsystem
+type UserSettings struct {
+	UserID string `json:"userId" firestore:"userId"`
+	// Scheduler is the spaced repetition algorithm, empty means the default one
+	Scheduler string `json:"scheduler" firestore:"scheduler"`
+	// Timezone is an IANA zone name, due dates roll over at midnight in this zone
+	Timezone string `json:"timezone" firestore:"timezone"`
+	// DailyReviewLimit caps the reviews handed out per day, 0 means no limit
+	DailyReviewLimit int `json:"daily_review_limit" firestore:"daily_review_limit"`
+	// LeechThreshold is the number of failed reviews that makes a problem a leech, 0 means the default
+	LeechThreshold int `json:"leech_threshold" firestore:"leech_threshold"`
+	// PausedAt is when the user paused their schedule, zero while it runs
+	PausedAt time.Time `json:"paused_at" firestore:"paused_at"`
+	// ResumeCursor is the last problem ID a resume that failed part way already moved, the
+	// next resume continues after it instead of moving those problems twice
+	ResumeCursor string `json:"-" firestore:"resume_cursor"`
+	// LeetCodeRegion is the LeetCode site the user practices on, "com" or "cn", empty means "com"
+	LeetCodeRegion string `json:"leetcode_region" firestore:"leetcode_region"`
+}
+
+// WithDeck returns the settings with the scheduling overrides of deck applied
+func (s UserSettings) WithDeck(deck Deck) UserSettings {
+	if deck.Scheduler != "" {
+		s.Scheduler = deck.Scheduler
+	}
+	if deck.DailyReviewLimit != 0 {
+		s.DailyReviewLimit = deck.DailyReviewLimit
+	}
+	if deck.LeechThreshold != 0 {
+		s.LeechThreshold = deck.LeechThreshold
+	}
+	return s
+}
+
+// Paused reports whether the user froze their schedule
+func (s UserSettings) Paused() bool {
+	return !s.PausedAt.IsZero()
+}
+
+// Location returns the time zone of the user, UTC when none or an unknown one is set
+func (s UserSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// SubmissionSyncState is how far the LeetCode submissions of a user were copied into the
+// store, a sync only fetches submissions newer than LastSubmissionID, or those of the backfill
+// while one is left
+type SubmissionSyncState struct {
+	UserID string `json:"userId" firestore:"userId"`
+	// Region is the LeetCode site of the last sync, submission IDs only compare within a site
+	Region string `json:"region" firestore:"region"`
+	// LastSubmissionID and LastTimestamp are of the newest stored submission, 0 before the first sync
+	LastSubmissionID int64 `json:"last_submission_id" firestore:"last_submission_id"`
+	LastTimestamp    int64 `json:"last_timestamp" firestore:"last_timestamp"`
+	// BackfillBeforeID is set when a sync stopped at its window before reaching the submissions
+	// stored earlier, those with IDs between BackfillSinceID and BackfillBeforeID are missing
+	BackfillSinceID  int64     `json:"backfill_since_id" firestore:"backfill_since_id"`
+	BackfillBeforeID int64     `json:"backfill_before_id" firestore:"backfill_before_id"`
+	SyncedAt         time.Time `json:"synced_at" firestore:"synced_at"`
 }
 
 type RevisionList struct {
@@ -21,10 +173,52 @@ type RevisionList struct {
 	Revisions []RevisionProblem `json:"revisions" firestore:"revisions"`
 }
 
+// ErrInvalidProblemID is returned for a problem ID that cannot name a revision, IDs become
+// document IDs and URL path segments
+var ErrInvalidProblemID = errors.New("invalid problem id")
+
+// maxProblemIDLength keeps IDs well under the 1500 byte limit of Firestore document IDs
+const maxProblemIDLength = 256
+
+// ValidateProblemID checks that id can name a revision: it is not empty, has no slash,
+// whitespace or control characters, and is not one of the IDs Firestore reserves
+func ValidateProblemID(id string) error {
+	switch {
+	case id == "":
+		return fmt.Errorf("%w: empty, the title needs a letter or digit", ErrInvalidProblemID)
+	case len(id) > maxProblemIDLength:
+		return fmt.Errorf("%w: longer than %d bytes", ErrInvalidProblemID, maxProblemIDLength)
+	case id == "." || id == ".." || (strings.HasPrefix(id, "__") && strings.HasSuffix(id, "__")):
+		return fmt.Errorf("%w: %q is reserved", ErrInvalidProblemID, id)
+	case strings.ContainsFunc(id, func(r rune) bool { return r == '/' || unicode.IsSpace(r) || unicode.IsControl(r) }):
+		return fmt.Errorf("%w: %q contains a slash, space or control character", ErrInvalidProblemID, id)
+	}
+	return nil
+}
+
+// PreprocessRevisionProblems runs Preprocess on every problem and rejects a list that names
+// the same problem twice
+func PreprocessRevisionProblems(problems []RevisionProblem) error {
+	seen := make(map[string]int, len(problems))
+	for i := range problems {
+		if err := problems[i].Preprocess(); err != nil {
+			return err
+		}
+		if j, ok := seen[problems[i].Problem_id]; ok {
+			return fmt.Errorf("%w: %q is given for problems %d and %d", ErrInvalidProblemID, problems[i].Problem_id, j+1, i+1)
+		}
+		seen[problems[i].Problem_id] = i
+	}
+	return nil
+}
+
 func (rp *RevisionProblem) Preprocess() error {
 	if rp.Title == "" {
 		return fmt.Errorf("no title provided for revision problem, title is required")
 	}
+	if err := ValidateProblemID(rp.EnsureProblemID()); err != nil {
+		return err
+	}
 	if rp.Confidence_level == 0 {
 		return fmt.Errorf("no condidence level provided for revision problem, condidence level is required")
 	}
@@ -37,19 +231,10 @@ func (rp *RevisionProblem) Preprocess() error {
 	return nil
 }
 
-func (rp *RevisionProblem) FindNextRevisionDate() {
-	daysToAdd := 1
-	switch rp.Confidence_level {
-	case 1:
-		daysToAdd = 1
-	case 2:
-		daysToAdd = 3
-	case 3:
-		daysToAdd = 7
-	case 4:
-		daysToAdd = 14
-	case 5:
-		daysToAdd = 30
-	}
-	rp.Next_revision = time.Now().AddDate(0, 0, daysToAdd).Format("2006-01-02")
+// EnsureProblemID fills Problem_id from the submission when the client did not send it
+func (rp *RevisionProblem) EnsureProblemID() string {
+	if rp.Problem_id == "" {
+		rp.Problem_id = rp.ProblemSlug()
+	}
+	return rp.Problem_id
 }
@@ -1,8 +1,16 @@
 package models
 
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
 type LeetCodeSubmission struct {
 	ID                     int64  `json:"id" firestore:"id"`
+	QuestionID             int64  `json:"question_id" firestore:"question_id"`
 	Title                  string `json:"title" firestore:"title"`
+	TitleSlug              string `json:"title_slug" firestore:"title_slug"`
 	Code                   string `json:"code" firestore:"code"`
 	Lang                   string `json:"lang" firestore:"lang"`
 	LangName               string `json:"lang_name" firestore:"lang_name"`
@@ -19,6 +27,66 @@ type LeetCodeSubmission struct {
 	CurrentSpaceComplexity string `json:"currentSpaceComplexity" firestore:"currentSpaceComplexity"`
 }
 
+// ProblemMetadata is what LeetCode publishes about a problem, cached per slug
+type ProblemMetadata struct {
+	Slug string `json:"slug" firestore:"slug"`
+	// QuestionID is the number LeetCode shows in front of the title
+	QuestionID string `json:"question_id" firestore:"question_id"`
+	Title      string `json:"title" firestore:"title"`
+	// Content is the problem statement as HTML, empty for premium problems fetched without a
+	// premium session
+	Content    string   `json:"content" firestore:"content"`
+	Difficulty string   `json:"difficulty" firestore:"difficulty"`
+	TopicTags  []string `json:"topic_tags" firestore:"topic_tags"`
+	// AcceptanceRate is the percentage of accepted submissions
+	AcceptanceRate   float64           `json:"acceptance_rate" firestore:"acceptance_rate"`
+	SimilarQuestions []SimilarQuestion `json:"similar_questions" firestore:"similar_questions"`
+	IsPaidOnly       bool              `json:"is_paid_only" firestore:"is_paid_only"`
+	FetchedAt        time.Time         `json:"fetched_at" firestore:"fetched_at"`
+}
+
+// SimilarQuestion is a problem LeetCode lists as similar to another one
+type SimilarQuestion struct {
+	Title      string `json:"title" firestore:"title"`
+	Slug       string `json:"slug" firestore:"slug"`
+	Difficulty string `json:"difficulty" firestore:"difficulty"`
+}
+
+// ProblemSlug returns the LeetCode slug of the submitted problem, taken from title_slug,
+// then from a /problems/{slug} URL and finally derived from the title the way LeetCode does
+func (s *LeetCodeSubmission) ProblemSlug() string {
+	if s.TitleSlug != "" {
+		return s.TitleSlug
+	}
+	if _, rest, ok := strings.Cut(s.URL, "/problems/"); ok {
+		slug, _, _ := strings.Cut(rest, "/")
+		if slug != "" {
+			return slug
+		}
+	}
+	return SlugifyTitle(s.Title)
+}
+
+// SlugifyTitle mirrors LeetCode slugs: punctuation is dropped and runs of spaces or
+// dashes become a single dash, so "Pow(x, n)" becomes "powx-n"
+func SlugifyTitle(title string) string {
+	var b strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingDash = false
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			pendingDash = true
+		}
+	}
+	return b.String()
+}
+
 type SubmissionsDump struct {
 	Submissions []LeetCodeSubmission `json:"submissions_dump"`
 }
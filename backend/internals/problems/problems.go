@@ -0,0 +1,117 @@
+// Package problems looks up the LeetCode metadata of problems. It is cached per slug in the
+// store, shared by all users, so each problem is fetched from LeetCode about once.
+package problems
+
+import (
+	"context"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/leetcode"
+	"dsa-helper-backend/internals/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxAge is how long cached metadata is used before it is fetched again, problems rarely
+// change but their tags and acceptance rate drift
+const MaxAge = 30 * 24 * time.Hour
+
+// DefaultFetchLimit is the number of problems a request may fetch from LeetCode in a Lookup,
+// the others are left out until a later request finds them cached
+const DefaultFetchLimit = 20
+
+// Get returns the metadata of the problem with the given slug, fetching it when the cache has
+// none or a stale copy. A stale copy is still returned when LeetCode cannot be reached.
+func Get(ctx context.Context, store datastore.Store, client leetcode.LeetCodeClient, cookie string, slug string) (models.ProblemMetadata, error) {
+	cached, found, err := lookupCache(ctx, store, slug)
+	if err != nil {
+		return models.ProblemMetadata{}, err
+	}
+	if found && fresh(cached) {
+		return cached, nil
+	}
+	meta, err := fetch(ctx, store, client, cookie, slug, cached)
+	if err != nil && found && !errors.Is(err, leetcode.ErrProblemNotFound) && ctx.Err() == nil {
+		return cached, nil
+	}
+	return meta, err
+}
+
+// Lookup returns the metadata of the slugs it can find. Cached problems are always found,
+// at most fetchLimit others are fetched from LeetCode. Problems that could not be fetched
+// are left out of the map and their errors joined, callers fall back to what they know.
+func Lookup(ctx context.Context, store datastore.Store, client leetcode.LeetCodeClient, cookie string, slugs []string, fetchLimit int) (map[string]models.ProblemMetadata, error) {
+	found := make(map[string]models.ProblemMetadata, len(slugs))
+	var missing []string
+	var errs []error
+	for _, slug := range slugs {
+		if _, ok := found[slug]; ok || slug == "" {
+			continue
+		}
+		cached, ok, err := lookupCache(ctx, store, slug)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			found[slug] = cached
+		}
+		if !ok || !fresh(cached) {
+			missing = append(missing, slug)
+		}
+	}
+	for i, slug := range missing {
+		if i >= fetchLimit || ctx.Err() != nil {
+			break
+		}
+		meta, err := fetch(ctx, store, client, cookie, slug, found[slug])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", slug, err))
+			continue
+		}
+		found[slug] = meta
+	}
+	return found, errors.Join(errs...)
+}
+
+// Fill sets the difficulty of problem from meta and its tags when it has none, so user
+// chosen tags are kept
+func Fill(problem *models.RevisionProblem, meta models.ProblemMetadata) {
+	if meta.Difficulty != "" {
+		problem.Difficulty = meta.Difficulty
+	}
+	if len(problem.Tags) == 0 && len(meta.TopicTags) > 0 {
+		problem.Tags = append([]string(nil), meta.TopicTags...)
+	}
+}
+
+func fresh(meta models.ProblemMetadata) bool {
+	return time.Since(meta.FetchedAt) < MaxAge
+}
+
+func lookupCache(ctx context.Context, store datastore.Store, slug string) (models.ProblemMetadata, bool, error) {
+	meta, err := datastore.GetAnalysisProblems[models.ProblemMetadata](ctx, slug, store, datastore.ProblemMetadataCollection)
+	if errors.Is(err, datastore.ErrNotFound) {
+		return models.ProblemMetadata{}, false, nil
+	}
+	if err != nil {
+		return models.ProblemMetadata{}, false, fmt.Errorf("failed to get cached problem metadata: %w", err)
+	}
+	return meta, true, nil
+}
+
+// fetch gets the metadata of slug from LeetCode and caches it. A premium problem fetched
+// without a premium session comes without content, the content of cached is kept then.
+func fetch(ctx context.Context, store datastore.Store, client leetcode.LeetCodeClient, cookie string, slug string, cached models.ProblemMetadata) (models.ProblemMetadata, error) {
+	meta, err := client.FetchProblem(ctx, cookie, slug)
+	if err != nil {
+		return models.ProblemMetadata{}, err
+	}
+	if meta.Content == "" {
+		meta.Content = cached.Content
+	}
+	if err := datastore.AddAnalysisProblems(ctx, store, meta, slug, datastore.ProblemMetadataCollection); err != nil {
+		return models.ProblemMetadata{}, fmt.Errorf("failed to cache problem metadata: %w", err)
+	}
+	return meta, nil
+}
@@ -0,0 +1,30 @@
+package scheduler
+
+import "dsa-helper-backend/internals/models"
+
+// Fixed is the original schedule, every confidence level maps to a fixed number of days
+// and the revision history is ignored
+type Fixed struct{}
+
+func (Fixed) Name() string {
+	return FixedName
+}
+
+func (Fixed) Next(state models.ScheduleState, grade int, elapsedDays int) models.ScheduleState {
+	daysToAdd := 1
+	switch grade {
+	case 1:
+		daysToAdd = 1
+	case 2:
+		daysToAdd = 3
+	case 3:
+		daysToAdd = 7
+	case 4:
+		daysToAdd = 14
+	case 5:
+		daysToAdd = 30
+	}
+	state.Interval = daysToAdd
+	state.Repetitions++
+	return state
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"slices"
+	"time"
+)
+
+// UnknownDifficulty is the difficulty bucket of problems without one
+const UnknownDifficulty = "Unknown"
+
+// Forecast counts the reviews due on each of the next days, starting with the day of now.
+// Every review is replayed with the scheduler schedulerOf returns for the problem, assuming it
+// keeps its current confidence level, so a problem can show up several times in the window.
+// Overdue problems count for today. With a limit above 0 at most limit reviews are counted
+// per day, the rest stays due and leads the next day the way the due list hands them out.
+func Forecast(schedulerOf func(models.RevisionProblem) Scheduler, problems []models.RevisionProblem, now time.Time, days int, limit int) []models.ForecastDay {
+	today := StartOfDay(now)
+	forecast := make([]models.ForecastDay, days)
+	for i := range forecast {
+		forecast[i] = models.ForecastDay{
+			Date:         today.AddDate(0, 0, i).Format("2006-01-02"),
+			ByDifficulty: make(map[string]int),
+			ByTag:        make(map[string]int),
+		}
+	}
+	pending := slices.Clone(problems)
+	for day := range forecast {
+		reviewedAt := today.AddDate(0, 0, day)
+		endOfDay := today.AddDate(0, 0, day+1)
+		// due problems sort first, a prefix of pending is what the due list hands out
+		Prioritize(pending)
+		due := 0
+		for due < len(pending) && pending[due].Next_revision.Before(endOfDay) {
+			due++
+		}
+		if limit > 0 {
+			due = min(due, limit)
+		}
+		for i := range pending[:due] {
+			p := &pending[i]
+			forecast[day].Total++
+			difficulty := p.Difficulty
+			if difficulty == "" {
+				difficulty = UnknownDifficulty
+			}
+			forecast[day].ByDifficulty[difficulty]++
+			for _, tag := range p.Tags {
+				forecast[day].ByTag[tag]++
+			}
+			Review(schedulerOf(*p), p, reviewedAt)
+			p.Last_revised = reviewedAt.Format("2006-01-02")
+		}
+	}
+	return forecast
+}
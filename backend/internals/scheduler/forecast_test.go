@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"testing"
+	"time"
+)
+
+func TestForecastDailyLimit(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	today := StartOfDay(now)
+	var problems []models.RevisionProblem
+	for range 5 {
+		problems = append(problems, models.RevisionProblem{Confidence_level: 3, Next_revision: today.AddDate(0, 0, -1)})
+	}
+	fixed := func(models.RevisionProblem) Scheduler { return Fixed{} }
+	tests := []struct {
+		name  string
+		limit int
+		want  []int
+	}{
+		// confidence 3 comes back after 7 days with the fixed scheduler
+		{"no limit", 0, []int{5, 0, 0, 0, 0, 0, 0, 5}},
+		// the overflow stays due and leads the next days
+		{"limit 2", 2, []int{2, 2, 1, 0, 0, 0, 0, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast := Forecast(fixed, problems, now, len(tt.want), tt.limit)
+			for i, day := range forecast {
+				if day.Total != tt.want[i] {
+					t.Fatalf("day %d: %d reviews, want %d", i, day.Total, tt.want[i])
+				}
+			}
+		})
+	}
+	if !problems[0].Next_revision.Equal(today.AddDate(0, 0, -1)) {
+		t.Fatal("Forecast changed the problems it was given")
+	}
+}
+
+func TestForecastSchedulerPerProblem(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	problems := []models.RevisionProblem{
+		{Problem_id: "fixed", Confidence_level: 5, Next_revision: StartOfDay(now)},
+		{Problem_id: "sm2", Confidence_level: 5, Next_revision: StartOfDay(now)},
+	}
+	schedulerOf := func(p models.RevisionProblem) Scheduler {
+		if p.Problem_id == "fixed" {
+			return Fixed{}
+		}
+		return SM2{}
+	}
+	forecast := Forecast(schedulerOf, problems, now, 3, 0)
+	// SM-2 brings a first review back the next day, the fixed scheduler waits longer
+	if got := [3]int{forecast[0].Total, forecast[1].Total, forecast[2].Total}; got != [3]int{2, 1, 0} {
+		t.Fatalf("reviews per day %v, want [2 1 0]", got)
+	}
+}
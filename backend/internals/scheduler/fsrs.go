@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"math"
+)
+
+// FSRS grades
+const (
+	fsrsAgain = 1
+	fsrsHard  = 2
+	fsrsGood  = 3
+	fsrsEasy  = 4
+)
+
+const (
+	fsrsDecay  = -0.5
+	fsrsFactor = 19.0 / 81.0
+)
+
+// fsrsDefaultWeights are the published FSRS-4.5 default parameters
+var fsrsDefaultWeights = [17]float64{
+	0.4872, 1.4003, 3.7145, 13.8206, 5.1618, 1.2298, 0.8975, 0.031, 1.6474,
+	0.1367, 1.0461, 2.1072, 0.0793, 0.3246, 1.587, 0.2272, 2.8755,
+}
+
+// FSRS is the Free Spaced Repetition Scheduler (version 4.5). It models every problem by
+// its stability (days until recall drops to the requested retention) and difficulty (1-10).
+type FSRS struct {
+	Weights [17]float64
+	// RequestRetention is the recall probability at which a review becomes due
+	RequestRetention float64
+	MaximumInterval  int
+}
+
+func NewFSRS() FSRS {
+	return FSRS{
+		Weights:          fsrsDefaultWeights,
+		RequestRetention: 0.9,
+		MaximumInterval:  365,
+	}
+}
+
+func (FSRS) Name() string {
+	return FSRSName
+}
+
+// fsrsGrade folds the five confidence levels into the four FSRS grades
+func fsrsGrade(confidence int) int {
+	switch confidence {
+	case 1:
+		return fsrsAgain
+	case 2:
+		return fsrsHard
+	case 3, 4:
+		return fsrsGood
+	default:
+		return fsrsEasy
+	}
+}
+
+func (f FSRS) Next(state models.ScheduleState, grade int, elapsedDays int) models.ScheduleState {
+	w := f.Weights
+	g := fsrsGrade(grade)
+	if state.Stability == 0 {
+		state.Stability = w[g-1]
+		state.Difficulty = f.initialDifficulty(g)
+	} else {
+		retrievability := math.Pow(1+fsrsFactor*float64(elapsedDays)/state.Stability, fsrsDecay)
+		if g == fsrsAgain {
+			state.Stability = w[11] * math.Pow(state.Difficulty, -w[12]) * (math.Pow(state.Stability+1, w[13]) - 1) *
+				math.Exp(w[14]*(1-retrievability))
+			state.Lapses++
+		} else {
+			hardPenalty, easyBonus := 1.0, 1.0
+			if g == fsrsHard {
+				hardPenalty = w[15]
+			}
+			if g == fsrsEasy {
+				easyBonus = w[16]
+			}
+			state.Stability *= 1 + math.Exp(w[8])*(11-state.Difficulty)*math.Pow(state.Stability, -w[9])*
+				(math.Exp(w[10]*(1-retrievability))-1)*hardPenalty*easyBonus
+		}
+		difficulty := state.Difficulty - w[6]*float64(g-3)
+		// mean reversion towards the difficulty of a first "good" review
+		state.Difficulty = clampDifficulty(w[7]*f.initialDifficulty(fsrsGood) + (1-w[7])*difficulty)
+	}
+	if g == fsrsAgain {
+		state.Repetitions = 0
+	} else {
+		state.Repetitions++
+	}
+	interval := state.Stability / fsrsFactor * (math.Pow(f.RequestRetention, 1/fsrsDecay) - 1)
+	state.Interval = min(max(int(math.Round(interval)), 1), f.MaximumInterval)
+	return state
+}
+
+func (f FSRS) initialDifficulty(g int) float64 {
+	return clampDifficulty(f.Weights[4] - float64(g-3)*f.Weights[5])
+}
+
+func clampDifficulty(d float64) float64 {
+	return math.Min(math.Max(d, 1), 10)
+}
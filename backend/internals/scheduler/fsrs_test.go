@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"math"
+	"testing"
+)
+
+func TestFSRSNext(t *testing.T) {
+	f := NewFSRS()
+	// a problem after a first "good" review (confidence 3)
+	learned := models.ScheduleState{Stability: 3.7145, Difficulty: 5.1618, Interval: 4, Repetitions: 1}
+	tests := []struct {
+		name       string
+		state      models.ScheduleState
+		grade      int
+		elapsed    int
+		stability  float64
+		difficulty float64
+		interval   int
+		lapses     int
+	}{
+		// first reviews take w0-w3 as stability and w4 - (g-3)*w5 as difficulty
+		{"new again", models.ScheduleState{}, 1, 0, 0.4872, 7.6214, 1, 0},
+		{"new hard", models.ScheduleState{}, 2, 0, 1.4003, 6.3916, 1, 0},
+		{"new good", models.ScheduleState{}, 3, 0, 3.7145, 5.1618, 4, 0},
+		{"new easy", models.ScheduleState{}, 5, 0, 13.8206, 3.9320, 14, 0},
+		// reviews on the due day, where retrievability is exactly the requested 0.9
+		{"again on time", learned, 1, 4, 1.4332, 6.9012, 1, 1},
+		{"hard on time", learned, 2, 4, 6.2350, 6.0315, 6, 0},
+		{"good on time", learned, 3, 4, 14.8081, 5.1618, 15, 0},
+		{"easy on time", learned, 5, 4, 35.6141, 4.2921, 36, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := f.Next(tt.state, tt.grade, tt.elapsed)
+			if math.Abs(got.Stability-tt.stability) > 1e-3 {
+				t.Errorf("stability = %.4f, want %.4f", got.Stability, tt.stability)
+			}
+			if math.Abs(got.Difficulty-tt.difficulty) > 1e-3 {
+				t.Errorf("difficulty = %.4f, want %.4f", got.Difficulty, tt.difficulty)
+			}
+			if got.Interval != tt.interval {
+				t.Errorf("interval = %d, want %d", got.Interval, tt.interval)
+			}
+			if got.Lapses != tt.lapses {
+				t.Errorf("lapses = %d, want %d", got.Lapses, tt.lapses)
+			}
+		})
+	}
+}
+
+func TestFSRSMaximumInterval(t *testing.T) {
+	f := NewFSRS()
+	f.MaximumInterval = 30
+	got := f.Next(models.ScheduleState{Stability: 100, Difficulty: 3, Repetitions: 5}, 5, 100)
+	if got.Interval != 30 {
+		t.Fatalf("interval = %d, want 30", got.Interval)
+	}
+}
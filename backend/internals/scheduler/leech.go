@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"time"
+)
+
+// DefaultLeechThreshold is used for users that did not set their own
+const DefaultLeechThreshold = 4
+
+// RelearningSteps are the intervals in days a leech goes through after a failure,
+// the scheduler takes over again once all of them are passed
+var RelearningSteps = []int{1, 2, 4}
+
+// TrackLeech counts a failed review of problem, a grade below 3, and sends problems that
+// failed threshold times or more into relearning. It reports whether problem is relearning,
+// in which case Next_revision was set from RelearningSteps and must not be moved.
+func TrackLeech(problem *models.RevisionProblem, grade int, threshold int, now time.Time) bool {
+	if threshold <= 0 {
+		threshold = DefaultLeechThreshold
+	}
+	failed := grade < 3
+	if failed {
+		problem.Failure_count++
+		if problem.Failure_count >= threshold {
+			problem.Is_leech = true
+			problem.Relearning = true
+		}
+	}
+	if !problem.Relearning {
+		return false
+	}
+	if failed {
+		problem.Relearning_step = 0
+	} else {
+		problem.Relearning_step++
+	}
+	if problem.Relearning_step >= len(RelearningSteps) {
+		problem.Relearning = false
+		problem.Relearning_step = 0
+		return false
+	}
+	interval := RelearningSteps[problem.Relearning_step]
+	problem.Schedule.Interval = interval
+	problem.Next_revision = StartOfDay(now).AddDate(0, 0, interval)
+	return true
+}
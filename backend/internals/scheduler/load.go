@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"sort"
+	"time"
+)
+
+// Load tracks how many reviews fall on each day so new due dates can be spread over the
+// days around the one picked by the scheduler instead of piling up on a single day
+type Load struct {
+	// limit is the daily review limit of the user, 0 means none
+	limit int
+	// newSpread is how many days past its first due day BalanceNew may move a new problem
+	newSpread int
+	counts    map[string]int
+}
+
+// newProblemsPerDay is the pace a batch of new problems is spread at when there is no daily limit
+const newProblemsPerDay = 5
+
+// NewLoad counts the due days of problems in loc
+func NewLoad(problems []models.RevisionProblem, loc *time.Location, limit int) *Load {
+	l := &Load{limit: limit, counts: make(map[string]int)}
+	for _, p := range problems {
+		l.counts[dayKey(p.Next_revision.In(loc))]++
+	}
+	return l
+}
+
+// Balance moves the Next_revision set by Review to the least loaded day of a fuzz window
+// around it. When the limit is full on every day of the window it looks further ahead, and
+// when every candidate is full the problem stays on the least loaded one and overflows
+// into the due list of later days.
+func (l *Load) Balance(problem *models.RevisionProblem, now time.Time) {
+	l.balance(problem, now, 0)
+}
+
+// SpreadNew prepares the load for a batch of n new problems. Their first interval is a day or
+// two, too short for any fuzz, so BalanceNew spreads them over as many days as the daily limit
+// needs for n reviews, or over one day per newProblemsPerDay problems without a limit.
+func (l *Load) SpreadNew(n int) {
+	perDay := newProblemsPerDay
+	if l.limit > 0 {
+		perDay = l.limit
+	}
+	l.newSpread = max(n-1, 0) / perDay
+}
+
+// BalanceNew is Balance for a problem of the batch announced to SpreadNew, its first review
+// may also move up to the spread past the day picked by the scheduler
+func (l *Load) BalanceNew(problem *models.RevisionProblem, now time.Time) {
+	l.balance(problem, now, l.newSpread)
+}
+
+func (l *Load) balance(problem *models.RevisionProblem, now time.Time, spread int) {
+	today := StartOfDay(now)
+	interval := problem.Schedule.Interval
+	fuzz := fuzzDays(interval)
+	lo, hi := max(interval-fuzz, 1), interval+fuzz+spread
+	best := l.leastLoaded(today, interval, lo, hi)
+	if l.limit > 0 && l.counts[dayKey(today.AddDate(0, 0, best))] >= l.limit {
+		best = l.leastLoaded(today, interval, lo, hi+max(interval/2, 1))
+	}
+	problem.Schedule.Interval = best
+	problem.Next_revision = today.AddDate(0, 0, best)
+	l.counts[dayKey(problem.Next_revision)]++
+}
+
+// leastLoaded returns the day offset in [lo, hi] with the fewest reviews, closest to target on ties
+func (l *Load) leastLoaded(today time.Time, target int, lo int, hi int) int {
+	best := target
+	bestCount := l.counts[dayKey(today.AddDate(0, 0, target))]
+	for day := lo; day <= hi; day++ {
+		count := l.counts[dayKey(today.AddDate(0, 0, day))]
+		if count < bestCount || (count == bestCount && abs(day-target) < abs(best-target)) {
+			best, bestCount = day, count
+		}
+	}
+	return best
+}
+
+// fuzzDays is how far a due date may move, short intervals are kept exact
+func fuzzDays(interval int) int {
+	switch {
+	case interval <= 2:
+		return 0
+	case interval <= 7:
+		return 1
+	default:
+		return min(max(interval*15/100, 1), 7)
+	}
+}
+
+// Prioritize orders due problems the way they are handed out: the longest overdue first,
+// then the least confident ones
+func Prioritize(problems []models.RevisionProblem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		if !problems[i].Next_revision.Equal(problems[j].Next_revision) {
+			return problems[i].Next_revision.Before(problems[j].Next_revision)
+		}
+		return problems[i].Confidence_level < problems[j].Confidence_level
+	})
+}
+
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"testing"
+	"time"
+)
+
+func TestBalanceNewSpreadsBulkAdd(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		limit int
+		n     int
+		// want is the number of new problems due on each day from tomorrow on
+		want []int
+	}{
+		{"single problem", 0, 1, []int{1}},
+		{"no limit", 0, 20, []int{5, 5, 5, 5}},
+		{"daily limit", 8, 20, []int{7, 7, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load := NewLoad(nil, time.UTC, tt.limit)
+			load.SpreadNew(tt.n)
+			perDay := make(map[int]int)
+			for range tt.n {
+				// a first SM-2 review comes back after one day, which leaves no room for fuzz
+				p := models.RevisionProblem{Confidence_level: 3}
+				Review(SM2{}, &p, now)
+				load.BalanceNew(&p, now)
+				perDay[daysBetween(now, p.Next_revision)]++
+			}
+			if len(perDay) != len(tt.want) {
+				t.Fatalf("problems per day %v, want %v from tomorrow on", perDay, tt.want)
+			}
+			for i, want := range tt.want {
+				if perDay[i+1] != want {
+					t.Fatalf("problems per day %v, want %v from tomorrow on", perDay, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBalanceKeepsShortIntervals(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	load := NewLoad(nil, time.UTC, 0)
+	for range 10 {
+		p := models.RevisionProblem{Confidence_level: 3}
+		Review(SM2{}, &p, now)
+		load.Balance(&p, now)
+		if p.Schedule.Interval != 1 {
+			t.Fatalf("a review was moved to day %d", p.Schedule.Interval)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"time"
+)
+
+// Resume modes of a paused schedule
+const (
+	// ResumeShift moves every due date by the number of days the schedule was paused
+	ResumeShift = "shift"
+	// ResumeRamp evens out the problems due over the next days instead
+	ResumeRamp = "ramp"
+)
+
+// Shift moves the due date of every problem by the days between pausedAt and now, counted
+// in the location of now. It returns the number of days.
+func Shift(problems []models.RevisionProblem, pausedAt time.Time, now time.Time) int {
+	days := daysBetween(pausedAt.In(now.Location()), now)
+	if days <= 0 {
+		return 0
+	}
+	for i := range problems {
+		problems[i].Next_revision = problems[i].Next_revision.In(now.Location()).AddDate(0, 0, days)
+	}
+	return days
+}
+
+// RampUp reschedules the problems due before the end of a ramp of rampDays days starting
+// today so that the busiest day of the ramp has as few reviews as possible. Overdue problems
+// are spread over the whole ramp, the most urgent ones first, and no problem moves ahead of
+// its own due day. It returns the problems it rescheduled.
+func RampUp(problems []models.RevisionProblem, now time.Time, rampDays int) []models.RevisionProblem {
+	today := StartOfDay(now)
+	end := today.AddDate(0, 0, rampDays)
+	var due []models.RevisionProblem
+	for _, p := range problems {
+		if p.Next_revision.Before(end) {
+			due = append(due, p)
+		}
+	}
+	Prioritize(due)
+	// first is the earliest day of the ramp each problem may take, ascending like due
+	first := make([]int, len(due))
+	for i, p := range due {
+		first[i] = max(daysBetween(today, p.Next_revision.In(now.Location())), 0)
+	}
+	// the problems with the fewest days left to choose from are placed first, each on the
+	// least loaded of its days, which gives the number of reviews every day takes
+	load := &Load{counts: make(map[string]int)}
+	for i := len(due) - 1; i >= 0; i-- {
+		day := load.leastLoaded(today, first[i], first[i], rampDays-1)
+		load.counts[dayKey(today.AddDate(0, 0, day))]++
+	}
+	// then the days are handed out again in order of urgency, each problem taking the
+	// earliest one it may that still has room
+	for i := range due {
+		day := first[i]
+		for load.counts[dayKey(today.AddDate(0, 0, day))] == 0 {
+			day++
+		}
+		load.counts[dayKey(today.AddDate(0, 0, day))]--
+		due[i].Next_revision = today.AddDate(0, 0, day)
+	}
+	return due
+}
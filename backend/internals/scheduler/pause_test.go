@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRampUpEvensOutTheRamp(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	today := StartOfDay(now)
+	var problems []models.RevisionProblem
+	add := func(n int, day int) {
+		for range n {
+			problems = append(problems, models.RevisionProblem{
+				Problem_id:       fmt.Sprintf("p%d", len(problems)),
+				Confidence_level: 3,
+				Next_revision:    today.AddDate(0, 0, day).Add(14 * time.Hour),
+			})
+		}
+	}
+	// two weeks of backlog, then the problems that were due over the first days of the ramp
+	for day := -14; day < 0; day++ {
+		add(2, day)
+	}
+	for day := 1; day < 5; day++ {
+		add(4, day)
+	}
+	add(3, 10)
+	dueDay := make(map[string]int)
+	for _, p := range problems {
+		dueDay[p.Problem_id] = daysBetween(today, p.Next_revision)
+	}
+
+	moved := RampUp(problems, now, 5)
+	if len(moved) != 44 {
+		t.Fatalf("rescheduled %d problems, want the 44 due before the end of the ramp", len(moved))
+	}
+	perDay := make(map[int]int)
+	for i, p := range moved {
+		day := daysBetween(today, p.Next_revision)
+		perDay[day]++
+		if day < 0 || day >= 5 {
+			t.Fatalf("%s moved to day %d, outside the ramp", p.Problem_id, day)
+		}
+		if day < dueDay[p.Problem_id] {
+			t.Errorf("%s moved ahead from day %d to %d", p.Problem_id, dueDay[p.Problem_id], day)
+		}
+		if i > 0 && p.Next_revision.Before(moved[i-1].Next_revision) {
+			t.Errorf("%s is more urgent than %s but comes later", moved[i-1].Problem_id, p.Problem_id)
+		}
+	}
+	// 44 reviews over 5 days, none of them may take more than 9
+	for day, count := range perDay {
+		if count > 9 {
+			t.Errorf("day %d has %d reviews, want at most 9: %v", day, count, perDay)
+		}
+	}
+	if moved[0].Problem_id != problems[0].Problem_id || daysBetween(today, moved[0].Next_revision) != 0 {
+		t.Errorf("the longest overdue problem is due on day %d, want today", daysBetween(today, moved[0].Next_revision))
+	}
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"time"
+)
+
+// names accepted in models.UserSettings.Scheduler
+const (
+	FixedName = "fixed"
+	SM2Name   = "sm2"
+	FSRSName  = "fsrs"
+)
+
+// DefaultName is used for users that never picked an algorithm
+const DefaultName = SM2Name
+
+// Scheduler computes the next review of a problem from its previous state and the grade of
+// the review that just happened. Grades are the 1-5 confidence levels used by revisions.
+type Scheduler interface {
+	Name() string
+	// Next returns the state after the review, elapsedDays is the time since the previous
+	// review and state.Interval holds the days until the next one
+	Next(state models.ScheduleState, grade int, elapsedDays int) models.ScheduleState
+}
+
+// ForName returns the scheduler registered under name, an empty name gives the default
+func ForName(name string) (Scheduler, error) {
+	switch name {
+	case "", DefaultName:
+		return SM2{}, nil
+	case FixedName:
+		return Fixed{}, nil
+	case FSRSName:
+		return NewFSRS(), nil
+	default:
+		return nil, fmt.Errorf("unknown scheduler %q", name)
+	}
+}
+
+// Review grades problem with its confidence level and moves Next_revision to the day picked by s.
+// Days are counted in the location of now, so pass the current time in the time zone of the user.
+func Review(s Scheduler, problem *models.RevisionProblem, now time.Time) {
+	state := problem.Schedule
+	if state.Algorithm != s.Name() {
+		// the memory model of another algorithm means nothing to s, start over
+		state = models.ScheduleState{}
+	}
+	today := StartOfDay(now)
+	elapsed := state.Interval
+	if lastRevised, err := time.ParseInLocation("2006-01-02", problem.Last_revised, now.Location()); err == nil {
+		elapsed = daysBetween(lastRevised, today)
+	}
+	state = s.Next(state, clampGrade(problem.Confidence_level), max(elapsed, 0))
+	state.Algorithm = s.Name()
+	problem.Schedule = state
+	problem.Next_revision = today.AddDate(0, 0, state.Interval)
+}
+
+// StartOfDay returns midnight of the day of t in the location of t
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween counts calendar days, which differs from 24 hour periods across DST changes
+func daysBetween(from time.Time, to time.Time) int {
+	fy, fm, fd := from.Date()
+	ty, tm, td := to.Date()
+	return int(time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC).Sub(time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
+
+func clampGrade(grade int) int {
+	return min(max(grade, 1), 5)
+}
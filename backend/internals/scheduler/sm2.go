@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"math"
+)
+
+const (
+	sm2InitialEase = 2.5
+	sm2MinimumEase = 1.3
+)
+
+// SM2 is the SuperMemo 2 algorithm. Confidence levels are used directly as the review
+// quality, anything below 3 counts as a failed recall and restarts the repetitions.
+type SM2 struct{}
+
+func (SM2) Name() string {
+	return SM2Name
+}
+
+func (SM2) Next(state models.ScheduleState, grade int, elapsedDays int) models.ScheduleState {
+	if state.Ease == 0 {
+		state.Ease = sm2InitialEase
+	}
+	if grade < 3 {
+		if state.Repetitions > 0 {
+			state.Lapses++
+		}
+		state.Repetitions = 0
+		state.Interval = 1
+	} else {
+		switch state.Repetitions {
+		case 0:
+			state.Interval = 1
+		case 1:
+			state.Interval = 6
+		default:
+			state.Interval = int(math.Round(float64(state.Interval) * state.Ease))
+		}
+		state.Repetitions++
+	}
+	q := float64(5 - grade)
+	state.Ease = math.Max(sm2MinimumEase, state.Ease+0.1-q*(0.08+q*0.02))
+	return state
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"dsa-helper-backend/internals/models"
+	"math"
+	"testing"
+)
+
+func TestSM2Next(t *testing.T) {
+	tests := []struct {
+		name      string
+		grades    []int
+		intervals []int
+		ease      float64
+		lapses    int
+	}{
+		// EF' = EF + 0.1 - (5-q)(0.08 + (5-q)0.02) and intervals 1, 6, round(I*EF)
+		{"perfect", []int{5, 5, 5, 5}, []int{1, 6, 16, 45}, 2.9, 0},
+		{"good", []int{4, 4, 4, 4}, []int{1, 6, 15, 38}, 2.5, 0},
+		{"with effort", []int{3, 3, 3, 3}, []int{1, 6, 13, 27}, 1.94, 0},
+		{"lapse", []int{4, 4, 2, 4}, []int{1, 6, 1, 1}, 2.18, 1},
+		{"failed first review", []int{1}, []int{1}, 1.96, 0},
+		{"ease floor", []int{1, 1, 1, 1}, []int{1, 1, 1, 1}, 1.3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var state models.ScheduleState
+			for i, grade := range tt.grades {
+				state = SM2{}.Next(state, grade, state.Interval)
+				if state.Interval != tt.intervals[i] {
+					t.Fatalf("review %d: interval = %d, want %d", i+1, state.Interval, tt.intervals[i])
+				}
+			}
+			if math.Abs(state.Ease-tt.ease) > 1e-9 {
+				t.Errorf("ease = %v, want %v", state.Ease, tt.ease)
+			}
+			if state.Lapses != tt.lapses {
+				t.Errorf("lapses = %d, want %d", state.Lapses, tt.lapses)
+			}
+		})
+	}
+}
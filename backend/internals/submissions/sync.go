@@ -0,0 +1,103 @@
+// Package submissions keeps a copy of the LeetCode submissions of each user in the store, so
+// listing them does not download and analyse the whole history on every request
+package submissions
+
+import (
+	"cmp"
+	"context"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/leetcode"
+	"dsa-helper-backend/internals/models"
+	"fmt"
+	"time"
+)
+
+// DefaultWindow is the number of submissions a sync fetches at most when Options leaves it unset
+const DefaultWindow = 100
+
+// Analyzer fills in the complexity fields of newly fetched submissions before they are stored
+type Analyzer func(ctx context.Context, submissions []models.LeetCodeSubmission) ([]models.LeetCodeSubmission, error)
+
+// Options tune a Sync, the zero value fetches DefaultWindow submissions of leetcode.com and
+// stores them as fetched
+type Options struct {
+	// Window caps the submissions fetched by one sync
+	Window  int
+	Analyze Analyzer
+	// Region is the site client talks to, a sync of another site than the last one starts over
+	Region leetcode.Region
+}
+
+// Report is the outcome of a Sync
+type Report struct {
+	// New is the number of submissions fetched and stored by the sync
+	New              int             `json:"new"`
+	Region           leetcode.Region `json:"region"`
+	LastSubmissionID int64           `json:"last_submission_id"`
+	SyncedAt         time.Time       `json:"synced_at"`
+	// Backfilling is set when the window filled up before the sync reached the submissions
+	// stored earlier, the next syncs fetch the older ones that are missing
+	Backfilling bool `json:"backfilling"`
+}
+
+// Sync fetches the submissions the user made since the last sync, newest first, analyses them
+// and stores them with the new sync state. Nothing is stored when fetching or analysing fails,
+// the next sync fetches the same submissions again. When more submissions are new than fit in
+// the window the state keeps the range left out, and the following syncs fill it from the
+// newest down before fetching anything newer again.
+func Sync(ctx context.Context, store datastore.Store, client leetcode.LeetCodeClient, userID string, cookie string, opts Options) (Report, error) {
+	window := opts.Window
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	region := cmp.Or(opts.Region, leetcode.RegionGlobal)
+	state, err := store.GetSubmissionSyncState(ctx, userID)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to get sync state: %w", err)
+	}
+	if last, _ := leetcode.ParseRegion(state.Region); last != region {
+		// the user switched sites, the IDs of the other one say nothing about what to fetch
+		state = models.SubmissionSyncState{UserID: userID}
+	}
+	state.Region = string(region)
+	sinceID, beforeID := state.LastSubmissionID, int64(0)
+	if state.BackfillBeforeID > 0 {
+		sinceID, beforeID = state.BackfillSinceID, state.BackfillBeforeID
+	}
+	// one submission past the window tells whether the window reaches the stored ones
+	fetched, err := client.FetchSubmissionsBetween(ctx, cookie, sinceID, beforeID, window+1)
+	if err != nil {
+		return Report{}, err
+	}
+	backfilling := len(fetched) > window
+	if backfilling {
+		fetched = fetched[:window]
+	}
+	if len(fetched) > 0 && opts.Analyze != nil {
+		fetched, err = opts.Analyze(ctx, fetched)
+		if err != nil {
+			return Report{}, fmt.Errorf("failed to analyse submissions: %w", err)
+		}
+	}
+	for _, s := range fetched {
+		if s.ID > state.LastSubmissionID {
+			state.LastSubmissionID = s.ID
+			state.LastTimestamp = s.Timestamp
+		}
+	}
+	state.BackfillSinceID, state.BackfillBeforeID = 0, 0
+	if backfilling {
+		state.BackfillSinceID, state.BackfillBeforeID = sinceID, fetched[len(fetched)-1].ID
+	}
+	state.SyncedAt = time.Now().UTC()
+	if err := store.SaveSubmissions(ctx, userID, fetched, state); err != nil {
+		return Report{}, err
+	}
+	return Report{
+		New:              len(fetched),
+		Region:           region,
+		LastSubmissionID: state.LastSubmissionID,
+		SyncedAt:         state.SyncedAt,
+		Backfilling:      backfilling,
+	}, nil
+}
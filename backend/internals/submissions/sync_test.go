@@ -0,0 +1,67 @@
+package submissions
+
+import (
+	"context"
+	"dsa-helper-backend/internals/datastore"
+	"dsa-helper-backend/internals/leetcode"
+	"dsa-helper-backend/internals/leetcode/leetcodetest"
+	"dsa-helper-backend/internals/models"
+	"testing"
+)
+
+func TestSyncBackfillsPastTheWindow(t *testing.T) {
+	site := leetcodetest.NewServer()
+	t.Cleanup(site.Close)
+	client := site.LeetCodeClient()
+	client.Limiter = leetcode.NewLimiter(1000, 1000)
+	cookie := site.AddUser("li", leetcodetest.Submissions(25))
+	store := datastore.NewMemoryStore()
+	ctx := context.Background()
+	analysed := 0
+	opts := Options{Window: 10, Analyze: func(ctx context.Context, fetched []models.LeetCodeSubmission) ([]models.LeetCodeSubmission, error) {
+		analysed += len(fetched)
+		return fetched, nil
+	}}
+	sync := func(want Report) {
+		t.Helper()
+		report, err := Sync(ctx, store, client, "u", cookie, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.New != want.New || report.LastSubmissionID != want.LastSubmissionID || report.Backfilling != want.Backfilling {
+			t.Fatalf("got %+v, want %d new up to %d, backfilling %v", report, want.New, want.LastSubmissionID, want.Backfilling)
+		}
+	}
+
+	// the first syncs fill the history a window at a time, newest first
+	sync(Report{New: 10, LastSubmissionID: 25, Backfilling: true})
+	state, err := store.GetSubmissionSyncState(ctx, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.BackfillSinceID != 0 || state.BackfillBeforeID != 16 {
+		t.Fatalf("state %+v, want submissions below 16 left to backfill", state)
+	}
+	// submissions made during the backfill wait until it is done
+	site.AddUser("li", leetcodetest.Submissions(27))
+	sync(Report{New: 10, LastSubmissionID: 25, Backfilling: true})
+	sync(Report{New: 5, LastSubmissionID: 25})
+	sync(Report{New: 2, LastSubmissionID: 27})
+	sync(Report{LastSubmissionID: 27})
+	// a window of exactly the new submissions leaves nothing to backfill
+	site.AddUser("li", leetcodetest.Submissions(37))
+	sync(Report{New: 10, LastSubmissionID: 37})
+
+	page, err := store.QuerySubmissions(ctx, "u", datastore.SubmissionQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Submissions) != 37 || analysed != 37 {
+		t.Fatalf("stored %d submissions and analysed %d, want every one of the 37 once", len(page.Submissions), analysed)
+	}
+	for i, s := range page.Submissions {
+		if s.ID != int64(37-i) {
+			t.Fatalf("submission %d is %d, want %d", i, s.ID, 37-i)
+		}
+	}
+}
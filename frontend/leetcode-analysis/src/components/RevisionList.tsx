@@ -20,12 +20,24 @@ import {
 import type { RevisionProblem } from '../types/types';
 import {
     fetchRevisions, updateRevisionProblem,
-    deleteRevisionProblem
+    deleteRevisionProblem, recordReview
 } from '../functions/revisionHelpers';
 import { useNotifications } from "../contexts/NotificationContext";
 import CodeModal from "./CodeModal";
 import SolutionComparison from './SolutionComparison';
 
+// next_revision is the instant the problem becomes due, compare it by the local calendar day
+const localDate = (date: Date | string) => new Date(date).toLocaleDateString('en-CA');
+
+// reviewGrades are the grades a review is recorded with, below 3 the problem counts as forgotten
+const reviewGrades = [
+    { grade: 1, label: 'Forgot it' },
+    { grade: 2, label: 'Struggled' },
+    { grade: 3, label: 'Solved with effort' },
+    { grade: 4, label: 'Solved' },
+    { grade: 5, label: 'Easy' },
+];
+
 export default function RevisionList() {
     const [revisions, setRevisions] = useState<RevisionProblem[]>([]);
     const [loading, setLoading] = useState(true);
@@ -82,13 +94,13 @@ export default function RevisionList() {
 
     // Filter revisions based on selected filter and search query
     const filteredRevisions = revisions.filter(revision => {
-        const today = new Date().toISOString().split('T')[0];
+        const today = localDate(new Date());
 
         const filterMatches =
             filter === 'all' ? true :
-                filter === 'due' ? revision.next_revision === today :
-                    filter === 'overdue' ? revision.next_revision < today :
-                        filter === 'upcoming' ? revision.next_revision > today :
+                filter === 'due' ? localDate(revision.next_revision) === today :
+                    filter === 'overdue' ? localDate(revision.next_revision) < today :
+                        filter === 'upcoming' ? localDate(revision.next_revision) > today :
                             true;
 
         const searchMatches =
@@ -127,36 +139,10 @@ export default function RevisionList() {
         openCodeModal();
     };
 
-    // Mark as revised
-    const handleMarkRevised = async (revision: RevisionProblem) => {
+    // Mark as revised, the server schedules the next revision from the grade of the review
+    const handleMarkRevised = async (revision: RevisionProblem, grade: number) => {
         try {
-            // Calculate next revision date based on confidence level and spaced repetition
-            const today = new Date();
-            let nextRevisionDays = 1;
-
-            if (revision.confidence_level === 1) {
-                nextRevisionDays = 1; // Review tomorrow
-            } else if (revision.confidence_level === 2) {
-                nextRevisionDays = 3; // Review in 3 days
-            } else if (revision.confidence_level === 3) {
-                nextRevisionDays = 7; // Review in a week
-            } else if (revision.confidence_level === 4) {
-                nextRevisionDays = 14; // Review in 2 weeks
-            } else {
-                nextRevisionDays = 30; // Review in a month
-            }
-
-            const nextRevision = new Date(today);
-            nextRevision.setDate(nextRevision.getDate() + nextRevisionDays);
-
-            const updatedRevision = {
-                ...revision,
-                last_revised: today.toISOString().split('T')[0],
-                next_revision: nextRevision.toISOString().split('T')[0],
-                revision_count: revision.revision_count + 1
-            };
-
-            await updateRevisionProblem(updatedRevision);
+            await recordReview(revision, grade);
             showNotification('Success', 'Problem marked as revised', 'green');
             loadRevisions();
         } catch (err) {
@@ -182,7 +168,12 @@ export default function RevisionList() {
         if (!editForm) return;
 
         try {
-            await updateRevisionProblem(editForm);
+            // the review state only moves through reviews, a new confidence level is recorded as one
+            const { confidence_level, ...edit } = editForm;
+            await updateRevisionProblem({ ...edit, confidence_level: selectedRevision?.confidence_level ?? confidence_level });
+            if (selectedRevision && confidence_level !== selectedRevision.confidence_level) {
+                await recordReview(editForm, confidence_level);
+            }
             showNotification('Success', 'Revision problem updated successfully', 'green');
             closeEditModal();
             loadRevisions();
@@ -194,11 +185,11 @@ export default function RevisionList() {
 
     // Determine row color based on revision status
     const getRowColor = (revision: RevisionProblem) => {
-        const today = new Date().toISOString().split('T')[0];
+        const today = localDate(new Date());
 
-        if (revision.next_revision < today) {
+        if (localDate(revision.next_revision) < today) {
             return 'rgba(255, 0, 0, 0.1)'; // Overdue - red
-        } else if (revision.next_revision === today) {
+        } else if (localDate(revision.next_revision) === today) {
             return 'rgba(255, 165, 0, 0.1)'; // Due today - orange
         }
         return undefined;
@@ -227,6 +218,17 @@ export default function RevisionList() {
         );
     }
 
+    const renderGradeItems = (revision: RevisionProblem) => (
+        <>
+            <Menu.Label>How did the revision go?</Menu.Label>
+            {reviewGrades.map(({ grade, label }) => (
+                <Menu.Item key={grade} onClick={() => handleMarkRevised(revision, grade)}>
+                    {grade} - {label}
+                </Menu.Item>
+            ))}
+        </>
+    );
+
     const renderTableRow = (revision: RevisionProblem) => (
         <>
             <Table.Tr
@@ -285,17 +287,17 @@ export default function RevisionList() {
                 <Table.Td>
                     <Group gap="xs" wrap="nowrap">
                         <IconCalendar size={16} stroke={1.5} color={
-                            revision.next_revision < new Date().toISOString().split('T')[0] ? 'red' :
-                                revision.next_revision === new Date().toISOString().split('T')[0] ? 'orange' : undefined
+                            localDate(revision.next_revision) < localDate(new Date()) ? 'red' :
+                                localDate(revision.next_revision) === localDate(new Date()) ? 'orange' : undefined
                         } />
                         <Text
                             size="sm"
                             c={
-                                revision.next_revision < new Date().toISOString().split('T')[0] ? 'red' :
-                                    revision.next_revision === new Date().toISOString().split('T')[0] ? 'orange' : undefined
+                                localDate(revision.next_revision) < localDate(new Date()) ? 'red' :
+                                    localDate(revision.next_revision) === localDate(new Date()) ? 'orange' : undefined
                             }
                             fw={
-                                revision.next_revision <= new Date().toISOString().split('T')[0] ? 'bold' : undefined
+                                localDate(revision.next_revision) <= localDate(new Date()) ? 'bold' : undefined
                             }
                         >
                             {new Date(revision.next_revision).toLocaleDateString()}
@@ -341,14 +343,18 @@ export default function RevisionList() {
                 </Table.Td>
                 <Table.Td style={{ textAlign: 'center' }}>
                     <Group align="center" gap="xs">
-                        <Tooltip label="Mark as Revised">
-                            <ActionIcon color="green" variant="light" onClick={(e) => {
-                                e.stopPropagation();
-                                handleMarkRevised(revision);
-                            }}>
-                                <IconCheck size={16} />
-                            </ActionIcon>
-                        </Tooltip>
+                        <Menu width={200} position="bottom-end" shadow="md">
+                            <Menu.Target>
+                                <Tooltip label="Mark as Revised">
+                                    <ActionIcon color="green" variant="light" onClick={(e) => e.stopPropagation()}>
+                                        <IconCheck size={16} />
+                                    </ActionIcon>
+                                </Tooltip>
+                            </Menu.Target>
+                            <Menu.Dropdown onClick={(e) => e.stopPropagation()}>
+                                {renderGradeItems(revision)}
+                            </Menu.Dropdown>
+                        </Menu>
                         <Tooltip label="View Code">
                             <ActionIcon color="blue" variant="light" onClick={(e) => {
                                 e.stopPropagation();
@@ -621,13 +627,13 @@ export default function RevisionList() {
                                                     size={24}
                                                     radius="xl"
                                                     color={
-                                                        revision.next_revision < new Date().toISOString().split('T')[0] ? 'red' :
-                                                            revision.next_revision === new Date().toISOString().split('T')[0] ? 'orange' : 'blue'
+                                                        localDate(revision.next_revision) < localDate(new Date()) ? 'red' :
+                                                            localDate(revision.next_revision) === localDate(new Date()) ? 'orange' : 'blue'
                                                     }
                                                 >
-                                                    {revision.next_revision < new Date().toISOString().split('T')[0] ? (
+                                                    {localDate(revision.next_revision) < localDate(new Date()) ? (
                                                         <IconAlertCircle size={16} />
-                                                    ) : revision.next_revision === new Date().toISOString().split('T')[0] ? (
+                                                    ) : localDate(revision.next_revision) === localDate(new Date()) ? (
                                                         <IconClockHour4 size={16} />
                                                     ) : (
                                                         <IconCalendar size={16} />
@@ -640,22 +646,28 @@ export default function RevisionList() {
                                                     <Group>
                                                         <Text size="sm" c="dimmed">Review on:</Text>
                                                         <Text fw={600} size="sm" c={
-                                                            revision.next_revision < new Date().toISOString().split('T')[0] ? 'red' :
-                                                                revision.next_revision === new Date().toISOString().split('T')[0] ? 'orange' : undefined
+                                                            localDate(revision.next_revision) < localDate(new Date()) ? 'red' :
+                                                                localDate(revision.next_revision) === localDate(new Date()) ? 'orange' : undefined
                                                         }>
                                                             {new Date(revision.next_revision).toLocaleDateString()}
                                                         </Text>
                                                     </Group>
 
-                                                    <Button
-                                                        variant="light"
-                                                        color="green"
-                                                        size="xs"
-                                                        leftSection={<IconCheck size={14} />}
-                                                        onClick={() => handleMarkRevised(revision)}
-                                                    >
-                                                        Mark as Revised
-                                                    </Button>
+                                                    <Menu width={200} position="bottom-end" shadow="md">
+                                                        <Menu.Target>
+                                                            <Button
+                                                                variant="light"
+                                                                color="green"
+                                                                size="xs"
+                                                                leftSection={<IconCheck size={14} />}
+                                                            >
+                                                                Mark as Revised
+                                                            </Button>
+                                                        </Menu.Target>
+                                                        <Menu.Dropdown>
+                                                            {renderGradeItems(revision)}
+                                                        </Menu.Dropdown>
+                                                    </Menu>
                                                 </Group>
 
                                                 <Group justify="space-between" mb="sm">
@@ -19,7 +19,7 @@ import SolutionComparison from './SolutionComparison';
 import OverallAnalysisComponent from "./OverallAnalysis";
 import { useNotifications } from "../contexts/NotificationContext";
 import { addRevisionProblem } from '../functions/revisionHelpers';
-import type { RevisionProblem } from '../types/types';
+import type { NewRevisionProblem } from '../types/types';
 
 interface SubmissionsDashboardProps {
     cookie: string;
@@ -31,9 +31,6 @@ const defaultRevisionFormData = {
     difficulty: 'Medium',
     tags: [],
     notes: '',
-    last_revised: new Date().toISOString(),
-    next_revision: new Date(Date.now() + 7 * 24 * 60 * 60 * 1000).toISOString(), // Set next revision to one week later
-    revision_count: 0,
     id: 0,
     lang: '',
     lang_name: '',
@@ -69,7 +66,7 @@ function SubmissionsDashboard({ cookie, onCookieClear }: SubmissionsDashboardPro
     const [selectedSubmissionForComparison, setSelectedSubmissionForComparison] = useState<Submission | null>(null);
     const [activeTab, setActiveTab] = useState<string | null>("submissions");
     const [addRevisionModalOpened, setAddRevisionModalOpened] = useState(false);
-    const [revisionForm, setRevisionForm] = useState<RevisionProblem>(defaultRevisionFormData);
+    const [revisionForm, setRevisionForm] = useState<NewRevisionProblem>(defaultRevisionFormData);
     const [submissionsLimit, setSubmissionsLimit] = useState<number>(20);
     const [hasInitiallyFetched, setHasInitiallyFetched] = useState(false); // New state to track if we've fetched data
     const { showNotification } = useNotifications();
@@ -169,9 +166,6 @@ function SubmissionsDashboard({ cookie, onCookieClear }: SubmissionsDashboardPro
             confidence_level: 3,
             difficulty: "NA",
             tags: [],
-            revision_count: 0,
-            last_revised: new Date().toISOString(),
-            next_revision: new Date(Date.now() + 7 * 24 * 60 * 60 * 1000).toISOString(),
         });
         setAddRevisionModalOpened(true);
     };
@@ -20,6 +20,7 @@ async function fetchFeedback(submission: Pick<Submission, 'id' | 'code' | 'lang'
             candidate_code: submission.code,
             lang: submission.lang,
             problem_statement: submission.title,
+            title_slug: submission.title_slug,
             problem_id: submission.id
         }),
     });
@@ -53,7 +54,9 @@ async function fetchSubmissions(cookie: string, submissionLimit: number,): Promi
         const errorData = await response.json().catch(() => ({ message: 'Failed to fetch submissions. Unknown error.' }));
         throw new Error(errorData.message || `HTTP error ${response.status}`);
     }
-    return response.json();
+    // the backend syncs new submissions into its store and answers with a page of them
+    const result = await response.json();
+    return { ...result, data: result.data?.submissions || [] };
 }
 // Simulated API call
 async function fetchComparisonData(submission: Submission): Promise<{ data: ComparisonData }> {
@@ -72,6 +75,7 @@ async function fetchComparisonData(submission: Submission): Promise<{ data: Comp
             candidate_code: submission.code,
             lang: submission.lang,
             problem_statement: submission.title,
+            title_slug: submission.title_slug,
             problem_id: submission.id
         }),
     });
@@ -1,25 +1,39 @@
 // eslint-disable-next-line @typescript-eslint/ban-ts-comment
 // @ts-expect-error
 import { auth } from "../firebase-config";
-import type { RevisionProblem } from '../types/types';
+import type { NewRevisionProblem, RevisionProblem } from '../types/types';
 // const API_BASE_URL = 'http://localhost:8080';
 const API_BASE_URL = 'https://leetcode-project-backend-1082156221911.europe-west1.run.app'
 
+// fetchRevisions follows the pages of GET /api/revisions and returns every revision in data
 export async function fetchRevisions() {
     const token = await auth.currentUser?.getIdToken();
-    const response = await fetch(API_BASE_URL + '/api/revisions', {
-        headers: {
-            'Authorization': `Bearer ${token}`,
-            'Content-Type': 'application/json',
+    const revisions: RevisionProblem[] = [];
+    let cursor = '';
+    let result;
+    do {
+        const params = new URLSearchParams({ limit: '200' });
+        if (cursor) {
+            params.set('cursor', cursor);
+        }
+        const response = await fetch(API_BASE_URL + '/api/revisions?' + params.toString(), {
+            headers: {
+                'Authorization': `Bearer ${token}`,
+                'Content-Type': 'application/json',
+
+            }
+        });
 
+        if (!response.ok) {
+            throw new Error(`Failed to fetch revisions: ${response.status}`);
         }
-    });
 
-    if (!response.ok) {
-        throw new Error(`Failed to fetch revisions: ${response.status}`);
-    }
+        result = await response.json();
+        revisions.push(...(result.data?.problems || []));
+        cursor = result.data?.next_cursor || '';
+    } while (cursor);
 
-    return await response.json();
+    return { ...result, data: revisions };
 }
 
 export async function fetchDueRevisions() {
@@ -39,7 +53,7 @@ export async function fetchDueRevisions() {
     return await response.json();
 }
 
-export async function addRevisionProblem(problems: RevisionProblem[]) {
+export async function addRevisionProblem(problems: NewRevisionProblem[]) {
     const token = await auth.currentUser?.getIdToken();
     const response = await fetch(API_BASE_URL + '/api/revisions', {
         method: 'POST',
@@ -75,6 +89,26 @@ export async function updateRevisionProblem(problem: RevisionProblem) {
     return await response.json();
 }
 
+// recordReview posts a review with its grade (1-5), the server moves the confidence level,
+// the counters and the next revision date
+export async function recordReview(problem: RevisionProblem, grade: number) {
+    const token = await auth.currentUser?.getIdToken();
+    const response = await fetch(API_BASE_URL + '/api/revisions/' + encodeURIComponent(problem.problem_id || '') + '/reviews', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+            'Authorization': `Bearer ${token}`
+        },
+        body: JSON.stringify({ grade })
+    });
+
+    if (!response.ok) {
+        throw new Error(`Failed to record review: ${response.status}`);
+    }
+
+    return await response.json();
+}
+
 export async function deleteRevisionProblem(problem: RevisionProblem) {
     const token = await auth.currentUser?.getIdToken();
     const response = await fetch(API_BASE_URL + '/api/revisions', {
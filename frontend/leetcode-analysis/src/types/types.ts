@@ -9,6 +9,7 @@ interface Submission {
     url: string;
     isPending: string;
     title: string;
+    title_slug?: string;
     memory: string;
     code: string;
     isBestSolution: boolean;
@@ -79,6 +80,7 @@ interface OverallAnalysis {
     commonMistakesSummary: string | null;
 }
 interface RevisionProblem extends Submission {
+    problem_id?: string;
     notes: string;
     last_revised: string;
     next_revision: string;
@@ -88,6 +90,9 @@ interface RevisionProblem extends Submission {
     tags: string[];
 }
 
+// NewRevisionProblem is a problem added to the revisions, the server schedules its first revision
+type NewRevisionProblem = Omit<RevisionProblem, 'last_revised' | 'next_revision' | 'revision_count'>;
+
 interface RevisionList {
     userId: string;
     revisions: RevisionProblem[];
@@ -102,5 +107,6 @@ export type {
     PatternInfo,
     OverallAnalysis,
     RevisionProblem,
+    NewRevisionProblem,
     RevisionList
 }
\ No newline at end of file